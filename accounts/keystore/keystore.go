@@ -69,6 +69,10 @@ type KeyStore struct {
 	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
 	updating    bool                    // Whether the event notification loop is running
 
+	stakingPolicy string                  // Path of the staking policy file
+	staking       map[common.Address]bool // Accounts unlocked by the staking policy
+	stakingMu     sync.Mutex              // Serializes staking policy updates
+
 	mu sync.RWMutex
 }
 
@@ -349,6 +353,12 @@ func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout t
 		return err
 	}
 
+	ks.unlockKey(a, key, timeout, stakingOnly)
+	return nil
+}
+
+// unlockKey installs the decrypted key of the account.
+func (ks *KeyStore) unlockKey(a accounts.Account, key *Key, timeout time.Duration, stakingOnly bool) {
 	ks.mu.Lock()
 	defer ks.mu.Unlock()
 	u, found := ks.unlocked[a.Address]
@@ -362,7 +372,7 @@ func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout t
 			if !stakingOnly {
 				ks.unlocked[a.Address].stakingOnly = stakingOnly
 			}
-			return nil
+			return
 		}
 		// Terminate the expire goroutine and replace it below.
 		close(u.abort)
@@ -374,7 +384,6 @@ func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout t
 		u = &unlocked{Key: key, stakingOnly: stakingOnly}
 	}
 	ks.unlocked[a.Address] = u
-	return nil
 }
 
 // Find resolves the given account into a unique entry in the keystore.
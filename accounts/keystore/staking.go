@@ -0,0 +1,240 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"nuclear/core/nuclear/accounts"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/log"
+)
+
+var (
+	ErrNoStakingPolicy = errors.New("no staking policy configured")
+)
+
+// StakingPassword names the source of an account password. Exactly one of
+// the sources must be set.
+type StakingPassword struct {
+	File    string `json:"file,omitempty"`    // first line of a file
+	Env     string `json:"env,omitempty"`     // environment variable
+	Keyring string `json:"keyring,omitempty"` // entry of the policy keyring
+}
+
+// StakingAccount is a policy entry of an account allowed to stake.
+type StakingAccount struct {
+	Address  common.Address  `json:"address"`
+	Password StakingPassword `json:"password"`
+}
+
+// StakingPolicy lists the accounts to be unlocked for staking only.
+//
+// The keyring is a stand-in for an OS keyring: a directory with a password
+// file per entry, which must not be accessible by other users. Relative paths
+// are resolved against the directory of the policy file.
+type StakingPolicy struct {
+	Keyring  string           `json:"keyring,omitempty"`
+	Accounts []StakingAccount `json:"accounts"`
+
+	dir string
+}
+
+// LoadStakingPolicy reads the policy from a JSON file.
+func LoadStakingPolicy(path string) (*StakingPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &StakingPolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid staking policy %s: %v", path, err)
+	}
+
+	policy.dir = filepath.Dir(path)
+
+	seen := make(map[common.Address]bool, len(policy.Accounts))
+	for _, a := range policy.Accounts {
+		if seen[a.Address] {
+			return nil, fmt.Errorf("duplicate staking account %s", a.Address.Hex())
+		}
+		seen[a.Address] = true
+	}
+
+	return policy, nil
+}
+
+func (p *StakingPolicy) path(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(p.dir, file)
+}
+
+// Password resolves the password of the policy entry.
+func (p *StakingPolicy) Password(a *StakingAccount) (string, error) {
+	src := &a.Password
+
+	switch {
+	case src.File != "" && src.Env == "" && src.Keyring == "":
+		return readPasswordFile(p.path(src.File))
+
+	case src.Env != "" && src.File == "" && src.Keyring == "":
+		password, ok := os.LookupEnv(src.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", src.Env)
+		}
+		return password, nil
+
+	case src.Keyring != "" && src.File == "" && src.Env == "":
+		if p.Keyring == "" {
+			return "", errors.New("staking policy keyring is not configured")
+		}
+		if src.Keyring != filepath.Base(src.Keyring) {
+			return "", fmt.Errorf("invalid keyring entry %q", src.Keyring)
+		}
+
+		path := filepath.Join(p.path(p.Keyring), src.Keyring)
+		if fi, err := os.Stat(path); err != nil {
+			return "", err
+		} else if runtime.GOOS != "windows" && fi.Mode().Perm()&0077 != 0 {
+			return "", fmt.Errorf("keyring entry %s is accessible by other users", path)
+		}
+		return readPasswordFile(path)
+	}
+
+	return "", fmt.Errorf("exactly one password source is required for %s", a.Address.Hex())
+}
+
+func readPasswordFile(path string) (string, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(strings.SplitN(string(text), "\n", 2)[0], "\r"), nil
+}
+
+// SetStakingPolicy unlocks the accounts of the policy file for staking only
+// and remembers the file for later rotation.
+func (ks *KeyStore) SetStakingPolicy(path string) ([]common.Address, error) {
+	ks.stakingMu.Lock()
+	defer ks.stakingMu.Unlock()
+
+	res, err := ks.applyStakingPolicy(path)
+	if err == nil {
+		ks.stakingPolicy = path
+	}
+	return res, err
+}
+
+// RotateStaking reloads the staking policy file. Accounts added to the policy
+// get unlocked for staking only and the removed ones get locked, unless they
+// were fully unlocked meanwhile. No change is made if any of the accounts
+// fails to unlock.
+func (ks *KeyStore) RotateStaking() ([]common.Address, error) {
+	ks.stakingMu.Lock()
+	defer ks.stakingMu.Unlock()
+
+	if ks.stakingPolicy == "" {
+		return nil, ErrNoStakingPolicy
+	}
+
+	return ks.applyStakingPolicy(ks.stakingPolicy)
+}
+
+// StakingAccounts returns the accounts unlocked by the staking policy.
+func (ks *KeyStore) StakingAccounts() []common.Address {
+	ks.stakingMu.Lock()
+	defer ks.stakingMu.Unlock()
+
+	res := make([]common.Address, 0, len(ks.staking))
+	for addr := range ks.staking {
+		res = append(res, addr)
+	}
+	return res
+}
+
+func (ks *KeyStore) applyStakingPolicy(path string) ([]common.Address, error) {
+	policy, err := LoadStakingPolicy(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt all the keys first to keep the current set on failure
+	keys := make([]*Key, 0, len(policy.Accounts))
+	found := make([]accounts.Account, 0, len(policy.Accounts))
+	abort := func() {
+		for _, key := range keys {
+			zeroKey(key.PrivateKey)
+		}
+	}
+
+	for i := range policy.Accounts {
+		entry := &policy.Accounts[i]
+
+		password, err := policy.Password(entry)
+		if err != nil {
+			abort()
+			return nil, err
+		}
+
+		a, key, err := ks.getDecryptedKey(accounts.Account{Address: entry.Address}, password)
+		if err != nil {
+			abort()
+			return nil, fmt.Errorf("failed to unlock %s: %v", entry.Address.Hex(), err)
+		}
+
+		keys = append(keys, key)
+		found = append(found, a)
+	}
+
+	staking := make(map[common.Address]bool, len(found))
+	res := make([]common.Address, 0, len(found))
+	for i, a := range found {
+		ks.unlockKey(a, keys[i], 0, true)
+		staking[a.Address] = true
+		res = append(res, a.Address)
+		log.Info("Unlocked account for staking", "address", a.Address.Hex())
+	}
+
+	for addr := range ks.staking {
+		if staking[addr] {
+			continue
+		}
+
+		ks.mu.RLock()
+		u, ok := ks.unlocked[addr]
+		ks.mu.RUnlock()
+
+		if ok && u.stakingOnly {
+			ks.Lock(addr)
+			log.Info("Locked staking account", "address", addr.Hex())
+		}
+	}
+
+	ks.staking = staking
+	return res, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"nuclear/core/nuclear/core/types"
+)
+
+func writeStakingPolicy(t *testing.T, path string, policy *StakingPolicy) {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStakingPolicy(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	policyDir, err := ioutil.TempDir("", "eth-staking-policy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(policyDir)
+
+	a1, err := ks.NewAccount("pass1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := ks.NewAccount("pass2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a3, err := ks.NewAccount("pass3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Password sources
+	if err := ioutil.WriteFile(filepath.Join(policyDir, "pass1"), []byte("pass1\r\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("NUCLEAR_TEST_STAKING_PASS2", "pass2")
+	defer os.Unsetenv("NUCLEAR_TEST_STAKING_PASS2")
+	keyring := filepath.Join(policyDir, "keyring")
+	if err := os.Mkdir(keyring, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(keyring, "a3"), []byte("pass3"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(policyDir, "policy.json")
+	writeStakingPolicy(t, path, &StakingPolicy{
+		Keyring: "keyring",
+		Accounts: []StakingAccount{
+			{Address: a1.Address, Password: StakingPassword{File: "pass1"}},
+			{Address: a2.Address, Password: StakingPassword{Env: "NUCLEAR_TEST_STAKING_PASS2"}},
+		},
+	})
+
+	if _, err := ks.RotateStaking(); err != ErrNoStakingPolicy {
+		t.Fatalf("wrong error for missing policy: %v", err)
+	}
+
+	unlocked, err := ks.SetStakingPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unlocked) != 2 {
+		t.Fatalf("wrong number of unlocked accounts: %d", len(unlocked))
+	}
+
+	wallet := ks.Wallets()[0]
+	for _, w := range ks.Wallets() {
+		if w.Contains(a1) {
+			wallet = w
+		}
+	}
+	if !wallet.IsUnlockedForStaking(a1) {
+		t.Fatal("account not unlocked for staking")
+	}
+	if _, err := ks.SignHash(a1, testSigData); err != nil {
+		t.Fatalf("staking hash signing failed: %v", err)
+	}
+	if _, err := ks.SignTx(a1, new(types.Transaction), nil); err != ErrStaking {
+		t.Fatalf("transaction signing must be refused: %v", err)
+	}
+
+	// Full unlock of a2 must survive its removal from the policy
+	if err := ks.Unlock(a2, "pass2", false); err != nil {
+		t.Fatal(err)
+	}
+
+	writeStakingPolicy(t, path, &StakingPolicy{
+		Keyring: "keyring",
+		Accounts: []StakingAccount{
+			{Address: a3.Address, Password: StakingPassword{Keyring: "a3"}},
+		},
+	})
+	if _, err := ks.RotateStaking(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SignHash(a1, testSigData); err != ErrLocked {
+		t.Fatalf("removed staking account must be locked: %v", err)
+	}
+	if _, err := ks.SignTx(a2, new(types.Transaction), nil); err != nil {
+		t.Fatalf("fully unlocked account got locked: %v", err)
+	}
+	if _, err := ks.SignHash(a3, testSigData); err != nil {
+		t.Fatalf("keyring account not unlocked: %v", err)
+	}
+	if accs := ks.StakingAccounts(); len(accs) != 1 || accs[0] != a3.Address {
+		t.Fatalf("wrong staking accounts: %v", accs)
+	}
+
+	// Failed rotation keeps the current set
+	writeStakingPolicy(t, path, &StakingPolicy{
+		Accounts: []StakingAccount{
+			{Address: a1.Address, Password: StakingPassword{File: "pass1"}},
+			{Address: a3.Address, Password: StakingPassword{Keyring: "a3"}},
+		},
+	})
+	if _, err := ks.RotateStaking(); err == nil {
+		t.Fatal("rotation without keyring must fail")
+	}
+	if _, err := ks.SignHash(a1, testSigData); err != ErrLocked {
+		t.Fatalf("account unlocked by failed rotation: %v", err)
+	}
+	if _, err := ks.SignHash(a3, testSigData); err != nil {
+		t.Fatalf("account locked by failed rotation: %v", err)
+	}
+
+	// Keyring entries accessible by others are refused
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(filepath.Join(keyring, "a3"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		policy := &StakingPolicy{Keyring: keyring}
+		entry := &StakingAccount{Address: a3.Address, Password: StakingPassword{Keyring: "a3"}}
+		if _, err := policy.Password(entry); err == nil {
+			t.Fatal("insecure keyring entry accepted")
+		}
+	}
+
+	// Ambiguous password source
+	policy := &StakingPolicy{}
+	entry := &StakingAccount{Password: StakingPassword{File: "a", Env: "b"}}
+	if _, err := policy.Password(entry); err == nil {
+		t.Fatal("multiple password sources accepted")
+	}
+}
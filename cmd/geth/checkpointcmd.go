@@ -0,0 +1,138 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"nuclear/core/nuclear/cmd/utils"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	checkpointAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: node.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	checkpointOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Output file (default: stdout)",
+	}
+	checkpointCommand = cli.Command{
+		Name:     "checkpoint",
+		Usage:    "Checkpoint export and import",
+		Category: "NUCLEAR COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(checkpointExport),
+				Name:      "export",
+				Usage:     "Export validated checkpoints of a running node",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					checkpointAttachFlag,
+					checkpointOutputFlag,
+				},
+				Description: `
+Export all validated checkpoints of the attached node as a JSON list, the
+most recent first. Every checkpoint carries its signatures with the CPP
+signer one going first:
+
+[{"number": 1, "hash": "0x...", "since": 2, "signatures": ["0x..."]}]
+
+The output can be passed to "checkpoint import" or to the --checkpoint
+option of a new node.`,
+			},
+			{
+				Action:    utils.MigrateFlags(checkpointImport),
+				Name:      "import",
+				Usage:     "Import signed checkpoints into a running node",
+				ArgsUsage: "<checkpoint.json>",
+				Flags: []cli.Flag{
+					checkpointAttachFlag,
+				},
+				Description: `
+Import either a single signed checkpoint or a list of them as produced by
+"checkpoint export". Each checkpoint must be signed by the CPP signer.`,
+			},
+		},
+	}
+)
+
+// checkpointExport writes out the validated checkpoints of a running node.
+func checkpointExport(ctx *cli.Context) error {
+	client, err := dialRPC(ctx.String(checkpointAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to nuclear node: %v", err)
+	}
+	defer client.Close()
+
+	var checkpoints []core.SignedCheckpoint
+	if err := client.Call(&checkpoints, "admin_checkpointExport"); err != nil {
+		utils.Fatalf("Failed to export checkpoints: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if file := ctx.String(checkpointOutputFlag.Name); file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			utils.Fatalf("Failed to create output: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(checkpoints); err != nil {
+		utils.Fatalf("Failed to write output: %v", err)
+	}
+
+	return nil
+}
+
+// checkpointImport feeds signed checkpoints to a running node.
+func checkpointImport(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	checkpoints, err := core.ReadCheckpointFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read checkpoints: %v", err)
+	}
+
+	client, err := dialRPC(ctx.String(checkpointAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to nuclear node: %v", err)
+	}
+	defer client.Close()
+
+	var imported int
+	err = client.Call(&imported, "admin_checkpointImport", checkpoints)
+	fmt.Printf("Imported %d of %d checkpoints\n", imported, len(checkpoints))
+	if err != nil {
+		utils.Fatalf("Failed to import checkpoints: %v", err)
+	}
+
+	return nil
+}
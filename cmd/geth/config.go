@@ -22,8 +22,10 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"os"
 	"reflect"
+	"strings"
 	"unicode"
 
 	cli "gopkg.in/urfave/cli.v1"
@@ -36,6 +38,9 @@ import (
 	"nuclear/core/nuclear/params"
 	whisper "nuclear/core/nuclear/whisper/whisperv6"
 	"github.com/naoina/toml"
+
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi_svc "nuclear/core/nuclear/energi/service"
 )
 
 var (
@@ -185,15 +190,29 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 
 	utils.RegisterDynamicCheckpointService(stack)
 
+	energi_common.AllowLoopbackMasternodes(ctx.GlobalBool(utils.MasternodeLoopbackFlag.Name))
+
 	if ctx.GlobalBool(utils.MasternodeFlag.Name) {
-		var owner common.Address
+		mncfg := &energi_svc.MasternodeConfig{
+			MeshSize:         ctx.GlobalInt(utils.MasternodeMeshSizeFlag.Name),
+			CheckpointGossip: ctx.GlobalBool(utils.MasternodeCheckpointGossipFlag.Name),
+		}
 		if ownerStr := ctx.GlobalString(utils.MasternodeOwnerFlag.Name); ownerStr != "" {
 			if !common.IsHexAddress(ownerStr) {
 				utils.Fatalf("Invalid owner address was set as an argument")
 			}
-			owner = common.HexToAddress(ownerStr)
+			mncfg.Owner = common.HexToAddress(ownerStr)
+		}
+		if addrsStr := ctx.GlobalString(utils.MasternodeAddrsFlag.Name); addrsStr != "" {
+			for _, addrStr := range strings.Split(addrsStr, ",") {
+				ip := net.ParseIP(strings.TrimSpace(addrStr))
+				if !energi_common.IsPublicIP(ip) {
+					utils.Fatalf("Invalid masternode address: %v", addrStr)
+				}
+				mncfg.Addrs = append(mncfg.Addrs, ip)
+			}
 		}
-		utils.RegisterMasternodeService(stack, owner)
+		utils.RegisterMasternodeService(stack, mncfg)
 	}
 
 	return stack
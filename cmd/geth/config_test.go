@@ -0,0 +1,64 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nuclear/core/nuclear/eth"
+)
+
+func TestAutocollateralConfig(t *testing.T) {
+	cfg := gethConfig{Eth: eth.DefaultConfig}
+	cfg.Eth.MinerAutocollateral = false
+
+	out, err := tomlSettings.Marshal(&cfg.Eth)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	if !bytes.Contains(out, []byte("MinerAutocollateral = false")) {
+		t.Fatalf("disabled autocollateralize is not dumped:\n%s", out)
+	}
+
+	tests := map[string]bool{
+		"false": false,
+		"true":  true,
+		"0":     false,
+		"1":     true,
+		"2":     true,
+	}
+	for value, expected := range tests {
+		cfg := gethConfig{Eth: eth.DefaultConfig}
+		cfg.Eth.MinerAutocollateral = !expected
+
+		input := "[Eth]\nMinerAutocollateral = " + value + "\n"
+		if err := tomlSettings.NewDecoder(strings.NewReader(input)).Decode(&cfg); err != nil {
+			t.Fatalf("value %s: failed to decode: %v", value, err)
+		}
+		if cfg.Eth.MinerAutocollateral != expected {
+			t.Errorf("value %s: got %v, expected %v", value, cfg.Eth.MinerAutocollateral, expected)
+		}
+	}
+
+	cfg = gethConfig{Eth: eth.DefaultConfig}
+	input := "[Eth]\nMinerAutocollateral = 3\n"
+	if err := tomlSettings.NewDecoder(strings.NewReader(input)).Decode(&cfg); err == nil {
+		t.Fatalf("invalid mode is accepted")
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"nuclear/core/nuclear/accounts/keystore"
+	"nuclear/core/nuclear/cmd/utils"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/node"
+	"nuclear/core/nuclear/p2p/enode"
+	"nuclear/core/nuclear/params"
+	"github.com/shengdoushi/base58"
+	"gopkg.in/urfave/cli.v1"
+
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+var (
+	devnetOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Value: "devnet",
+		Usage: "Output directory, must not exist",
+	}
+	devnetNodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Value: 4,
+		Usage: "Number of staking nodes",
+	}
+	devnetMasternodesFlag = cli.IntFlag{
+		Name:  "masternodes",
+		Value: 3,
+		Usage: "Number of the nodes which are pre-registered masternodes",
+	}
+	devnetChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Value: 59797,
+		Usage: "Chain ID and network ID of the developer network",
+	}
+	devnetBalanceFlag = cli.Uint64Flag{
+		Name:  "balance",
+		Value: 1000000,
+		Usage: "Staking balance of every node account in NRG",
+	}
+	devnetCollateralFlag = cli.Uint64Flag{
+		Name:  "collateral",
+		Value: 10000,
+		Usage: "Collateral of every masternode in NRG",
+	}
+	devnetIPFlag = cli.StringFlag{
+		Name:  "ip",
+		Value: "192.0.2.1",
+		Usage: "Public IPv4 address of the masternodes in the registry, which refuses loopback addresses",
+	}
+	devnetPortFlag = cli.IntFlag{
+		Name:  "port",
+		Value: 40000,
+		Usage: "P2P port of the first node, HTTP-RPC ports start at port+1000",
+	}
+	devnetCommand = cli.Command{
+		Action:    utils.MigrateFlags(devnetCreate),
+		Name:      "devnet",
+		Usage:     "Generate a local multi-node developer network",
+		ArgsUsage: " ",
+		Category:  "NUCLEAR COMMANDS",
+		Flags: []cli.Flag{
+			devnetOutputFlag,
+			devnetNodesFlag,
+			devnetMasternodesFlag,
+			devnetChainIDFlag,
+			devnetBalanceFlag,
+			devnetCollateralFlag,
+			devnetIPFlag,
+			devnetPortFlag,
+		},
+		Description: `
+Generate everything needed to run a private network of local nodes:
+
+  genesis.json       custom genesis with the funded node accounts, the
+                     masternode collateral deposited and the masternodes
+                     announced
+  password.txt       password of all the generated accounts
+  node<N>/           data directory with the account key in the keystore,
+                     the node key and the config.toml of the node
+  devnet.json        accounts, masternodes and endpoints of the nodes
+  migration.json     Gen 2 snapshot for the migration block
+  start.sh           starts all the nodes in the background
+
+The CPP, EBI and migration signer keys are put into the keystore of the
+first node. The masternode address of a node is derived from its node key.
+The nodes connect to each other as static peers on the loopback interface.
+
+The masternode registry accepts only public addresses, so the masternodes
+announce the loopback address and their listen ports in their ENR instead.
+The nodes are started with --masternode.loopback to accept such records.`,
+	}
+)
+
+// devnetConfig is the part of gethConfig written for the nodes, everything
+// else keeps the defaults.
+type devnetConfig struct {
+	Eth struct {
+		NetworkId uint64
+	}
+	Node node.Config
+}
+
+type devnetNode struct {
+	DataDir    string          `json:"datadir"`
+	Account    common.Address  `json:"account"`
+	Masternode *common.Address `json:"masternode,omitempty"`
+	Enode      string          `json:"enode"`
+	HTTP       string          `json:"http"`
+}
+
+type devnetInfo struct {
+	ChainID         uint64         `json:"chainId"`
+	CPPSigner       common.Address `json:"cppSigner"`
+	EBISigner       common.Address `json:"ebiSigner"`
+	MigrationSigner common.Address `json:"migrationSigner"`
+	Nodes           []devnetNode   `json:"nodes"`
+}
+
+func devnetKey() *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		utils.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
+
+// devnetMigration returns a Gen 2 snapshot which fills the gas limit of the
+// migration block with small amounts of the owner.
+func devnetMigration(owner common.Address) interface{} {
+	type snapshotItem struct {
+		Owner  string   `json:"owner"`
+		Amount *big.Int `json:"amount"`
+		Atype  string   `json:"type"`
+	}
+
+	// Gen 2 address with checksum
+	gen2_owner := append([]byte{33}, owner.Bytes()...)
+	checksum := sha256.Sum256(gen2_owner)
+	gen2_owner = append(gen2_owner, checksum[:4]...)
+
+	items := make([]snapshotItem, params.MinGasLimit/100000)
+	for i := range items {
+		items[i] = snapshotItem{
+			Owner:  base58.Encode(gen2_owner, base58.BitcoinAlphabet),
+			Amount: big.NewInt(1),
+			Atype:  "pubkeyhash",
+		}
+	}
+
+	return map[string]interface{}{
+		"snapshot_utxos":     items,
+		"snapshot_blacklist": []string{},
+		"snapshot_hash":      hex.EncodeToString(checksum[:]),
+	}
+}
+
+// devnetCreate writes out the genesis and the node configurations of a new
+// developer network.
+func devnetCreate(ctx *cli.Context) error {
+	nodes := ctx.Int(devnetNodesFlag.Name)
+	masternodes := ctx.Int(devnetMasternodesFlag.Name)
+	if nodes < 1 || masternodes < 0 || masternodes > nodes {
+		utils.Fatalf("Invalid number of nodes or masternodes")
+	}
+
+	collateral := ctx.Uint64(devnetCollateralFlag.Name)
+	if collateral == 0 || collateral%1000 != 0 {
+		utils.Fatalf("Collateral must be a multiple of 1000 NRG")
+	}
+
+	ip := net.ParseIP(ctx.String(devnetIPFlag.Name)).To4()
+	if ip == nil || !energi_common.IsPublicIP(ip) {
+		utils.Fatalf("Masternode IP must be a public IPv4 address")
+	}
+	ipv4address := uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+
+	out, err := filepath.Abs(ctx.String(devnetOutputFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid output directory: %v", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		utils.Fatalf("Output directory already exists: %v", out)
+	}
+	if err := os.MkdirAll(out, 0700); err != nil {
+		utils.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	password_buf := make([]byte, 16)
+	if _, err := rand.Read(password_buf); err != nil {
+		utils.Fatalf("Failed to generate password: %v", err)
+	}
+	password := hex.EncodeToString(password_buf)
+	if err := ioutil.WriteFile(filepath.Join(out, "password.txt"), []byte(password), 0600); err != nil {
+		utils.Fatalf("Failed to write password: %v", err)
+	}
+
+	chain_id := ctx.Uint64(devnetChainIDFlag.Name)
+	port := ctx.Int(devnetPortFlag.Name)
+	nrg := big.NewInt(params.Ether)
+
+	cpp_key, ebi_key, migration_key := devnetKey(), devnetKey(), devnetKey()
+
+	info := &devnetInfo{
+		ChainID:         chain_id,
+		CPPSigner:       crypto.PubkeyToAddress(cpp_key.PublicKey),
+		EBISigner:       crypto.PubkeyToAddress(ebi_key.PublicKey),
+		MigrationSigner: crypto.PubkeyToAddress(migration_key.PublicKey),
+	}
+
+	cfg := &core.DevnetConfig{
+		ChainID: new(big.Int).SetUint64(chain_id),
+		// The initial balances are mature for staking right away
+		Timestamp: uint64(time.Now().Unix()) - energi_params.MaturityPeriod,
+		Signers: params.NuclearConfig{
+			CPPSigner:       info.CPPSigner,
+			EBISigner:       info.EBISigner,
+			MigrationSigner: info.MigrationSigner,
+		},
+		Alloc: make(core.GenesisAlloc),
+	}
+
+	balance := new(big.Int).Mul(new(big.Int).SetUint64(ctx.Uint64(devnetBalanceFlag.Name)), nrg)
+	node_keys := make([]*ecdsa.PrivateKey, nodes)
+	enodes := make([]*enode.Node, nodes)
+
+	for i := 0; i < nodes; i++ {
+		datadir := filepath.Join(out, fmt.Sprintf("node%d", i))
+
+		ks := keystore.NewKeyStore(
+			filepath.Join(datadir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+		account, err := ks.ImportECDSA(devnetKey(), password)
+		if err != nil {
+			utils.Fatalf("Failed to store account: %v", err)
+		}
+
+		if i == 0 {
+			cfg.Signers.BackboneAddress = account.Address
+
+			for _, key := range []*ecdsa.PrivateKey{cpp_key, ebi_key, migration_key} {
+				if _, err := ks.ImportECDSA(key, password); err != nil {
+					utils.Fatalf("Failed to store signer: %v", err)
+				}
+			}
+		}
+
+		node_keys[i] = devnetKey()
+		if err := os.MkdirAll(filepath.Join(datadir, clientIdentifier), 0700); err != nil {
+			utils.Fatalf("Failed to create data directory: %v", err)
+		}
+		if err := crypto.SaveECDSA(filepath.Join(datadir, clientIdentifier, "nodekey"), node_keys[i]); err != nil {
+			utils.Fatalf("Failed to store node key: %v", err)
+		}
+
+		enodes[i] = enode.NewV4(&node_keys[i].PublicKey, net.IPv4(127, 0, 0, 1), port+i, port+i)
+		cfg.Alloc[account.Address] = core.GenesisAccount{Balance: balance}
+
+		node := devnetNode{
+			DataDir: datadir,
+			Account: account.Address,
+			Enode:   enodes[i].String(),
+			HTTP:    fmt.Sprintf("http://127.0.0.1:%d", port+1000+i),
+		}
+
+		if i < masternodes {
+			masternode := crypto.PubkeyToAddress(node_keys[i].PublicKey)
+			node.Masternode = &masternode
+
+			cfg.Masternodes = append(cfg.Masternodes, core.DevnetMasternode{
+				Owner:      account.Address,
+				Masternode: masternode,
+				Collateral: new(big.Int).Mul(new(big.Int).SetUint64(collateral), nrg),
+				IPv4:       ipv4address,
+				Enode:      energi_common.MasternodePubkey(&node_keys[i].PublicKey),
+			})
+		}
+
+		info.Nodes = append(info.Nodes, node)
+	}
+
+	genesis, err := core.DevnetGenesisBlock(cfg)
+	if err != nil {
+		utils.Fatalf("Failed to create genesis: %v", err)
+	}
+	devnetWriteJSON(filepath.Join(out, "genesis.json"), genesis)
+
+	// Node configs with all the other nodes as static peers
+	for i, node := range info.Nodes {
+		config := devnetConfig{Node: defaultNodeConfig()}
+		config.Eth.NetworkId = chain_id
+		config.Node.DataDir = node.DataDir
+		config.Node.HTTPHost = "127.0.0.1"
+		config.Node.HTTPPort = port + 1000 + i
+		config.Node.P2P.ListenAddr = fmt.Sprintf("127.0.0.1:%d", port+i)
+		config.Node.P2P.NoDiscovery = true
+		config.Node.P2P.StaticNodes = nil
+		for j, peer := range enodes {
+			if j != i {
+				config.Node.P2P.StaticNodes = append(config.Node.P2P.StaticNodes, peer)
+			}
+		}
+
+		toml, err := tomlSettings.Marshal(&config)
+		if err != nil {
+			utils.Fatalf("Failed to encode config: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(node.DataDir, "config.toml"), toml, 0600); err != nil {
+			utils.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	devnetWriteJSON(filepath.Join(out, "devnet.json"), info)
+
+	// The first block must be the Gen 2 migration, it is mined by the first node
+	devnetWriteJSON(filepath.Join(out, "migration.json"), devnetMigration(info.Nodes[0].Account))
+
+	// Start script
+	script := new(bytes.Buffer)
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("# Starts the developer network nodes, logs are in the node directories.\n")
+	script.WriteString("NUCLEAR=${NUCLEAR:-" + clientIdentifier + "}\n")
+	script.WriteString("cd \"$(dirname \"$0\")\"\n")
+	for i, node := range info.Nodes {
+		dir := fmt.Sprintf("node%d", i)
+		unlock := node.Account.Hex()
+		if i == 0 {
+			unlock += "," + info.MigrationSigner.Hex()
+		}
+		fmt.Fprintf(script,
+			"\"$NUCLEAR\" --config %s/config.toml --init genesis.json --mine"+
+				" --unlock %s --unlock.staking --password password.txt"+
+				" --nat extip:127.0.0.1 --masternode.loopback",
+			dir, unlock)
+		if i == 0 {
+			fmt.Fprintf(script, " --miner.migration migration.json --miner.dpos %s=%s",
+				energi_params.Nuclear_MigrationContract.Hex(), info.MigrationSigner.Hex())
+		}
+		if node.Masternode != nil {
+			script.WriteString(" --masternode")
+		}
+		fmt.Fprintf(script, " \"$@\" > %s/%s.log 2>&1 &\n", dir, clientIdentifier)
+	}
+	script.WriteString("wait\n")
+
+	if err := ioutil.WriteFile(filepath.Join(out, "start.sh"), script.Bytes(), 0700); err != nil {
+		utils.Fatalf("Failed to write start script: %v", err)
+	}
+
+	fmt.Printf("Developer network of %d nodes and %d masternodes is in %s\n", nodes, masternodes, out)
+	return nil
+}
+
+func devnetWriteJSON(path string, value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode %s: %v", path, err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		utils.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"nuclear/core/nuclear/cmd/utils"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	genesisJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the inspection as JSON",
+	}
+	genesisCommand = cli.Command{
+		Name:     "genesis",
+		Usage:    "Genesis block tools",
+		Category: "NUCLEAR COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(genesisInspect),
+				Name:      "inspect",
+				Usage:     "Inspect the governance deployment of a genesis",
+				ArgsUsage: "[mainnet|testnet|<genesis.json>]",
+				Flags: []cli.Flag{
+					genesisJSONFlag,
+				},
+				Description: `
+Build the genesis state in memory and list the system contracts deployed by
+it: the proxy and implementation relations, the code hashes, the storage set
+by the constructors and the balances. A custom genesis file is processed the
+same way as by the init command. The default is mainnet.
+
+The genesis hash of the mainnet and testnet chain IDs must match the known
+one and the governed proxies must point to their genesis implementations.
+The command fails unless all the checks pass.`,
+			},
+		},
+	}
+)
+
+// genesisInspect lists the system contracts of a genesis.
+func genesisInspect(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+
+	var genesis *core.Genesis
+	switch arg := ctx.Args().First(); arg {
+	case "", "mainnet":
+		genesis = core.DefaultNuclearMainnetGenesisBlock()
+	case "testnet":
+		genesis = core.DefaultNuclearTestnetGenesisBlock()
+	default:
+		var err error
+		if genesis, err = core.DeveloperNuclearGenesisBlock(arg); err != nil {
+			utils.Fatalf("%v", err)
+		}
+	}
+
+	res, err := core.InspectNuclearGenesis(genesis)
+	if err != nil {
+		utils.Fatalf("Failed to inspect genesis: %v", err)
+	}
+
+	if ctx.Bool(genesisJSONFlag.Name) {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			utils.Fatalf("Failed to encode: %v", err)
+		}
+	} else {
+		printGenesisInspection(res)
+	}
+
+	if !res.Passed() {
+		utils.Fatalf("Genesis inspection failed")
+	}
+	return nil
+}
+
+func printGenesisInspection(res *core.GenesisInspection) {
+	fmt.Printf("Genesis:   %s\n", res.Hash.Hex())
+	if res.Expected != nil {
+		fmt.Printf("Expected:  %s\n", res.Expected.Hex())
+	} else {
+		fmt.Println("Expected:  unknown chain")
+	}
+
+	for _, c := range res.Contracts {
+		fmt.Println()
+		fmt.Printf("%s %s\n", c.Address.Hex(), c.Name)
+		if c.Impl != nil {
+			fmt.Printf("  Impl:     %s\n", c.Impl.Hex())
+		}
+		if c.Proxy != nil {
+			fmt.Printf("  Proxy:    %s\n", c.Proxy.Hex())
+		}
+		fmt.Printf("  Code:     %s (%d bytes)\n", c.CodeHash.Hex(), c.CodeSize)
+		fmt.Printf("  Balance:  %s\n", c.Balance)
+
+		keys := make([]common.Hash, 0, len(c.Storage))
+		for key := range c.Storage {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].Big().Cmp(keys[j].Big()) < 0
+		})
+		for _, key := range keys {
+			fmt.Printf("  Storage:  %s = %s\n", key.Hex(), c.Storage[key].Hex())
+		}
+	}
+
+	fmt.Println()
+	for _, problem := range res.Problems {
+		fmt.Printf("Problem:   %s\n", problem)
+	}
+	if res.Passed() {
+		fmt.Println("Result:    PASS")
+	} else {
+		fmt.Println("Result:    FAIL")
+	}
+}
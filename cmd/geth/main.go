@@ -56,6 +56,7 @@ var (
 		utils.IdentityFlag,
 		utils.UnlockedAccountFlag,
 		utils.UnlockStakingOnlyFlag,
+		utils.StakingPolicyFlag,
 		utils.PasswordFileFlag,
 		utils.BootnodesFlag,
 		utils.BootnodesV4Flag,
@@ -91,6 +92,8 @@ var (
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
 		utils.WhitelistFlag,
+		utils.CheckpointFlag,
+		utils.SporkOverrideFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheTrieFlag,
@@ -118,6 +121,9 @@ var (
 		utils.MinerMigrationFlag,
 		utils.MinerNonceCapFlag,
 		utils.MinerAutocollateralFlag,
+		utils.MinerAutocollateralTargetFlag,
+		utils.MinerAutocollateralReserveFlag,
+		utils.MinerAutocollateralMinStepFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -128,6 +134,7 @@ var (
 		utils.DeveloperPeriodFlag,
 		utils.TestnetFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMInternalTransfersFlag,
 		utils.NetworkIdFlag,
 		utils.ConstantinopleOverrideFlag,
 		utils.RPCCORSDomainFlag,
@@ -142,6 +149,10 @@ var (
 		utils.EVMInterpreterFlag,
 		utils.MasternodeFlag,
 		utils.MasternodeOwnerFlag,
+		utils.MasternodeAddrsFlag,
+		utils.MasternodeLoopbackFlag,
+		utils.MasternodeMeshSizeFlag,
+		utils.MasternodeCheckpointGossipFlag,
 		utils.NuclearInitDevFlag,
 		configFileFlag,
 	}
@@ -214,6 +225,18 @@ func init() {
 		licenseCommand,
 		// See config.go
 		dumpConfigCommand,
+		// See treasurycmd.go:
+		treasuryCommand,
+		// See migrationcmd.go:
+		migrationCommand,
+		// See checkpointcmd.go:
+		checkpointCommand,
+		// See upgradecmd.go:
+		upgradeCommand,
+		// See devnetcmd.go:
+		devnetCommand,
+		// See genesiscmd.go:
+		genesisCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -304,6 +327,11 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 			unlockAccount(ctx, ks, trimmed, i, passwords, stakingOnly)
 		}
 	}
+	if policy := ctx.GlobalString(utils.StakingPolicyFlag.Name); policy != "" {
+		if _, err := ks.SetStakingPolicy(policy); err != nil {
+			utils.Fatalf("Failed to apply staking policy: %v", err)
+		}
+	}
 	// Register wallet event handlers to open and auto-derive wallets
 	events := make(chan accounts.WalletEvent, 16)
 	stack.AccountManager().Subscribe(events)
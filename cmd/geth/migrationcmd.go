@@ -0,0 +1,244 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"nuclear/core/nuclear/cmd/utils"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/log"
+	"gopkg.in/urfave/cli.v1"
+
+	energi_api "nuclear/core/nuclear/energi/api"
+	energi_consensus "nuclear/core/nuclear/energi/consensus"
+)
+
+var (
+	migrationBundleFlag = cli.StringFlag{
+		Name:  "bundle",
+		Usage: "Claim bundle file as produced by energi.prepareGen2Claim",
+	}
+	migrationKeysFlag = cli.StringFlag{
+		Name:  "keys",
+		Usage: "File with Gen2 WIF private keys, one per line, or Gen2 wallet dump",
+	}
+	migrationOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Signed bundle file (default: overwrite the input bundle)",
+	}
+	migrationSnapshotFlag = cli.StringFlag{
+		Name:  "snapshot",
+		Usage: "Gen2 snapshot file",
+	}
+	migrationDiffFlag = cli.StringFlag{
+		Name:  "diff",
+		Usage: "Another snapshot file to compare against",
+	}
+	migrationAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Usage: "API endpoint to compare migration block against (optional)",
+	}
+	migrationCommand = cli.Command{
+		Name:     "migration",
+		Usage:    "Gen2 migration tools",
+		Category: "NUCLEAR COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(migrationSign),
+				Name:      "sign",
+				Usage:     "Sign Gen2 claim bundle offline",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					migrationBundleFlag,
+					migrationKeysFlag,
+					migrationOutputFlag,
+				},
+				Description: `
+Sign a Gen2 claim bundle with Gen2 private keys. No network access is
+required, so the command is meant to be run on an air-gapped machine.
+
+The flow is:
+1. energi.prepareGen2Claim(owners, destination) on an online node,
+2. nuclear migration sign --bundle <file> --keys <file> on the offline machine,
+3. energi.submitGen2Claim(bundle) on an online node,
+4. energi.checkGen2Claim(bundle) to track status of each coin.
+`,
+			},
+			{
+				Action:    utils.MigrateFlags(migrationVerify),
+				Name:      "verify",
+				Usage:     "Verify Gen2 snapshot used for migration",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					migrationSnapshotFlag,
+					migrationDiffFlag,
+					migrationAttachFlag,
+				},
+				Description: `
+Parse the Gen2 snapshot file and report totals, blacklisted entries,
+duplicate and invalid owners as well as the declared snapshot_hash and
+the hash of the resulting setSnapshot() call data.
+
+With --diff, per-owner differences against another snapshot are reported.
+With --attach, per-owner differences against the migration transaction
+of block #1 of the attached node are reported.
+
+The command fails if any difference is found.
+`,
+			},
+		},
+	}
+)
+
+// migrationSign adds Gen2 signatures to a claim bundle.
+func migrationSign(ctx *cli.Context) error {
+	bundle_file := ctx.String(migrationBundleFlag.Name)
+	keys_file := ctx.String(migrationKeysFlag.Name)
+	if bundle_file == "" || keys_file == "" {
+		utils.Fatalf("Both --%v and --%v are required", migrationBundleFlag.Name, migrationKeysFlag.Name)
+	}
+
+	data, err := ioutil.ReadFile(bundle_file)
+	if err != nil {
+		utils.Fatalf("Failed to read bundle: %v", err)
+	}
+
+	bundle := new(energi_api.Gen2ClaimBundle)
+	if err = json.Unmarshal(data, bundle); err != nil {
+		utils.Fatalf("Failed to parse bundle: %v", err)
+	}
+
+	keys, err := loadGen2Keys(keys_file)
+	if err != nil {
+		utils.Fatalf("Failed to load keys: %v", err)
+	}
+
+	signed, err := energi_api.SignGen2Claim(bundle, keys)
+	if err != nil {
+		utils.Fatalf("Failed to sign bundle: %v", err)
+	}
+
+	for _, c := range bundle.Claims {
+		if len(c.Signature) == 0 {
+			log.Warn("Missing key for coin", "item", c.ItemID, "owner", c.Owner)
+		}
+	}
+
+	data, err = json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode bundle: %v", err)
+	}
+
+	out_file := ctx.String(migrationOutputFlag.Name)
+	if out_file == "" {
+		out_file = bundle_file
+	}
+
+	if err = ioutil.WriteFile(out_file, data, 0600); err != nil {
+		utils.Fatalf("Failed to write bundle: %v", err)
+	}
+
+	log.Info("Signed claim bundle", "signed", signed, "total", len(bundle.Claims), "file", out_file)
+	return nil
+}
+
+// loadGen2Keys reads WIF keys from a key list or a wallet dump file.
+func loadGen2Keys(file string) ([]energi_api.Gen2Key, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return energi_api.ParseGen2Dump(string(data)), nil
+}
+
+type migrationVerifyResult struct {
+	Report    *energi_consensus.SnapshotReport
+	Diff      *energi_consensus.SnapshotDiff `json:",omitempty"`
+	ChainHash string                         `json:",omitempty"`
+	ChainDiff *energi_consensus.SnapshotDiff `json:",omitempty"`
+}
+
+// migrationVerify reports snapshot contents and differences.
+func migrationVerify(ctx *cli.Context) error {
+	file := ctx.String(migrationSnapshotFlag.Name)
+	if file == "" {
+		utils.Fatalf("--%v is required", migrationSnapshotFlag.Name)
+	}
+
+	report, err := energi_consensus.VerifySnapshot(file)
+	if err != nil {
+		utils.Fatalf("Failed to parse snapshot: %v", err)
+	}
+
+	res := &migrationVerifyResult{Report: report}
+	failed := len(report.Invalid) != 0
+
+	if other := ctx.String(migrationDiffFlag.Name); other != "" {
+		if res.Diff, err = energi_consensus.DiffSnapshots(file, other); err != nil {
+			utils.Fatalf("Failed to compare snapshots: %v", err)
+		}
+		failed = failed || !res.Diff.IsEmpty()
+	}
+
+	if endpoint := ctx.String(migrationAttachFlag.Name); endpoint != "" {
+		client, err := dialRPC(endpoint)
+		if err != nil {
+			utils.Fatalf("Unable to attach to nuclear node: %v", err)
+		}
+		defer client.Close()
+
+		var block struct {
+			ExtraData    hexutil.Bytes `json:"extraData"`
+			Transactions []struct {
+				Input hexutil.Bytes `json:"input"`
+			} `json:"transactions"`
+		}
+		if err = client.Call(&block, "eth_getBlockByNumber", "0x1", true); err != nil {
+			utils.Fatalf("Failed to get migration block: %v", err)
+		}
+		if len(block.Transactions) == 0 {
+			utils.Fatalf("Migration transaction is missing")
+		}
+
+		if res.ChainHash, err = energi_consensus.MigrationExtraHash(block.ExtraData); err != nil {
+			utils.Fatalf("Failed to decode migration block extra: %v", err)
+		}
+
+		res.ChainDiff, err = energi_consensus.DiffMigrationData(file, block.Transactions[0].Input)
+		if err != nil {
+			utils.Fatalf("Failed to compare migration data: %v", err)
+		}
+		failed = failed || !res.ChainDiff.IsEmpty() || res.ChainHash != report.Hash
+	}
+
+	out, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode result: %v", err)
+	}
+	fmt.Fprintln(os.Stdout, string(out))
+
+	if failed {
+		utils.Fatalf("Snapshot verification failed")
+	}
+
+	return nil
+}
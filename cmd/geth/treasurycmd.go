@@ -0,0 +1,197 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"nuclear/core/nuclear/cmd/utils"
+	"nuclear/core/nuclear/node"
+	"gopkg.in/urfave/cli.v1"
+
+	energi_api "nuclear/core/nuclear/energi/api"
+)
+
+var (
+	treasuryAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: node.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	treasuryFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Value: 1,
+		Usage: "First block of the range",
+	}
+	treasuryToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block of the range (default: current block)",
+	}
+	treasuryFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Value: "csv",
+		Usage: "Output format: csv or json",
+	}
+	treasuryOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Output file (default: stdout)",
+	}
+	treasuryCommand = cli.Command{
+		Name:     "treasury",
+		Usage:    "Treasury accounting tools",
+		Category: "NUCLEAR COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(treasuryExport),
+				Name:      "export",
+				Usage:     "Export Treasury history of a running node",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					treasuryAttachFlag,
+					treasuryFromFlag,
+					treasuryToFlag,
+					treasuryFormatFlag,
+					treasuryOutputFlag,
+				},
+				Description: `
+Export superblocks, proposal payouts and contributions of the Treasury
+in the specified block range. The attached node must keep historical
+states, i.e. run in archive mode.
+
+CSV output has one row per event with the following columns:
+type,block,time,tx,address,ref_uuid,amount,balance
+`,
+			},
+		},
+	}
+)
+
+// treasuryExport queries Treasury history over RPC and writes it out.
+func treasuryExport(ctx *cli.Context) error {
+	format := ctx.String(treasuryFormatFlag.Name)
+	if format != "csv" && format != "json" {
+		utils.Fatalf("Unsupported format: %v", format)
+	}
+
+	client, err := dialRPC(ctx.String(treasuryAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to nuclear node: %v", err)
+	}
+	defer client.Close()
+
+	to := ctx.Uint64(treasuryToFlag.Name)
+	if to == 0 {
+		var head struct {
+			Number string `json:"number"`
+		}
+		if err := client.Call(&head, "eth_getBlockByNumber", "latest", false); err != nil {
+			utils.Fatalf("Failed to get current block: %v", err)
+		}
+		if to, err = strconv.ParseUint(head.Number, 0, 64); err != nil {
+			utils.Fatalf("Invalid block number: %v", err)
+		}
+	}
+
+	history := new(energi_api.TreasuryHistory)
+	err = client.Call(history, "energi_treasuryHistory", ctx.Uint64(treasuryFromFlag.Name), to)
+	if err != nil {
+		utils.Fatalf("Failed to get Treasury history: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if file := ctx.String(treasuryOutputFlag.Name); file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			utils.Fatalf("Failed to create output: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(history)
+	} else {
+		err = writeTreasuryCSV(out, history)
+	}
+
+	if err != nil {
+		utils.Fatalf("Failed to write output: %v", err)
+	}
+
+	return nil
+}
+
+// writeTreasuryCSV writes Treasury history as flat CSV rows ordered by type.
+func writeTreasuryCSV(out io.Writer, history *energi_api.TreasuryHistory) error {
+	w := csv.NewWriter(out)
+
+	rows := [][]string{
+		{"type", "block", "time", "tx", "address", "ref_uuid", "amount", "balance"},
+	}
+
+	for _, sb := range history.Superblocks {
+		rows = append(rows, []string{
+			"superblock",
+			fmt.Sprint(sb.Number),
+			fmt.Sprint(sb.Time),
+			"",
+			"",
+			"",
+			sb.Inflow.ToInt().String(),
+			sb.Balance.ToInt().String(),
+		})
+	}
+
+	for _, p := range history.Payouts {
+		rows = append(rows, []string{
+			"payout",
+			fmt.Sprint(p.Block),
+			"",
+			p.TxHash.Hex(),
+			p.Proposal.Hex(),
+			p.RefUUID,
+			p.Amount.ToInt().String(),
+			"",
+		})
+	}
+
+	for _, c := range history.Contributions {
+		rows = append(rows, []string{
+			"contribution",
+			fmt.Sprint(c.Block),
+			"",
+			c.TxHash.Hex(),
+			c.From.Hex(),
+			"",
+			c.Amount.ToInt().String(),
+			"",
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return w.Error()
+}
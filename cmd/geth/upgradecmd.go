@@ -0,0 +1,155 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"nuclear/core/nuclear/cmd/utils"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/ethclient"
+	"nuclear/core/nuclear/node"
+	"gopkg.in/urfave/cli.v1"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_common "nuclear/core/nuclear/energi/common"
+)
+
+var (
+	upgradeAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: node.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	upgradeArtifactsFlag = cli.StringFlag{
+		Name:  "artifacts",
+		Value: "build/contracts/energi",
+		Usage: "Directory of the compiled contracts",
+	}
+	upgradeCommand = cli.Command{
+		Name:     "upgrade",
+		Usage:    "Governed contract upgrade tools",
+		Category: "NUCLEAR COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(upgradeVerify),
+				Name:      "verify",
+				Usage:     "Verify an upgrade proposal against compiled contracts",
+				ArgsUsage: "<proxy> <proposal> <contract>",
+				Flags: []cli.Flag{
+					upgradeAttachFlag,
+					upgradeArtifactsFlag,
+				},
+				Description: `
+Compare the runtime code of the implementation proposed for the governed
+proxy with the solc output of "make energi-contracts" for the <contract>,
+e.g. MasternodeRegistryV2. The metadata hash is not a part of the
+comparison, but a metadata mismatch is reported as it indicates modified
+sources.
+
+The proposed implementation must also keep the storage layout of the
+current one, as found in the <Name>.storage.json files. The command fails
+unless the proposal passes all the checks.`,
+			},
+		},
+	}
+)
+
+// upgradeVerify checks the upgrade proposal of a running node against the
+// local artifacts.
+func upgradeVerify(ctx *cli.Context) error {
+	if len(ctx.Args()) != 3 {
+		utils.Fatalf("This command requires proxy, proposal and contract arguments.")
+	}
+	contract := ctx.Args().Get(2)
+
+	var proxy, proposal common.Address
+	for i, addr := range []*common.Address{&proxy, &proposal} {
+		arg := ctx.Args().Get(i)
+		if !common.IsHexAddress(arg) {
+			utils.Fatalf("Invalid address: %v", arg)
+		}
+		*addr = common.HexToAddress(arg)
+	}
+
+	artifacts, err := energi_common.LoadArtifacts(ctx.String(upgradeArtifactsFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to load artifacts: %v", err)
+	}
+
+	rpcClient, err := dialRPC(ctx.String(upgradeAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to nuclear node: %v", err)
+	}
+	client := ethclient.NewClient(rpcClient)
+	defer client.Close()
+
+	proxy_obj, err := energi_abi.NewIGovernedProxyCaller(proxy, client)
+	if err != nil {
+		utils.Fatalf("Failed to bind proxy: %v", err)
+	}
+
+	current, err := proxy_obj.Impl(nil)
+	if err != nil {
+		utils.Fatalf("Failed to get current implementation: %v", err)
+	}
+
+	proposed, err := proxy_obj.UpgradeProposalImpl(nil, proposal)
+	if err != nil {
+		utils.Fatalf("Failed to get proposed implementation: %v", err)
+	}
+
+	current_code, err := client.CodeAt(context.Background(), current, nil)
+	if err != nil {
+		utils.Fatalf("Failed to get code: %v", err)
+	}
+
+	proposed_code, err := client.CodeAt(context.Background(), proposed, nil)
+	if err != nil {
+		utils.Fatalf("Failed to get code: %v", err)
+	}
+
+	res := energi_common.VerifyUpgrade(artifacts, contract, current_code, proposed_code)
+	res.Current.Address = current
+	res.Proposed.Address = proposed
+
+	fmt.Printf("Proxy:     %s\n", proxy.Hex())
+	fmt.Printf("Proposal:  %s\n", proposal.Hex())
+	fmt.Printf("Current:   %s %s\n", current.Hex(), res.Current.Contract)
+	fmt.Printf("Proposed:  %s %s\n", proposed.Hex(), res.Proposed.Contract)
+
+	switch {
+	case !res.StorageChecked:
+		fmt.Println("Storage:   not checked")
+	case res.StorageCompatible:
+		fmt.Println("Storage:   compatible")
+	default:
+		fmt.Println("Storage:   incompatible")
+	}
+
+	for _, note := range res.Notes {
+		fmt.Printf("Note:      %s\n", note)
+	}
+
+	if !res.Passed {
+		utils.Fatalf("Result:    FAIL")
+	}
+
+	fmt.Println("Result:    PASS")
+	return nil
+}
@@ -84,6 +84,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.CheckpointFlag,
+			utils.SporkOverrideFlag,
 		},
 	},
 	{
@@ -91,6 +93,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.MasternodeFlag,
 			utils.MasternodeOwnerFlag,
+			utils.MasternodeAddrsFlag,
+			utils.MasternodeLoopbackFlag,
+			utils.MasternodeMeshSizeFlag,
+			utils.MasternodeCheckpointGossipFlag,
 		},
 	},
 	{
@@ -154,6 +160,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.UnlockedAccountFlag,
 			utils.UnlockStakingOnlyFlag,
+			utils.StakingPolicyFlag,
 			utils.PasswordFileFlag,
 		},
 	},
@@ -214,6 +221,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerMigrationFlag,
 			utils.MinerNonceCapFlag,
 			utils.MinerAutocollateralFlag,
+			utils.MinerAutocollateralTargetFlag,
+			utils.MinerAutocollateralReserveFlag,
+			utils.MinerAutocollateralMinStepFlag,
 		},
 	},
 	{
@@ -227,6 +237,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.VMInternalTransfersFlag,
 			utils.EVMInterpreterFlag,
 			utils.EWASMInterpreterFlag,
 		},
@@ -25,9 +25,12 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"strconv"
 	"strings"
 
 	"nuclear/core/nuclear/common/math"
+	"nuclear/core/nuclear/eth"
+	"nuclear/core/nuclear/log"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -185,6 +188,80 @@ func GlobalBig(ctx *cli.Context, name string) *big.Int {
 	return (*big.Int)(val.(*bigValue))
 }
 
+// AutocollateralFlag is an enabled by default boolean command line flag, which
+// also accepts the deprecated numeric autocollateralize modes.
+type AutocollateralFlag struct {
+	Name  string
+	Usage string
+}
+
+// autocollateralValue turns bool into a flag.Value. The deprecated numeric
+// mode is kept to warn about it once logging is set up.
+type autocollateralValue struct {
+	enabled bool
+	mode    *uint64
+}
+
+func (a *autocollateralValue) String() string {
+	if a == nil {
+		return ""
+	}
+	return strconv.FormatBool(a.enabled)
+}
+
+func (a *autocollateralValue) Set(s string) error {
+	enabled, err := strconv.ParseBool(s)
+	if err == nil {
+		a.enabled, a.mode = enabled, nil
+		return nil
+	}
+
+	mode, perr := strconv.ParseUint(s, 10, 64)
+	if perr != nil {
+		return err
+	}
+	if enabled, err = eth.AutocollateralMode(mode); err != nil {
+		return err
+	}
+
+	a.enabled, a.mode = enabled, &mode
+	return nil
+}
+
+func (a *autocollateralValue) IsBoolFlag() bool {
+	return true
+}
+
+func (f AutocollateralFlag) GetName() string {
+	return f.Name
+}
+
+func (f AutocollateralFlag) String() string {
+	return fmt.Sprintf("%s\t%v", prefixedNames(f.Name), f.Usage)
+}
+
+func (f AutocollateralFlag) Apply(set *flag.FlagSet) {
+	value := &autocollateralValue{enabled: true}
+	eachName(f.Name, func(name string) {
+		set.Var(value, f.Name, f.Usage)
+	})
+}
+
+// GlobalAutocollateral returns the value of an AutocollateralFlag from the
+// global flag set.
+func GlobalAutocollateral(ctx *cli.Context, name string) bool {
+	val := ctx.GlobalGeneric(name)
+	if val == nil {
+		return true
+	}
+
+	value := val.(*autocollateralValue)
+	if value.mode != nil {
+		log.Warn("Numeric --"+name+" is deprecated, use true or false", "mode", *value.mode)
+	}
+	return value.enabled
+}
+
 func prefixFor(name string) (prefix string) {
 	if len(name) == 1 {
 		prefix = "-"
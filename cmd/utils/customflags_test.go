@@ -39,3 +39,29 @@ func TestPathExpansion(t *testing.T) {
 		}
 	}
 }
+
+func TestAutocollateralValue(t *testing.T) {
+	tests := map[string]bool{
+		"false": false,
+		"true":  true,
+		"0":     false,
+		"1":     true,
+		"2":     true,
+	}
+	for value, expected := range tests {
+		v := autocollateralValue{enabled: !expected}
+		if err := v.Set(value); err != nil {
+			t.Fatalf("value %s: failed to set: %v", value, err)
+		}
+		if v.enabled != expected {
+			t.Errorf("value %s: got %v, expected %v", value, v.enabled, expected)
+		}
+	}
+
+	for _, value := range []string{"3", "yes", "-1"} {
+		v := autocollateralValue{enabled: true}
+		if err := v.Set(value); err == nil {
+			t.Errorf("value %s: invalid value is accepted", value)
+		}
+	}
+}
@@ -194,6 +194,14 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "Signed checkpoint JSON file to enforce before sync",
+	}
+	SporkOverrideFlag = cli.StringFlag{
+		Name:  "spork.override",
+		Usage: "Spork override JSON file to use instead of the on-chain sporks (not on mainnet)",
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  metrics.DashboardEnabledFlag,
@@ -408,10 +416,21 @@ var (
 		Name:  "miner.noncecap",
 		Usage: "Cap the maximum PoS Nonce value",
 	}
-	MinerAutocollateralFlag = cli.Uint64Flag{
+	MinerAutocollateralFlag = AutocollateralFlag{
 		Name:  "miner.autocollateralize",
-		Usage: "Autocollateralize for MN owner addresses (0 - disable, 1 - after MN rewards, 2 - rapid)",
-		Value: 1,
+		Usage: "Autocollateralize for MN owner addresses after MN rewards (numeric modes are deprecated)",
+	}
+	MinerAutocollateralTargetFlag = cli.Uint64Flag{
+		Name:  "miner.autocollateralize.target",
+		Usage: "Target masternode collateral in NRG for autocollateralize (0 - maximum allowed)",
+	}
+	MinerAutocollateralReserveFlag = cli.Uint64Flag{
+		Name:  "miner.autocollateralize.reserve",
+		Usage: "Balance in NRG to keep liquid on autocollateralize",
+	}
+	MinerAutocollateralMinStepFlag = cli.Uint64Flag{
+		Name:  "miner.autocollateralize.minstep",
+		Usage: "Minimal autocollateralize deposit in NRG (0 - minimal collateral)",
 	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
@@ -423,6 +442,11 @@ var (
 		Name:  "unlock.staking",
 		Usage: "Unlock only for staking purposes (hash signing)",
 	}
+	StakingPolicyFlag = cli.StringFlag{
+		Name:  "unlock.policy",
+		Usage: "Staking policy file of accounts to unlock for staking only at startup",
+		Value: "",
+	}
 	PasswordFileFlag = cli.StringFlag{
 		Name:  "password",
 		Usage: "Password file to use for non-interactive password input",
@@ -433,6 +457,10 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMInternalTransfersFlag = cli.BoolFlag{
+		Name:  "vm.internaltransfers",
+		Usage: "Record and index internal value transfers of processed blocks",
+	}
 	RPCGlobalGasCap = cli.Uint64Flag{
 		Name:  "rpc.gascap",
 		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas",
@@ -677,6 +705,28 @@ var (
 		Value: "",
 	}
 
+	MasternodeAddrsFlag = cli.StringFlag{
+		Name:  "masternode.addrs",
+		Usage: "Comma separated extra public IPv4/IPv6 addresses advertised in the masternode ENR",
+		Value: "",
+	}
+
+	MasternodeLoopbackFlag = cli.BoolFlag{
+		Name:  "masternode.loopback",
+		Usage: "Accept loopback addresses of masternodes (local developer networks only)",
+	}
+
+	MasternodeMeshSizeFlag = cli.IntFlag{
+		Name:  "masternode.meshsize",
+		Usage: "Number of reserved connections to other active masternodes (0 = disabled)",
+		Value: energi_svc.DefaultMeshSize,
+	}
+
+	MasternodeCheckpointGossipFlag = cli.BoolFlag{
+		Name:  "masternode.cpgossip",
+		Usage: "Gossip checkpoint votes between masternodes and submit them on-chain only after the quorum",
+	}
+
 	NuclearInitDevFlag = cli.StringFlag{
 		Name:  "init",
 		Usage: "Nuclear network: use pre-configured custom genesis block",
@@ -1320,12 +1370,36 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		cfg.MinerNonceCap = ctx.GlobalUint64(MinerNonceCapFlag.Name)
 	}
 	if ctx.GlobalIsSet(MinerAutocollateralFlag.Name) {
-		cfg.MinerAutocollateral = ctx.GlobalUint64(MinerAutocollateralFlag.Name)
+		cfg.MinerAutocollateral = GlobalAutocollateral(ctx, MinerAutocollateralFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerAutocollateralTargetFlag.Name) {
+		cfg.MinerAutocollateralTarget = new(big.Int).Mul(
+			new(big.Int).SetUint64(ctx.GlobalUint64(MinerAutocollateralTargetFlag.Name)),
+			big.NewInt(params.Ether))
+	}
+	if ctx.GlobalIsSet(MinerAutocollateralReserveFlag.Name) {
+		cfg.MinerAutocollateralReserve = new(big.Int).Mul(
+			new(big.Int).SetUint64(ctx.GlobalUint64(MinerAutocollateralReserveFlag.Name)),
+			big.NewInt(params.Ether))
+	}
+	if ctx.GlobalIsSet(MinerAutocollateralMinStepFlag.Name) {
+		cfg.MinerAutocollateralMinStep = new(big.Int).Mul(
+			new(big.Int).SetUint64(ctx.GlobalUint64(MinerAutocollateralMinStepFlag.Name)),
+			big.NewInt(params.Ether))
 	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMInternalTransfersFlag.Name) {
+		cfg.InternalTransfers = ctx.GlobalBool(VMInternalTransfersFlag.Name)
+	}
+	if ctx.GlobalIsSet(CheckpointFlag.Name) {
+		cfg.Checkpoint = ctx.GlobalString(CheckpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(SporkOverrideFlag.Name) {
+		cfg.SporkOverride = ctx.GlobalString(SporkOverrideFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(EWASMInterpreterFlag.Name) {
 		cfg.EWASMInterpreter = ctx.GlobalString(EWASMInterpreterFlag.Name)
@@ -1466,13 +1540,13 @@ func RegisterDynamicCheckpointService(stack *node.Node) {
 }
 
 // RegisterMasternodeService configures Nuclear Masternode service. It also accepts
-// the owner parameter which is an optional user set cmd argument.
-func RegisterMasternodeService(stack *node.Node, owner common.Address) {
+// the config with optional user set cmd arguments.
+func RegisterMasternodeService(stack *node.Node, cfg *energi_svc.MasternodeConfig) {
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		var ethServ *eth.Ethereum
 		ctx.Service(&ethServ)
 
-		return energi_svc.NewMasternodeService(ethServ, owner)
+		return energi_svc.NewMasternodeService(ethServ, cfg)
 	}); err != nil {
 		Fatalf("Failed to register the Nuclear Masternode service: %v", err)
 	}
@@ -119,6 +119,13 @@ type PoW interface {
 	Hashrate() float64
 }
 
+// RewardReporter is a consensus engine which itemises block rewards.
+type RewardReporter interface {
+	// BlockRewards returns the reward breakdown of a recently processed
+	// block identified by its state root.
+	BlockRewards(root common.Hash) (types.BlockRewards, bool)
+}
+
 type SealResult struct {
 	Block    *types.Block
 	NewState *state.StateDB
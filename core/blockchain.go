@@ -151,6 +151,7 @@ type BlockChain struct {
 	shouldPreserve func(*types.Block) bool // Function used to determine whether should preserve the given block.
 
 	checkpoints *checkpointManager
+	transfers   *TransferRecorder
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -189,6 +190,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		vmConfig:       vmConfig,
 		badBlocks:      badBlocks,
 	}
+	if rec, ok := vmConfig.TransferTracer.(*TransferRecorder); ok {
+		bc.transfers = rec
+	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
 
@@ -219,6 +223,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		}
 	}
 	bc.checkpoints.setup(bc)
+	bc.loadLocalCheckpoints()
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -668,6 +673,21 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// GetBlockRewards retrieves the reward breakdown of the given block.
+func (bc *BlockChain) GetBlockRewards(hash common.Hash, number uint64) types.BlockRewards {
+	return rawdb.ReadBlockRewards(bc.db, hash, number)
+}
+
+// TransferRecorder returns the internal transfer recorder, if enabled.
+func (bc *BlockChain) TransferRecorder() *TransferRecorder {
+	return bc.transfers
+}
+
+// GetInternalTransfers retrieves recorded internal transfers of the given block.
+func (bc *BlockChain) GetInternalTransfers(hash common.Hash, number uint64) types.InternalTransfers {
+	return rawdb.ReadInternalTransfers(bc.db, hash, number)
+}
+
 // GetBlocksFromHash returns the block corresponding to hash and up to n-1 ancestors.
 // [deprecated by eth/62]
 func (bc *BlockChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*types.Block) {
@@ -1040,6 +1060,17 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	// Write other block data using a batch.
 	batch := bc.db.NewBatch()
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+	if rr, ok := bc.engine.(consensus.RewardReporter); ok {
+		if rewards, ok := rr.BlockRewards(block.Root()); ok {
+			rawdb.WriteBlockRewards(batch, block.Hash(), block.NumberU64(), rewards)
+		}
+	}
+	var transfers types.InternalTransfers
+	if bc.transfers != nil {
+		if transfers = bc.transfers.Take(state, block); transfers != nil {
+			rawdb.WriteInternalTransfers(batch, block.Hash(), block.NumberU64(), transfers)
+		}
+	}
 
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1069,6 +1100,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		// Write the positional metadata for transaction/receipt lookups and preimages
 		rawdb.WriteTxLookupEntries(batch, block)
 		rawdb.WritePreimages(batch, state.Preimages())
+		if transfers != nil {
+			indexInternalTransfers(bc.db, batch, block, transfers)
+		}
 
 		status = CanonStatTy
 	} else {
@@ -1546,6 +1580,14 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		// Write lookup entries for hash based transaction/receipt searches
 		rawdb.WriteTxLookupEntries(bc.db, newChain[i])
 		addedTxs = append(addedTxs, newChain[i].Transactions()...)
+
+		// NOTE: the new head block is indexed on write as its transfers are not stored yet
+		if bc.transfers != nil {
+			transfers := rawdb.ReadInternalTransfers(bc.db, newChain[i].Hash(), newChain[i].NumberU64())
+			if transfers != nil {
+				indexInternalTransfers(bc.db, bc.db, newChain[i], transfers)
+			}
+		}
 	}
 	// When transactions get deleted from the database, the receipts that were
 	// created in the fork must also be deleted
@@ -1607,6 +1649,7 @@ func (bc *BlockChain) update() {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+			bc.expireLocalCheckpoints()
 		case <-bc.quit:
 			return
 		}
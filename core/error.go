@@ -36,4 +36,12 @@ var (
 
 	// ErrCheckpointMismatch is returned if a block to import does not match checkpoint
 	ErrCheckpointMismatch = errors.New("checkpoint mismatch")
+
+	// ErrCheckpointConflict is returned if a local checkpoint would roll back
+	// the canonical chain and enforcement is not forced.
+	ErrCheckpointConflict = errors.New("checkpoint conflicts with canonical chain")
+
+	// ErrCheckpointNotLocal is returned on removal of a checkpoint which was
+	// not added locally.
+	ErrCheckpointNotLocal = errors.New("not a local checkpoint")
 )
@@ -20,11 +20,13 @@ func (g GenesisXfer) MarshalJSON() ([]byte, error) {
 		Addr  common.UnprefixedAddress `json:"addr" gencodec:"required"`
 		Code  hexutil.Bytes            `json:"code" gencodec:"required"`
 		Value *math.HexOrDecimal256    `json:"value,omitempty"`
+		From  *common.Address          `json:"from,omitempty"`
 	}
 	var enc GenesisXfer
 	enc.Addr = common.UnprefixedAddress(g.Addr)
 	enc.Code = g.Code
 	enc.Value = (*math.HexOrDecimal256)(g.Value)
+	enc.From = g.From
 	return json.Marshal(&enc)
 }
 
@@ -34,6 +36,7 @@ func (g *GenesisXfer) UnmarshalJSON(input []byte) error {
 		Addr  *common.UnprefixedAddress `json:"addr" gencodec:"required"`
 		Code  *hexutil.Bytes            `json:"code" gencodec:"required"`
 		Value *math.HexOrDecimal256     `json:"value,omitempty"`
+		From  *common.Address           `json:"from,omitempty"`
 	}
 	var dec GenesisXfer
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -50,5 +53,8 @@ func (g *GenesisXfer) UnmarshalJSON(input []byte) error {
 	if dec.Value != nil {
 		g.Value = (*big.Int)(dec.Value)
 	}
+	if dec.From != nil {
+		g.From = dec.From
+	}
 	return nil
 }
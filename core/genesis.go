@@ -96,10 +96,14 @@ type GenesisAccount struct {
 
 type GenesisXfers []GenesisXfer
 
+// GenesisXfer deploys the code at the address. If From is set, it is a regular
+// call of the pre-funded account to the address with the code as input instead.
+// The calls are made after all the code is deployed.
 type GenesisXfer struct {
-	Addr  common.Address `json:"addr" gencodec:"required"`
-	Code  []byte         `json:"code" gencodec:"required"`
-	Value *big.Int       `json:"value,omitempty"`
+	Addr  common.Address  `json:"addr" gencodec:"required"`
+	Code  []byte          `json:"code" gencodec:"required"`
+	Value *big.Int        `json:"value,omitempty"`
+	From  *common.Address `json:"from,omitempty"`
 }
 
 // field type overrides for gencodec
@@ -305,6 +309,10 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 		}
 
 		for i, tx := range g.Xfers {
+			if tx.From != nil {
+				continue
+			}
+
 			gp.AddGas(gasLimit)
 			val := tx.Value
 
@@ -339,6 +347,39 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 			statedb.AddBalance(tx.Addr, val)
 		}
 
+		// Calls go after all the code, including the governance appended to
+		// the custom transfers.
+		for _, tx := range g.Xfers {
+			if tx.From == nil {
+				continue
+			}
+
+			gp.AddGas(gasLimit)
+			val := tx.Value
+
+			if val == nil {
+				val = common.Big0
+			}
+
+			msg := types.NewMessage(
+				*tx.From,
+				&tx.Addr,
+				statedb.GetNonce(*tx.From),
+				val,
+				gasLimit,
+				common.Big0,
+				tx.Code,
+				false,
+			)
+			ctx := NewEVMContext(msg, head, nil, &author)
+			ctx.GasLimit = gasLimit
+			evm := vm.NewEVM(ctx, statedb, g.Config, vmcfg)
+			_, _, failed, err := NewStateTransition(evm, msg, gp).TransitionDb()
+			if err != nil || failed {
+				panic(fmt.Errorf("Failed genesis call from %v to %v: %v", tx.From.Hex(), tx.Addr.Hex(), err))
+			}
+		}
+
 		if debug {
 			vm.WriteTrace(os.Stderr, vmcfg.Tracer.(*vm.StructLogger).StructLogs())
 			vm.WriteLogs(os.Stderr, statedb.Logs())
@@ -706,18 +747,7 @@ func DeployNuclearGovernance(config *params.ChainConfig) GenesisXfers {
 	)
 
 	// Proxy List
-	proxies := map[common.Address]common.Address{
-		energi_params.Nuclear_BlockReward:        energi_params.Nuclear_BlockRewardV1,
-		energi_params.Nuclear_Treasury:           energi_params.Nuclear_TreasuryV1,
-		energi_params.Nuclear_MasternodeRegistry: energi_params.Nuclear_MasternodeRegistryV1,
-		energi_params.Nuclear_StakerReward:       energi_params.Nuclear_StakerRewardV1,
-		energi_params.Nuclear_BackboneReward:     energi_params.Nuclear_BackboneRewardV1,
-		energi_params.Nuclear_SporkRegistry:      energi_params.Nuclear_SporkRegistryV1,
-		energi_params.Nuclear_CheckpointRegistry: energi_params.Nuclear_CheckpointRegistryV1,
-		energi_params.Nuclear_BlacklistRegistry:  energi_params.Nuclear_BlacklistRegistryV1,
-		energi_params.Nuclear_MasternodeToken:    energi_params.Nuclear_MasternodeTokenV1,
-	}
-	for k, v := range proxies {
+	for k, v := range energi_params.Nuclear_GovernedProxies {
 		deployNuclearContract(
 			&xfers,
 			k,
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -82,15 +83,21 @@ type validCheckpoint struct {
 	signatures []CheckpointSignature
 }
 
+// maxFutureCheckpoints limits the number of checkpoints collecting votes.
+const maxFutureCheckpoints = 16
+
 type futureCheckpoint struct {
 	Checkpoint
 	signatures map[common.Address]CheckpointSignature
+	confirmed  bool
 }
 
 type checkpointManager struct {
 	validated map[uint64]validCheckpoint
 	latest    uint64
 	future    map[uint64]futureCheckpoint
+	local     map[uint64]LocalCheckpoint
+	shadowed  map[uint64]validCheckpoint // replaced by local checkpoints
 	mtx       sync.RWMutex
 	newCpFeed event.Feed
 }
@@ -99,6 +106,8 @@ func newCheckpointManager() *checkpointManager {
 	return &checkpointManager{
 		validated: make(map[uint64]validCheckpoint),
 		future:    make(map[uint64]futureCheckpoint),
+		local:     make(map[uint64]LocalCheckpoint),
+		shadowed:  make(map[uint64]validCheckpoint),
 	}
 }
 
@@ -203,27 +212,9 @@ func (cm *checkpointManager) addCheckpoint(
 		}
 
 		// TODO: proper validation and use of future checkpoints
-		if len(sigs) == 0 {
-			log.Warn("Checkpoint: missing signatures",
-				"num", cp.Number, "hash", cp.Hash)
-			return errors.New("missing checkpoint signatures")
-		}
-
-		// The first one must always be CPP_signer
-		pubkey, err := crypto.Ecrecover(cm.hashToSign(&cp), sigs[0][:])
-		if err != nil {
-			log.Warn("Checkpoint: failed to extract signature",
-				"num", cp.Number, "hash", cp.Hash, "err", err)
+		if err := cm.verifySignature(chain, &cp, sigs); err != nil {
 			return err
 		}
-
-		// Check the primary signature
-		var signer common.Address
-		copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
-		if nrgconf := chain.Config().Nuclear; nrgconf == nil || signer != nrgconf.CPPSigner {
-			log.Warn("Checkpoint: invalid CPP signature", "num", cp.Number, "hash", cp.Hash)
-			return errors.New("invalid CPP signature")
-		}
 	}
 
 	cm.validated[cp.Number] = validCheckpoint{
@@ -244,6 +235,132 @@ func (cm *checkpointManager) addCheckpoint(
 	return err
 }
 
+// AddCheckpointVote aggregates a verified masternode vote on a proposed
+// checkpoint. It returns the number of votes collected so far and whether
+// the vote was not seen before.
+func (bc *BlockChain) AddCheckpointVote(
+	cp Checkpoint,
+	signer common.Address,
+	sig CheckpointSignature,
+) (int, bool, error) {
+	return bc.checkpoints.addVote(cp, signer, sig)
+}
+
+// ConfirmCheckpointVotes attaches the aggregated votes to the validated
+// checkpoint once the quorum is reached.
+func (bc *BlockChain) ConfirmCheckpointVotes(cp Checkpoint) bool {
+	return bc.checkpoints.confirmVotes(cp)
+}
+
+func (cm *checkpointManager) addVote(
+	cp Checkpoint,
+	signer common.Address,
+	sig CheckpointSignature,
+) (int, bool, error) {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+
+	fcp, ok := cm.future[cp.Number]
+	if !ok {
+		cm.pruneFuture()
+
+		fcp = futureCheckpoint{
+			Checkpoint: cp,
+			signatures: make(map[common.Address]CheckpointSignature),
+		}
+		cm.future[cp.Number] = fcp
+	} else if fcp.Hash != cp.Hash {
+		return len(fcp.signatures), false, ErrCheckpointMismatch
+	}
+
+	if _, ok := fcp.signatures[signer]; ok || fcp.confirmed {
+		return len(fcp.signatures), false, nil
+	}
+
+	fcp.signatures[signer] = append(CheckpointSignature{}, sig...)
+	return len(fcp.signatures), true, nil
+}
+
+// pruneFuture drops the oldest checkpoints still waiting for the quorum.
+func (cm *checkpointManager) pruneFuture() {
+	for len(cm.future) >= maxFutureCheckpoints {
+		oldest := ^uint64(0)
+		for num := range cm.future {
+			if num < oldest {
+				oldest = num
+			}
+		}
+		delete(cm.future, oldest)
+	}
+}
+
+func (cm *checkpointManager) confirmVotes(cp Checkpoint) bool {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+
+	fcp, ok := cm.future[cp.Number]
+	if !ok || fcp.Hash != cp.Hash || fcp.confirmed {
+		return false
+	}
+
+	vcp, ok := cm.validated[cp.Number]
+	if !ok || vcp.Hash != cp.Hash {
+		// Wait for the CPP signed checkpoint
+		return false
+	}
+
+	signers := make([]common.Address, 0, len(fcp.signatures))
+	for signer := range fcp.signatures {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+
+	// The first one must always be CPP_signer
+	for _, signer := range signers {
+		vcp.signatures = append(vcp.signatures, fcp.signatures[signer])
+	}
+	cm.validated[cp.Number] = vcp
+
+	fcp.confirmed = true
+	cm.future[cp.Number] = fcp
+
+	log.Info("Checkpoint votes reached quorum", "checkpoint", cp, "votes", len(signers))
+	return true
+}
+
+// verifySignature checks the primary signature of the checkpoint.
+func (cm *checkpointManager) verifySignature(
+	chain CheckpointChain,
+	cp *Checkpoint,
+	sigs []CheckpointSignature,
+) error {
+	if len(sigs) == 0 {
+		log.Warn("Checkpoint: missing signatures",
+			"num", cp.Number, "hash", cp.Hash)
+		return errors.New("missing checkpoint signatures")
+	}
+
+	// The first one must always be CPP_signer
+	pubkey, err := crypto.Ecrecover(cm.hashToSign(cp), sigs[0][:])
+	if err != nil {
+		log.Warn("Checkpoint: failed to extract signature",
+			"num", cp.Number, "hash", cp.Hash, "err", err)
+		return err
+	}
+
+	// Check the primary signature
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	if nrgconf := chain.Config().Nuclear; nrgconf == nil || signer != nrgconf.CPPSigner {
+		log.Warn("Checkpoint: invalid CPP signature", "num", cp.Number, "hash", cp.Hash)
+		return errors.New("invalid CPP signature")
+	}
+
+	return nil
+}
+
 func (cm *checkpointManager) hashToSign(cp *Checkpoint) []byte {
 	data := []byte("||Nuclear Blockchain Checkpoint||")
 	data = append(data, common.BigToHash(new(big.Int).SetUint64(cp.Number)).Bytes()...)
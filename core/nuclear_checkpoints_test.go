@@ -19,10 +19,15 @@ package core
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
+	"math/big"
 	"testing"
+	"time"
 
+	"nuclear/core/nuclear/common"
 	"nuclear/core/nuclear/consensus/ethash"
+	"nuclear/core/nuclear/core/rawdb"
 	"nuclear/core/nuclear/core/types"
 	"nuclear/core/nuclear/crypto"
 	"nuclear/core/nuclear/log"
@@ -184,3 +189,292 @@ func TestCheckpoints(t *testing.T) {
 	assert.Empty(t, err)
 	assert.Equal(t, chain.checkpoints.latest, fpn+2)
 }
+
+func TestCheckpointVotes(t *testing.T) {
+	t.Parallel()
+
+	cm := newCheckpointManager()
+	cp := Checkpoint{Number: 10, Hash: common.HexToHash("0x1234")}
+	other := Checkpoint{Number: 10, Hash: common.HexToHash("0x5678")}
+
+	mn1 := common.HexToAddress("0x1")
+	mn2 := common.HexToAddress("0x2")
+
+	count, added, err := cm.addVote(cp, mn2, CheckpointSignature{2})
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.Equal(t, 1, count)
+
+	// Duplicate votes are not counted
+	count, added, err = cm.addVote(cp, mn2, CheckpointSignature{3})
+	assert.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, 1, count)
+
+	count, added, err = cm.addVote(cp, mn1, CheckpointSignature{1})
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.Equal(t, 2, count)
+
+	_, _, err = cm.addVote(other, mn1, CheckpointSignature{1})
+	assert.Equal(t, ErrCheckpointMismatch, err)
+
+	// Not validated yet
+	assert.False(t, cm.confirmVotes(cp))
+
+	cm.validated[cp.Number] = validCheckpoint{
+		Checkpoint: cp,
+		signatures: []CheckpointSignature{{0}},
+	}
+	assert.False(t, cm.confirmVotes(other))
+	assert.True(t, cm.confirmVotes(cp))
+	assert.False(t, cm.confirmVotes(cp))
+	assert.Equal(t, []CheckpointSignature{{0}, {1}, {2}}, cm.validated[cp.Number].signatures)
+
+	// Late votes are not attached
+	count, added, err = cm.addVote(cp, common.HexToAddress("0x3"), CheckpointSignature{3})
+	assert.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, 2, count)
+
+	// Pruning keeps the most recent ones
+	for i := uint64(11); i <= 11+maxFutureCheckpoints; i++ {
+		_, _, err = cm.addVote(Checkpoint{Number: i}, mn1, CheckpointSignature{1})
+		assert.NoError(t, err)
+	}
+	assert.Len(t, cm.future, maxFutureCheckpoints)
+	assert.NotContains(t, cm.future, uint64(10))
+	assert.NotContains(t, cm.future, uint64(11))
+}
+
+type fakeCheckpointChain struct {
+	config   *params.ChainConfig
+	enforced []Checkpoint
+}
+
+func (c *fakeCheckpointChain) GetHeaderByNumber(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number)}
+}
+func (c *fakeCheckpointChain) CurrentHeader() *types.Header {
+	return c.GetHeaderByNumber(100)
+}
+func (c *fakeCheckpointChain) EnforceCheckpoint(cp Checkpoint) error {
+	c.enforced = append(c.enforced, cp)
+	return nil
+}
+func (c *fakeCheckpointChain) Config() *params.ChainConfig {
+	return c.config
+}
+
+func TestSignedCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	chain := &fakeCheckpointChain{
+		config: &params.ChainConfig{
+			Nuclear: &params.NuclearConfig{
+				CPPSigner: crypto.PubkeyToAddress(key.PublicKey),
+			},
+		},
+	}
+	cm := newCheckpointManager()
+
+	cp := Checkpoint{Since: 12, Number: 10, Hash: common.HexToHash("0x1234")}
+	sig, err := crypto.Sign(cm.hashToSign(&cp), key)
+	assert.NoError(t, err)
+	bad_sig, err := crypto.Sign(cm.hashToSign(&cp), other)
+	assert.NoError(t, err)
+
+	// JSON round trip, both single and list forms
+	scp := NewSignedCheckpoint(cp, []CheckpointSignature{sig})
+	data, err := json.Marshal(scp)
+	assert.NoError(t, err)
+
+	list, err := ParseSignedCheckpoints(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []SignedCheckpoint{scp}, list)
+
+	data, err = json.Marshal([]SignedCheckpoint{scp, scp})
+	assert.NoError(t, err)
+	list, err = ParseSignedCheckpoints(data)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, cp, list[1].Checkpoint())
+	assert.Equal(t, []CheckpointSignature{sig}, list[1].CheckpointSignatures())
+
+	_, err = ParseSignedCheckpoints([]byte("{"))
+	assert.Error(t, err)
+
+	// Signature checks
+	assert.NoError(t, cm.verifySignature(chain, &cp, scp.CheckpointSignatures()))
+	assert.Error(t, cm.verifySignature(chain, &cp, nil))
+	assert.Error(t, cm.verifySignature(chain, &cp, []CheckpointSignature{bad_sig}))
+
+	wrong := cp
+	wrong.Hash = common.HexToHash("0x5678")
+	assert.Error(t, cm.verifySignature(chain, &wrong, []CheckpointSignature{sig}))
+}
+
+func TestCheckpointExportImport(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	config := &params.ChainConfig{
+		Nuclear: &params.NuclearConfig{
+			CPPSigner: crypto.PubkeyToAddress(key.PublicKey),
+		},
+	}
+	chain := &fakeCheckpointChain{config: config}
+	cm := newCheckpointManager()
+
+	signed := Checkpoint{Since: 12, Number: 10, Hash: common.HexToHash("0x1234")}
+	sig, err := crypto.Sign(cm.hashToSign(&signed), key)
+	assert.NoError(t, err)
+	assert.NoError(t, cm.addCheckpoint(chain, signed, []CheckpointSignature{sig, {1}}, false))
+
+	// Hardcoded and local checkpoints have no signatures
+	assert.NoError(t, cm.addCheckpoint(chain, Checkpoint{Number: 5, Hash: common.HexToHash("0x5")}, nil, true))
+	assert.NoError(t, cm.addCheckpoint(chain, Checkpoint{Number: 20, Hash: common.HexToHash("0x20")}, nil, true))
+
+	exported := cm.export(chain)
+	assert.Equal(t, []SignedCheckpoint{
+		NewSignedCheckpoint(signed, []CheckpointSignature{sig, {1}}),
+	}, exported)
+
+	data, err := json.Marshal(exported)
+	assert.NoError(t, err)
+	imported, err := ParseSignedCheckpoints(data)
+	assert.NoError(t, err)
+
+	other := &fakeCheckpointChain{config: config}
+	other_cm := newCheckpointManager()
+	for _, scp := range imported {
+		assert.NoError(t, other_cm.addTrusted(other, scp))
+	}
+	assert.Equal(t, []Checkpoint{signed}, other.enforced)
+	assert.Equal(t, exported, other_cm.export(other))
+}
+
+func TestLocalCheckpoints(t *testing.T) {
+	t.Parallel()
+	log.Root().SetHandler(log.StdoutHandler)
+
+	engine := ethash.NewFaker()
+	db, chain, err := newCanonical(engine, 10, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer chain.Stop()
+
+	fpn := uint64(3)
+	fp := chain.GetBlockByNumber(fpn)
+	canonical := chain.GetHeaderByNumber(fpn + 1).Hash()
+
+	blocks := makeBlockChain(fp, 2, engine, db, canonicalSeed+1)
+	_, err = chain.InsertChain(blocks)
+	assert.Empty(t, err)
+	fork := blocks[0].Hash()
+
+	log.Trace("Matching checkpoint")
+	rollback, err := chain.AddLocalCheckpoint(
+		Checkpoint{Number: 2, Hash: chain.GetHeaderByNumber(2).Hash()}, "test", 0, false)
+	assert.NoError(t, err)
+	assert.Nil(t, rollback)
+
+	log.Trace("Conflicting checkpoint")
+	cp := Checkpoint{Number: fpn + 1, Hash: fork}
+	rollback, err = chain.AddLocalCheckpoint(cp, "test", time.Hour, false)
+	assert.Equal(t, ErrCheckpointConflict, err)
+	assert.Equal(t, &CheckpointRollback{
+		Number:    fpn + 1,
+		Canonical: canonical,
+		Head:      10,
+		Blocks:    7,
+		Reorg:     true,
+	}, rollback)
+	assert.Equal(t, canonical, chain.GetHeaderByNumber(fpn+1).Hash())
+	assert.Len(t, chain.ListLocalCheckpoints(), 1)
+
+	log.Trace("Forced checkpoint")
+	rollback, err = chain.AddLocalCheckpoint(cp, "test", time.Hour, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, rollback)
+	assert.Equal(t, fork, chain.GetHeaderByNumber(fpn+1).Hash())
+
+	local := chain.ListLocalCheckpoints()
+	assert.Len(t, local, 2)
+	assert.Equal(t, cp, local[0].Checkpoint)
+	assert.Equal(t, "test", local[0].Source)
+	assert.NotZero(t, local[0].Expires)
+	assert.Zero(t, local[1].Expires)
+
+	log.Trace("Validated checkpoint mismatch")
+	other := Checkpoint{Number: fpn + 1, Hash: canonical}
+	_, err = chain.AddLocalCheckpoint(other, "test", 0, false)
+	assert.Error(t, err)
+
+	log.Trace("Persistence")
+	var stored []LocalCheckpoint
+	assert.NoError(t, json.Unmarshal(rawdb.ReadLocalCheckpoints(db), &stored))
+	assert.Equal(t, local, stored)
+
+	log.Trace("Removal")
+	assert.Equal(t, ErrCheckpointNotLocal, chain.RemoveLocalCheckpoint(fpn))
+	assert.NoError(t, chain.RemoveLocalCheckpoint(fpn+1))
+	assert.Nil(t, chain.CheckpointSignatures(cp))
+	assert.Equal(t, uint64(2), chain.checkpoints.latest)
+	assert.Len(t, chain.ListLocalCheckpoints(), 1)
+
+	log.Trace("Expiry")
+	_, err = chain.AddLocalCheckpoint(cp, "test", time.Hour, false)
+	assert.NoError(t, err)
+	cm := chain.checkpoints
+	assert.Equal(t, 0, cm.expireLocal(chain, uint64(time.Now().Unix())))
+	assert.Equal(t, 1, cm.expireLocal(chain, uint64(time.Now().Add(2*time.Hour).Unix())))
+	assert.Len(t, chain.ListLocalCheckpoints(), 1)
+}
+
+func TestLocalCheckpointShadowing(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	chain := &fakeCheckpointChain{
+		config: &params.ChainConfig{
+			Nuclear: &params.NuclearConfig{
+				CPPSigner: crypto.PubkeyToAddress(key.PublicKey),
+			},
+		},
+	}
+	cm := newCheckpointManager()
+
+	signed := Checkpoint{Since: 12, Number: 10, Hash: common.HexToHash("0x1234")}
+	sig, err := crypto.Sign(cm.hashToSign(&signed), key)
+	assert.NoError(t, err)
+	sigs := []CheckpointSignature{sig, {1}}
+	assert.NoError(t, cm.addCheckpoint(chain, signed, sigs, false))
+
+	// The same checkpoint keeps the signatures
+	same := LocalCheckpoint{Checkpoint: Checkpoint{Number: 10, Hash: signed.Hash}}
+	assert.NoError(t, cm.addLocal(chain, same))
+	assert.Equal(t, sigs, cm.validated[10].signatures)
+	assert.NoError(t, cm.removeLocal(chain, 10))
+	assert.Equal(t, validCheckpoint{signed, sigs}, cm.validated[10])
+
+	// A conflicting one replaces it until removed, also after another one
+	other := LocalCheckpoint{Checkpoint: Checkpoint{Number: 10, Hash: common.HexToHash("0x5678")}}
+	assert.NoError(t, cm.addLocal(chain, other))
+	assert.Equal(t, validCheckpoint{Checkpoint: other.Checkpoint}, cm.validated[10])
+	assert.NoError(t, cm.addLocal(chain, same))
+	assert.Equal(t, validCheckpoint{signed, sigs}, cm.validated[10])
+	assert.NoError(t, cm.addLocal(chain, other))
+	assert.NoError(t, cm.removeLocal(chain, 10))
+	assert.Equal(t, validCheckpoint{signed, sigs}, cm.validated[10])
+	assert.Len(t, cm.shadowed, 0)
+
+	// Nothing to restore
+	assert.NoError(t, cm.addLocal(chain, LocalCheckpoint{Checkpoint: Checkpoint{Number: 20}}))
+	assert.NoError(t, cm.removeLocal(chain, 20))
+	assert.NotContains(t, cm.validated, uint64(20))
+	assert.Equal(t, uint64(10), cm.latest)
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/params"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+// DevnetMasternode is a masternode announced in the developer network genesis.
+type DevnetMasternode struct {
+	Owner      common.Address
+	Masternode common.Address
+	Collateral *big.Int
+	IPv4       uint32
+	Enode      [2][32]byte
+}
+
+// DevnetConfig describes the genesis of a developer network.
+type DevnetConfig struct {
+	ChainID     *big.Int
+	Timestamp   uint64
+	Signers     params.NuclearConfig
+	Alloc       GenesisAlloc
+	Masternodes []DevnetMasternode
+}
+
+// DevnetChainConfig returns the chain configuration of a developer network
+// with short governance periods.
+func DevnetChainConfig(chainID *big.Int, signers params.NuclearConfig) *params.ChainConfig {
+	config := *params.NuclearTestnetChainConfig
+	config.ChainID = new(big.Int).Set(chainID)
+	config.Nuclear = &signers
+	config.SuperblockCycle = big.NewInt(60)
+	config.MNCleanupPeriod = big.NewInt(60 * 60)
+	return &config
+}
+
+// DevnetGenesisBlock returns the custom genesis of a developer network as
+// accepted by the init command, i.e. without the governance deployment.
+// The masternode owners get their collateral deposited and the masternodes
+// announced by the genesis calls.
+func DevnetGenesisBlock(cfg *DevnetConfig) (*Genesis, error) {
+	if cfg.ChainID.Cmp(params.NuclearMainnetChainConfig.ChainID) == 0 {
+		return nil, errors.New("developer network must not use the mainnet chain ID")
+	}
+
+	token_abi, err := abi.JSON(strings.NewReader(energi_abi.IMasternodeTokenABI))
+	if err != nil {
+		return nil, err
+	}
+
+	registry_abi, err := abi.JSON(strings.NewReader(energi_abi.IMasternodeRegistryV2ABI))
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := &Genesis{
+		Config:     DevnetChainConfig(cfg.ChainID, cfg.Signers),
+		Coinbase:   energi_params.Nuclear_Treasury,
+		Timestamp:  cfg.Timestamp,
+		ExtraData:  []byte{},
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(0xFFFF),
+		Alloc:      DefaultPrealloc(),
+	}
+
+	for addr, account := range cfg.Alloc {
+		genesis.Alloc[addr] = account
+	}
+
+	for _, mn := range cfg.Masternodes {
+		if mn.Collateral.Sign() <= 0 {
+			return nil, fmt.Errorf("no collateral of masternode %s", mn.Masternode.Hex())
+		}
+
+		// The collateral is funded on top of the owner allocation
+		account := genesis.Alloc[mn.Owner]
+		balance := new(big.Int).Set(mn.Collateral)
+		if account.Balance != nil {
+			balance.Add(balance, account.Balance)
+		}
+		account.Balance = balance
+		genesis.Alloc[mn.Owner] = account
+
+		deposit, err := token_abi.Pack("depositCollateral")
+		if err != nil {
+			return nil, err
+		}
+
+		announce, err := registry_abi.Pack("announce", mn.Masternode, mn.IPv4, mn.Enode)
+		if err != nil {
+			return nil, err
+		}
+
+		owner := mn.Owner
+		genesis.Xfers = append(genesis.Xfers,
+			GenesisXfer{
+				Addr:  energi_params.Nuclear_MasternodeToken,
+				Code:  deposit,
+				Value: mn.Collateral,
+				From:  &owner,
+			},
+			GenesisXfer{
+				Addr: energi_params.Nuclear_MasternodeRegistry,
+				Code: announce,
+				From: &owner,
+			},
+		)
+	}
+
+	return genesis, nil
+}
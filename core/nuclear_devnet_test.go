@@ -0,0 +1,123 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/core/vm"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func TestDevnetGenesisBlock(t *testing.T) {
+	t.Parallel()
+
+	staker := common.HexToAddress("0x1000")
+	collateral := new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))
+
+	cfg := &DevnetConfig{
+		ChainID: big.NewInt(59797),
+		Signers: params.NuclearConfig{
+			CPPSigner: common.HexToAddress("0x2001"),
+			EBISigner: common.HexToAddress("0x2002"),
+		},
+		Alloc: GenesisAlloc{
+			staker: {Balance: collateral},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateKey()
+		pubkey := crypto.CompressPubkey(&key.PublicKey)
+
+		mn := DevnetMasternode{
+			Owner:      common.BigToAddress(big.NewInt(int64(0x3000 + i))),
+			Masternode: crypto.PubkeyToAddress(key.PublicKey),
+			Collateral: collateral,
+			IPv4:       0x01020300 + uint32(i),
+		}
+		copy(mn.Enode[0][:], pubkey[:32])
+		copy(mn.Enode[1][:], pubkey[32:])
+		cfg.Masternodes = append(cfg.Masternodes, mn)
+	}
+
+	// Mainnet is refused
+	_, err := DevnetGenesisBlock(&DevnetConfig{ChainID: params.NuclearMainnetChainConfig.ChainID})
+	assert.NotEmpty(t, err)
+
+	genesis, err := DevnetGenesisBlock(cfg)
+	assert.Empty(t, err)
+	assert.Equal(t, cfg.Signers.CPPSigner, genesis.Config.Nuclear.CPPSigner)
+	assert.Len(t, genesis.Xfers, 4)
+
+	// Must survive the genesis file round trip
+	data, err := json.Marshal(genesis)
+	assert.Empty(t, err)
+	genesis = new(Genesis)
+	assert.Empty(t, json.Unmarshal(data, genesis))
+	assert.Equal(t, cfg.Masternodes[0].Owner, *genesis.Xfers[0].From)
+
+	// The same way as the init command does
+	genesis.Xfers = append(genesis.Xfers, DeployNuclearGovernance(genesis.Config)...)
+
+	db := ethdb.NewMemDatabase()
+	block := genesis.MustCommit(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	assert.Empty(t, err)
+
+	assert.Equal(t, collateral, statedb.GetBalance(staker))
+	assert.Equal(t, common.Big0.Uint64(), statedb.GetBalance(cfg.Masternodes[0].Owner).Uint64())
+
+	registry_abi, err := abi.JSON(strings.NewReader(energi_abi.IMasternodeRegistryV2ABI))
+	assert.Empty(t, err)
+	input, err := registry_abi.Pack("count")
+	assert.Empty(t, err)
+
+	msg := types.NewMessage(
+		common.Address{}, &energi_params.Nuclear_MasternodeRegistry,
+		0, common.Big0, 1000000, common.Big0, input, false)
+	evm := vm.NewEVM(NewEVMContext(msg, block.Header(), nil, &common.Address{}),
+		statedb, genesis.Config, vm.Config{})
+	ret, _, failed, err := ApplyMessage(evm, msg, new(GasPool).AddGas(1000000))
+	assert.Empty(t, err)
+	assert.False(t, failed)
+
+	count := new(struct {
+		Active           *big.Int
+		Total            *big.Int
+		ActiveCollateral *big.Int
+		TotalCollateral  *big.Int
+		MaxOfAllTimes    *big.Int
+	})
+	assert.Empty(t, registry_abi.Unpack(count, "count", ret))
+	assert.Equal(t, big.NewInt(2), count.Active)
+	assert.Equal(t, new(big.Int).Mul(collateral, big.NewInt(2)), count.ActiveCollateral)
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/params"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+var nuclearContractNames = map[common.Address]string{
+	energi_params.Nuclear_BlockReward:        "BlockReward",
+	energi_params.Nuclear_Treasury:           "Treasury",
+	energi_params.Nuclear_MasternodeRegistry: "MasternodeRegistry",
+	energi_params.Nuclear_StakerReward:       "StakerReward",
+	energi_params.Nuclear_BackboneReward:     "BackboneReward",
+	energi_params.Nuclear_SporkRegistry:      "SporkRegistry",
+	energi_params.Nuclear_CheckpointRegistry: "CheckpointRegistry",
+	energi_params.Nuclear_BlacklistRegistry:  "BlacklistRegistry",
+	energi_params.Nuclear_MigrationContract:  "Gen2Migration",
+	energi_params.Nuclear_MasternodeToken:    "MasternodeToken",
+	energi_params.Nuclear_Blacklist:          "Blacklist",
+	energi_params.Nuclear_Whitelist:          "Whitelist",
+	energi_params.Nuclear_MasternodeList:     "MasternodeList",
+
+	energi_params.Nuclear_BlockRewardV1:        "BlockRewardV1",
+	energi_params.Nuclear_TreasuryV1:           "TreasuryV1",
+	energi_params.Nuclear_MasternodeRegistryV1: "MasternodeRegistryV1",
+	energi_params.Nuclear_StakerRewardV1:       "StakerRewardV1",
+	energi_params.Nuclear_BackboneRewardV1:     "BackboneRewardV1",
+	energi_params.Nuclear_SporkRegistryV1:      "SporkRegistryV1",
+	energi_params.Nuclear_CheckpointRegistryV1: "CheckpointRegistryV1",
+	energi_params.Nuclear_BlacklistRegistryV1:  "BlacklistRegistryV1",
+	energi_params.Nuclear_CompensationFundV1:   "CompensationFundV1",
+	energi_params.Nuclear_MasternodeTokenV1:    "MasternodeTokenV1",
+}
+
+// GenesisContract is a contract deployed by the genesis.
+type GenesisContract struct {
+	Name     string                      `json:"name"`
+	Address  common.Address              `json:"address"`
+	Proxy    *common.Address             `json:"proxy,omitempty"`
+	Impl     *common.Address             `json:"impl,omitempty"`
+	CodeHash common.Hash                 `json:"codeHash"`
+	CodeSize int                         `json:"codeSize"`
+	Balance  *big.Int                    `json:"balance"`
+	Storage  map[common.Hash]common.Hash `json:"storage"`
+}
+
+// GenesisInspection is the system contract deployment of a genesis.
+type GenesisInspection struct {
+	Hash      common.Hash       `json:"hash"`
+	Expected  *common.Hash      `json:"expected,omitempty"`
+	Contracts []GenesisContract `json:"contracts"`
+	Problems  []string          `json:"problems"`
+}
+
+// Passed tells if no problem is found.
+func (gi *GenesisInspection) Passed() bool {
+	return len(gi.Problems) == 0
+}
+
+// InspectNuclearGenesis builds the genesis state in memory and lists the system
+// contracts with their constructor-set storage. The genesis hash is checked
+// against the known one of the chain ID, the governed proxies against their
+// genesis implementations.
+func InspectNuclearGenesis(g *Genesis) (*GenesisInspection, error) {
+	if g.Config == nil || g.Config.Nuclear == nil {
+		return nil, fmt.Errorf("not a Nuclear genesis")
+	}
+
+	db := ethdb.NewMemDatabase()
+	block := g.ToBlock(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &GenesisInspection{
+		Hash:      block.Hash(),
+		Contracts: []GenesisContract{},
+		Problems:  []string{},
+	}
+
+	switch g.Config.ChainID.Uint64() {
+	case params.NuclearMainnetChainConfig.ChainID.Uint64():
+		ret.Expected = &params.MainnetGenesisHash
+	case params.NuclearTestnetChainConfig.ChainID.Uint64():
+		ret.Expected = &params.TestnetGenesisHash
+	}
+
+	if ret.Expected != nil && *ret.Expected != ret.Hash {
+		ret.Problems = append(ret.Problems, fmt.Sprintf(
+			"genesis hash %s does not match %s", ret.Hash.Hex(), ret.Expected.Hex()))
+	}
+
+	// Contracts deployed by the genesis in the address order
+	seen := make(map[common.Address]bool)
+	addresses := []common.Address{}
+	for _, xfer := range g.Xfers {
+		if xfer.From != nil || seen[xfer.Addr] {
+			continue
+		}
+		seen[xfer.Addr] = true
+		addresses = append(addresses, xfer.Addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Big().Cmp(addresses[j].Big()) < 0
+	})
+
+	impl_proxies := make(map[common.Address]common.Address)
+	for proxy := range energi_params.Nuclear_GovernedProxies {
+		impl := common.BytesToAddress(
+			statedb.GetState(proxy, energi_params.Storage_ProxyImpl).Bytes())
+		impl_proxies[impl] = proxy
+	}
+
+	for _, addr := range addresses {
+		name, ok := nuclearContractNames[addr]
+		if !ok {
+			name = "custom"
+		}
+
+		code := statedb.GetCode(addr)
+		contract := GenesisContract{
+			Name:     name,
+			Address:  addr,
+			CodeHash: crypto.Keccak256Hash(code),
+			CodeSize: len(code),
+			Balance:  statedb.GetBalance(addr),
+			Storage:  make(map[common.Hash]common.Hash),
+		}
+
+		// NOTE: the iterated values are RLP encoded
+		statedb.ForEachStorage(addr, func(key, _ common.Hash) bool {
+			contract.Storage[key] = statedb.GetState(addr, key)
+			return true
+		})
+
+		if genesis_impl, ok := energi_params.Nuclear_GovernedProxies[addr]; ok {
+			impl := common.BytesToAddress(
+				statedb.GetState(addr, energi_params.Storage_ProxyImpl).Bytes())
+			contract.Impl = &impl
+
+			if impl != genesis_impl {
+				ret.Problems = append(ret.Problems, fmt.Sprintf(
+					"proxy %s points to %s instead of %s",
+					addr.Hex(), impl.Hex(), genesis_impl.Hex()))
+			}
+		}
+
+		if proxy, ok := impl_proxies[addr]; ok {
+			contract.Proxy = &proxy
+		}
+
+		if len(code) == 0 {
+			ret.Problems = append(ret.Problems, fmt.Sprintf(
+				"no code at %s", addr.Hex()))
+		}
+
+		ret.Contracts = append(ret.Contracts, contract)
+	}
+
+	for proxy := range energi_params.Nuclear_GovernedProxies {
+		if !seen[proxy] {
+			ret.Problems = append(ret.Problems, fmt.Sprintf(
+				"governed proxy %s is not deployed", proxy.Hex()))
+		}
+	}
+	sort.Strings(ret.Problems)
+
+	return ret, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func TestInspectNuclearGenesis(t *testing.T) {
+	t.Parallel()
+
+	res, err := InspectNuclearGenesis(DefaultNuclearMainnetGenesisBlock())
+	assert.Empty(t, err)
+	assert.Equal(t, params.MainnetGenesisHash, *res.Expected)
+	assert.Equal(t, res.Hash, *res.Expected)
+	assert.True(t, res.Passed())
+
+	contracts := make(map[common.Address]GenesisContract)
+	for _, c := range res.Contracts {
+		contracts[c.Address] = c
+	}
+	assert.Len(t, contracts, 2*len(energi_params.Nuclear_GovernedProxies)+5)
+
+	for proxy, impl := range energi_params.Nuclear_GovernedProxies {
+		assert.Equal(t, impl, *contracts[proxy].Impl)
+		assert.Equal(t, proxy, *contracts[impl].Proxy)
+		assert.Nil(t, contracts[proxy].Proxy)
+	}
+
+	treasury := contracts[energi_params.Nuclear_TreasuryV1]
+	assert.Equal(t, "TreasuryV1", treasury.Name)
+	assert.Equal(t, crypto.Keccak256Hash(common.FromHex(energi_abi.TreasuryV1RuntimeBin)), treasury.CodeHash)
+	assert.NotEmpty(t, treasury.Storage)
+
+	migration := contracts[energi_params.Nuclear_MigrationContract]
+	assert.Equal(t, new(big.Int).Mul(big.NewInt(0xFFFF), big.NewInt(1e18)), migration.Balance)
+
+	// Custom chain, no known hash
+	genesis := DefaultNuclearMainnetGenesisBlock()
+	config := *genesis.Config
+	config.ChainID = big.NewInt(59797)
+	genesis.Config = &config
+	res, err = InspectNuclearGenesis(genesis)
+	assert.Empty(t, err)
+	assert.Nil(t, res.Expected)
+	assert.True(t, res.Passed())
+
+	// Tampered mainnet
+	genesis = DefaultNuclearMainnetGenesisBlock()
+	genesis.Timestamp++
+	res, err = InspectNuclearGenesis(genesis)
+	assert.Empty(t, err)
+	assert.False(t, res.Passed())
+	assert.Len(t, res.Problems, 1)
+}
@@ -0,0 +1,273 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/log"
+)
+
+// LocalCheckpoint is a checkpoint enforced by the node operator without
+// any signatures. It is persisted across restarts until removed or expired.
+type LocalCheckpoint struct {
+	Checkpoint
+	Source  string // origin of the checkpoint, e.g. the API call
+	Added   uint64 // unix time of addition
+	Expires uint64 // unix time of expiry, zero for none
+}
+
+func (lcp *LocalCheckpoint) expired(now uint64) bool {
+	return lcp.Expires != 0 && lcp.Expires <= now
+}
+
+// CheckpointRollback describes the effect of checkpoint enforcement on
+// the current canonical chain.
+type CheckpointRollback struct {
+	Number    uint64      // checkpoint height
+	Canonical common.Hash // canonical block replaced at the height
+	Head      uint64      // current head height
+	Blocks    uint64      // number of canonical blocks dropped
+	Reorg     bool        // the chain is reorganized, otherwise rewound
+}
+
+// CheckpointRollback reports what EnforceCheckpoint would roll back for the
+// checkpoint. It returns nil if the checkpoint matches the canonical chain.
+func (bc *BlockChain) CheckpointRollback(cp Checkpoint) *CheckpointRollback {
+	header := bc.GetHeaderByNumber(cp.Number)
+	if header == nil || header.Hash() == cp.Hash {
+		return nil
+	}
+
+	head := bc.CurrentBlock().NumberU64()
+
+	return &CheckpointRollback{
+		Number:    cp.Number,
+		Canonical: header.Hash(),
+		Head:      head,
+		Blocks:    head - cp.Number + 1,
+		Reorg:     bc.HasBlock(cp.Hash, cp.Number),
+	}
+}
+
+// AddLocalCheckpoint enforces the checkpoint on behalf of the node operator.
+// A checkpoint conflicting with the canonical chain or with another validated
+// checkpoint is refused unless forced. The rollback report is returned either
+// way.
+func (bc *BlockChain) AddLocalCheckpoint(
+	cp Checkpoint,
+	source string,
+	ttl time.Duration,
+	force bool,
+) (*CheckpointRollback, error) {
+	rollback := bc.CheckpointRollback(cp)
+
+	if !force {
+		if rollback != nil {
+			log.Warn("Local checkpoint conflicts with canonical chain",
+				"checkpoint", cp, "canonical", rollback.Canonical, "blocks", rollback.Blocks)
+			return rollback, ErrCheckpointConflict
+		}
+
+		if bc.checkpoints.conflicts(cp) {
+			return nil, ErrCheckpointMismatch
+		}
+	}
+
+	now := time.Now()
+	lcp := LocalCheckpoint{
+		Checkpoint: cp,
+		Source:     source,
+		Added:      uint64(now.Unix()),
+	}
+	if ttl > 0 {
+		lcp.Expires = uint64(now.Add(ttl).Unix())
+	}
+
+	err := bc.checkpoints.addLocal(bc, lcp)
+	bc.writeLocalCheckpoints()
+
+	return rollback, err
+}
+
+// RemoveLocalCheckpoint stops enforcement of the local checkpoint. The chain
+// is not restored to the state before the checkpoint.
+func (bc *BlockChain) RemoveLocalCheckpoint(number uint64) error {
+	if err := bc.checkpoints.removeLocal(bc, number); err != nil {
+		return err
+	}
+
+	bc.writeLocalCheckpoints()
+	return nil
+}
+
+// ListLocalCheckpoints returns the local checkpoints, the latest first.
+func (bc *BlockChain) ListLocalCheckpoints() []LocalCheckpoint {
+	return bc.checkpoints.listLocal()
+}
+
+// expireLocalCheckpoints removes the local checkpoints past their expiry.
+func (bc *BlockChain) expireLocalCheckpoints() {
+	if bc.checkpoints.expireLocal(bc, uint64(time.Now().Unix())) > 0 {
+		bc.writeLocalCheckpoints()
+	}
+}
+
+func (bc *BlockChain) writeLocalCheckpoints() {
+	data, err := json.Marshal(bc.checkpoints.listLocal())
+	if err != nil {
+		log.Error("Failed to encode local checkpoints", "err", err)
+		return
+	}
+
+	rawdb.WriteLocalCheckpoints(bc.db, data)
+}
+
+// loadLocalCheckpoints restores the persisted local checkpoints.
+func (bc *BlockChain) loadLocalCheckpoints() {
+	data := rawdb.ReadLocalCheckpoints(bc.db)
+	if len(data) == 0 {
+		return
+	}
+
+	var local []LocalCheckpoint
+	if err := json.Unmarshal(data, &local); err != nil {
+		log.Error("Failed to decode local checkpoints", "err", err)
+		return
+	}
+
+	now := uint64(time.Now().Unix())
+	for _, lcp := range local {
+		if lcp.expired(now) {
+			log.Info("Local checkpoint expired", "checkpoint", lcp.Checkpoint)
+			continue
+		}
+
+		if err := bc.checkpoints.addLocal(bc, lcp); err != nil {
+			log.Error("Failed to enforce local checkpoint", "checkpoint", lcp.Checkpoint, "err", err)
+		}
+	}
+
+	if len(local) != len(bc.checkpoints.listLocal()) {
+		bc.writeLocalCheckpoints()
+	}
+}
+
+// conflicts checks if another checkpoint is validated at the same height.
+func (cm *checkpointManager) conflicts(cp Checkpoint) bool {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+
+	curr, ok := cm.validated[cp.Number]
+	return ok && curr.Hash != cp.Hash
+}
+
+func (cm *checkpointManager) addLocal(chain CheckpointChain, lcp LocalCheckpoint) error {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+
+	// NOTE: local checkpoints take precedence over any other one.
+	//       The replaced one is kept with its signatures to be restored
+	//       on removal.
+	curr, ok := cm.validated[lcp.Number]
+	if _, is_local := cm.local[lcp.Number]; ok && !is_local {
+		cm.shadowed[lcp.Number] = curr
+	}
+	cm.local[lcp.Number] = lcp
+
+	if prev, ok := cm.shadowed[lcp.Number]; ok && prev.Hash == lcp.Hash {
+		cm.validated[lcp.Number] = prev
+	} else {
+		cm.validated[lcp.Number] = validCheckpoint{Checkpoint: lcp.Checkpoint}
+	}
+	log.Info("Added local checkpoint", "checkpoint", lcp.Checkpoint, "source", lcp.Source, "expires", lcp.Expires)
+
+	err := chain.EnforceCheckpoint(lcp.Checkpoint)
+
+	cm.updateLatest(chain, &lcp.Checkpoint)
+
+	return err
+}
+
+func (cm *checkpointManager) removeLocal(chain CheckpointChain, number uint64) error {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+
+	lcp, ok := cm.local[number]
+	if !ok {
+		return ErrCheckpointNotLocal
+	}
+
+	prev, shadowed := cm.shadowed[number]
+	delete(cm.local, number)
+	delete(cm.shadowed, number)
+
+	if curr, ok := cm.validated[number]; ok && curr.Hash == lcp.Hash {
+		if shadowed {
+			cm.validated[number] = prev
+		} else {
+			delete(cm.validated, number)
+		}
+	}
+
+	// Find the latest checkpoint which is still in effect
+	cm.latest = 0
+	head := chain.CurrentHeader().Number.Uint64()
+	for n := range cm.validated {
+		if n > cm.latest && n <= head {
+			cm.latest = n
+		}
+	}
+
+	log.Info("Removed local checkpoint", "checkpoint", lcp.Checkpoint, "source", lcp.Source)
+	return nil
+}
+
+func (cm *checkpointManager) listLocal() []LocalCheckpoint {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+
+	res := make([]LocalCheckpoint, 0, len(cm.local))
+	for _, lcp := range cm.local {
+		res = append(res, lcp)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Number > res[j].Number
+	})
+
+	return res
+}
+
+func (cm *checkpointManager) expireLocal(chain CheckpointChain, now uint64) (count int) {
+	for _, lcp := range cm.listLocal() {
+		if !lcp.expired(now) {
+			continue
+		}
+
+		if err := cm.removeLocal(chain, lcp.Number); err == nil {
+			log.Info("Local checkpoint expired", "checkpoint", lcp.Checkpoint)
+			count++
+		}
+	}
+
+	return
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+)
+
+// SignedCheckpoint is the portable form of a checkpoint used for export and
+// import. The first signature must always belong to CPP_signer.
+type SignedCheckpoint struct {
+	Number     uint64          `json:"number"`
+	Hash       common.Hash     `json:"hash"`
+	Since      uint64          `json:"since"`
+	Signatures []hexutil.Bytes `json:"signatures"`
+}
+
+func NewSignedCheckpoint(cp Checkpoint, sigs []CheckpointSignature) SignedCheckpoint {
+	res := SignedCheckpoint{
+		Number:     cp.Number,
+		Hash:       cp.Hash,
+		Since:      cp.Since,
+		Signatures: make([]hexutil.Bytes, len(sigs)),
+	}
+	for i, sig := range sigs {
+		res.Signatures[i] = hexutil.Bytes(sig)
+	}
+	return res
+}
+
+func (scp *SignedCheckpoint) Checkpoint() Checkpoint {
+	return Checkpoint{
+		Since:  scp.Since,
+		Number: scp.Number,
+		Hash:   scp.Hash,
+	}
+}
+
+func (scp *SignedCheckpoint) CheckpointSignatures() []CheckpointSignature {
+	res := make([]CheckpointSignature, len(scp.Signatures))
+	for i, sig := range scp.Signatures {
+		res[i] = CheckpointSignature(sig)
+	}
+	return res
+}
+
+// ParseSignedCheckpoints decodes either a single signed checkpoint or a list
+// of them.
+func ParseSignedCheckpoints(data []byte) (res []SignedCheckpoint, err error) {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		var scp SignedCheckpoint
+		if err = json.Unmarshal(data, &scp); err != nil {
+			return nil, err
+		}
+		return []SignedCheckpoint{scp}, nil
+	}
+
+	err = json.Unmarshal(data, &res)
+	return
+}
+
+// ReadCheckpointFile loads signed checkpoints from a JSON file.
+func ReadCheckpointFile(path string) ([]SignedCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSignedCheckpoints(data)
+}
+
+// AddTrustedCheckpoint enforces a checkpoint supplied by the node operator.
+// Unlike the ones from the registry, it is accepted regardless of the
+// compiled-in checkpoints, but the CPP signature is still required.
+func (bc *BlockChain) AddTrustedCheckpoint(scp SignedCheckpoint) error {
+	return bc.checkpoints.addTrusted(bc, scp)
+}
+
+// ExportCheckpoints returns the validated checkpoints signed by CPP_signer.
+// The hardcoded and local ones have no signatures to be imported elsewhere.
+func (bc *BlockChain) ExportCheckpoints() []SignedCheckpoint {
+	return bc.checkpoints.export(bc)
+}
+
+func (cm *checkpointManager) addTrusted(chain CheckpointChain, scp SignedCheckpoint) error {
+	cp := scp.Checkpoint()
+	sigs := scp.CheckpointSignatures()
+
+	if err := cm.verifySignature(chain, &cp, sigs); err != nil {
+		return err
+	}
+
+	return cm.addCheckpoint(chain, cp, sigs, true)
+}
+
+func (cm *checkpointManager) export(chain CheckpointChain) []SignedCheckpoint {
+	cm.mtx.Lock()
+	defer cm.mtx.Unlock()
+
+	res := make([]SignedCheckpoint, 0, len(cm.validated))
+
+	for _, v := range cm.validated {
+		if len(v.signatures) == 0 {
+			continue
+		}
+
+		if err := cm.verifySignature(chain, &v.Checkpoint, v.signatures); err != nil {
+			continue
+		}
+
+		res = append(res, NewSignedCheckpoint(v.Checkpoint, v.signatures))
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Number < res[j].Number
+	})
+
+	return res
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/core/vm"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// Max number of states with pending internal transfers.
+	// It covers concurrent block processing, mining and tracing.
+	transferRecorderStates = 64
+)
+
+type recordedTransfer struct {
+	snapshot int
+	transfer *types.InternalTransfer
+}
+
+type stateTransfers struct {
+	transfers []recordedTransfer
+}
+
+// TransferRecorder collects internal value transfers of all transactions
+// processed with the StateDB until the block gets written to the chain.
+//
+// NOTE: transfers of EVM runs without transaction hash set by
+// StateDB.Prepare(), e.g. eth_call, are ignored.
+type TransferRecorder struct {
+	mtx    sync.Mutex
+	states *lru.Cache
+}
+
+var _ vm.TransferTracer = (*TransferRecorder)(nil)
+
+func NewTransferRecorder() *TransferRecorder {
+	states, err := lru.New(transferRecorderStates)
+	if err != nil {
+		panic(err)
+	}
+
+	return &TransferRecorder{
+		states: states,
+	}
+}
+
+func (r *TransferRecorder) state(env *vm.EVM, create bool) *stateTransfers {
+	statedb, ok := env.StateDB.(*state.StateDB)
+	if !ok {
+		return nil
+	}
+
+	if v, ok := r.states.Get(statedb); ok {
+		return v.(*stateTransfers)
+	}
+
+	if !create {
+		return nil
+	}
+
+	st := &stateTransfers{}
+	r.states.Add(statedb, st)
+	return st
+}
+
+func (r *TransferRecorder) CaptureTransfer(
+	env *vm.EVM, snapshot int, from, to common.Address, value *big.Int,
+) {
+	statedb, ok := env.StateDB.(*state.StateDB)
+	if !ok || statedb.TxHash() == (common.Hash{}) {
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	st := r.state(env, true)
+	st.transfers = append(st.transfers, recordedTransfer{
+		snapshot: snapshot,
+		transfer: &types.InternalTransfer{
+			TxHash: statedb.TxHash(),
+			From:   from,
+			To:     to,
+			Value:  new(big.Int).Set(value),
+			Depth:  uint64(env.Depth()),
+		},
+	})
+}
+
+func (r *TransferRecorder) CaptureRevert(env *vm.EVM, snapshot int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	st := r.state(env, false)
+	if st == nil {
+		return
+	}
+
+	// Snapshot revisions grow monotonically
+	i := len(st.transfers)
+	for i > 0 && st.transfers[i-1].snapshot >= snapshot {
+		i--
+	}
+	st.transfers = st.transfers[:i]
+}
+
+// Fork makes transfers recorded with the src state available with its copy
+// dst, e.g. when the block gets finalized on a copy of the state.
+func (r *TransferRecorder) Fork(src, dst *state.StateDB) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	v, ok := r.states.Get(src)
+	if !ok {
+		return
+	}
+
+	transfers := v.(*stateTransfers).transfers
+	r.states.Add(dst, &stateTransfers{
+		transfers: append([]recordedTransfer(nil), transfers...),
+	})
+}
+
+// Take removes and returns transfers recorded with the state
+// for transactions of the block, nil if nothing was recorded.
+func (r *TransferRecorder) Take(statedb *state.StateDB, block *types.Block) types.InternalTransfers {
+	r.mtx.Lock()
+	v, ok := r.states.Get(statedb)
+	r.states.Remove(statedb)
+	r.mtx.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	res := types.InternalTransfers{}
+	in_block := make(map[common.Hash]bool, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		in_block[tx.Hash()] = true
+	}
+
+	for _, t := range v.(*stateTransfers).transfers {
+		if in_block[t.transfer.TxHash] {
+			res = append(res, t.transfer)
+		}
+	}
+
+	return res
+}
+
+// indexInternalTransfers adds canonical block transfers to the per-address index.
+// A block is indexed only once, its entries get valid again when the block
+// becomes canonical again after a reorg.
+func indexInternalTransfers(db rawdb.DatabaseReader, putter rawdb.DatabaseWriter, block *types.Block, transfers types.InternalTransfers) {
+	if rawdb.HasInternalTransfersIndex(db, block.Hash(), block.NumberU64()) {
+		return
+	}
+	rawdb.WriteInternalTransfersIndex(putter, block.Hash(), block.NumberU64())
+
+	counts := make(map[common.Address]uint64)
+
+	add := func(addr common.Address, index int) {
+		count, ok := counts[addr]
+		if !ok {
+			count = rawdb.ReadInternalTransferCount(db, addr)
+		}
+
+		rawdb.WriteInternalTransferEntry(putter, addr, count, &rawdb.InternalTransferEntry{
+			BlockHash:   block.Hash(),
+			BlockNumber: block.NumberU64(),
+			Index:       uint64(index),
+		})
+		counts[addr] = count + 1
+	}
+
+	for i, t := range transfers {
+		add(t.From, i)
+		if t.To != t.From {
+			add(t.To, i)
+		}
+	}
+
+	for addr, count := range counts {
+		rawdb.WriteInternalTransferCount(putter, addr, count)
+	}
+}
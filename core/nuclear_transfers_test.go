@@ -0,0 +1,146 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/core/vm"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferRecorder(t *testing.T) {
+	t.Parallel()
+
+	db := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	rec := NewTransferRecorder()
+	env := vm.NewEVM(vm.Context{}, statedb, params.TestChainConfig, vm.Config{TransferTracer: rec})
+
+	a := common.HexToAddress("0x1")
+	b := common.HexToAddress("0x2")
+	c := common.HexToAddress("0x3")
+
+	tx1 := types.NewTransaction(0, a, common.Big1, 21000, common.Big1, nil)
+	tx2 := types.NewTransaction(1, a, common.Big1, 21000, common.Big1, nil)
+	other := types.NewTransaction(2, a, common.Big1, 21000, common.Big1, nil)
+	block := types.NewBlock(&types.Header{Number: common.Big1}, types.Transactions{tx1, tx2}, nil, nil)
+
+	// Ignored without transaction
+	rec.CaptureTransfer(env, statedb.Snapshot(), a, b, big.NewInt(1))
+
+	statedb.Prepare(tx1.Hash(), common.Hash{}, 0)
+	rec.CaptureTransfer(env, statedb.Snapshot(), a, b, big.NewInt(2))
+	s := statedb.Snapshot()
+	rec.CaptureTransfer(env, s, b, c, big.NewInt(3))
+	rec.CaptureTransfer(env, statedb.Snapshot(), c, a, big.NewInt(4))
+	rec.CaptureRevert(env, s)
+	rec.CaptureTransfer(env, statedb.Snapshot(), b, a, big.NewInt(5))
+
+	statedb.Prepare(other.Hash(), common.Hash{}, 1)
+	rec.CaptureTransfer(env, statedb.Snapshot(), a, c, big.NewInt(6))
+
+	statedb.Prepare(tx2.Hash(), common.Hash{}, 1)
+	rec.CaptureTransfer(env, statedb.Snapshot(), c, b, big.NewInt(7))
+
+	// Finalization on a copy of the state
+	statedb_copy := statedb.Copy()
+	rec.Fork(statedb, statedb_copy)
+	rec.Fork(statedb_copy, statedb_copy.Copy())
+	assert.Len(t, rec.Take(statedb_copy, block), 3)
+
+	transfers := rec.Take(statedb, block)
+	assert.Equal(t, types.InternalTransfers{
+		{TxHash: tx1.Hash(), From: a, To: b, Value: big.NewInt(2)},
+		{TxHash: tx1.Hash(), From: b, To: a, Value: big.NewInt(5)},
+		{TxHash: tx2.Hash(), From: c, To: b, Value: big.NewInt(7)},
+	}, transfers)
+	assert.Nil(t, rec.Take(statedb, block))
+
+	// Index
+	indexInternalTransfers(db, db, block, transfers)
+	assert.Equal(t, uint64(2), rawdb.ReadInternalTransferCount(db, a))
+	assert.Equal(t, uint64(3), rawdb.ReadInternalTransferCount(db, b))
+	assert.Equal(t, uint64(1), rawdb.ReadInternalTransferCount(db, c))
+	assert.Equal(t, &rawdb.InternalTransferEntry{
+		BlockHash:   block.Hash(),
+		BlockNumber: 1,
+		Index:       2,
+	}, rawdb.ReadInternalTransferEntry(db, b, 2))
+
+	// The block is not indexed again when it gets canonical again
+	indexInternalTransfers(db, db, block, transfers)
+	assert.Equal(t, uint64(2), rawdb.ReadInternalTransferCount(db, a))
+	assert.Equal(t, uint64(3), rawdb.ReadInternalTransferCount(db, b))
+}
+
+func TestTransferRecorderSuicide(t *testing.T) {
+	t.Parallel()
+
+	outer := common.HexToAddress("0x10")
+	inner := common.HexToAddress("0x20")
+	beneficiary := common.HexToAddress("0x30")
+
+	// The outer contract calls the inner one, which self-destructs,
+	// and then optionally reverts.
+	run := func(revert bool) types.InternalTransfers {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		rec := NewTransferRecorder()
+		ctx := vm.Context{
+			CanTransfer: CanTransfer,
+			Transfer:    Transfer,
+			BlockNumber: common.Big1,
+		}
+		env := vm.NewEVM(ctx, statedb, params.TestChainConfig, vm.Config{TransferTracer: rec})
+
+		code := []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+			byte(vm.PUSH1), 0, byte(vm.PUSH20),
+		}
+		code = append(code, inner.Bytes()...)
+		code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+		if revert {
+			code = append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT))
+		}
+		statedb.SetCode(outer, code)
+		statedb.SetCode(inner, append(append([]byte{byte(vm.PUSH20)}, beneficiary.Bytes()...), byte(vm.SELFDESTRUCT)))
+		statedb.SetBalance(inner, big.NewInt(5))
+
+		tx := types.NewTransaction(0, outer, common.Big0, 100000, common.Big1, nil)
+		block := types.NewBlock(&types.Header{Number: common.Big1}, types.Transactions{tx}, nil, nil)
+		statedb.Prepare(tx.Hash(), common.Hash{}, 0)
+
+		env.Call(vm.AccountRef(beneficiary), outer, nil, 100000, common.Big0)
+		return rec.Take(statedb, block)
+	}
+
+	transfers := run(false)
+	assert.Len(t, transfers, 1)
+	assert.Equal(t, inner, transfers[0].From)
+	assert.Equal(t, beneficiary, transfers[0].To)
+	assert.Equal(t, big.NewInt(5), transfers[0].Value)
+
+	assert.Equal(t, types.InternalTransfers{}, run(true))
+}
@@ -324,6 +324,38 @@ func DeleteReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadBlockRewards retrieves the reward breakdown of a block.
+func ReadBlockRewards(db DatabaseReader, hash common.Hash, number uint64) types.BlockRewards {
+	data, _ := db.Get(blockRewardsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	rewards := types.BlockRewards{}
+	if err := rlp.DecodeBytes(data, &rewards); err != nil {
+		log.Error("Invalid block rewards RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return rewards
+}
+
+// WriteBlockRewards stores the reward breakdown of a block.
+func WriteBlockRewards(db DatabaseWriter, hash common.Hash, number uint64, rewards types.BlockRewards) {
+	bytes, err := rlp.EncodeToBytes(rewards)
+	if err != nil {
+		log.Crit("Failed to encode block rewards", "err", err)
+	}
+	if err := db.Put(blockRewardsKey(number, hash), bytes); err != nil {
+		log.Crit("Failed to store block rewards", "err", err)
+	}
+}
+
+// DeleteBlockRewards removes the reward breakdown of a block.
+func DeleteBlockRewards(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockRewardsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block rewards", "err", err)
+	}
+}
+
 // ReadBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -351,6 +383,8 @@ func WriteBlock(db DatabaseWriter, block *types.Block) {
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteBlockRewards(db, hash, number)
+	DeleteInternalTransfers(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
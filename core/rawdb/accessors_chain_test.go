@@ -317,3 +317,37 @@ func TestBlockReceiptStorage(t *testing.T) {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
+
+// Tests that reward breakdown of a single block can be stored and retrieved.
+func TestBlockRewardsStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	rewards := types.BlockRewards{
+		{Recipient: common.BytesToAddress([]byte{0x11}), Category: types.RewardStaker, Amount: big.NewInt(1)},
+		{Recipient: common.BytesToAddress([]byte{0x22}), Category: types.RewardMasternode, Amount: big.NewInt(2)},
+	}
+
+	hash := common.BytesToHash([]byte{0x03, 0x14})
+	if rs := ReadBlockRewards(db, hash, 0); rs != nil {
+		t.Fatalf("non existent rewards returned: %v", rs)
+	}
+	WriteBlockRewards(db, hash, 0, rewards)
+	if rs := ReadBlockRewards(db, hash, 0); len(rs) != len(rewards) {
+		t.Fatalf("rewards mismatch: have %v, want %v", rs, rewards)
+	} else {
+		for i := range rewards {
+			if rs[i].Recipient != rewards[i].Recipient ||
+				rs[i].Category != rewards[i].Category ||
+				rs[i].Amount.Cmp(rewards[i].Amount) != 0 {
+				t.Fatalf("reward #%d mismatch: have %v, want %v", i, rs[i], rewards[i])
+			}
+		}
+	}
+	if total := rewards.Total(); total.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("total mismatch: have %v, want 3", total)
+	}
+	DeleteBlockRewards(db, hash, 0)
+	if rs := ReadBlockRewards(db, hash, 0); rs != nil {
+		t.Fatalf("deleted rewards returned: %v", rs)
+	}
+}
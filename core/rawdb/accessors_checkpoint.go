@@ -0,0 +1,34 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"nuclear/core/nuclear/log"
+)
+
+// ReadLocalCheckpoints retrieves the JSON encoded list of local checkpoints.
+func ReadLocalCheckpoints(db DatabaseReader) []byte {
+	data, _ := db.Get(localCheckpointsKey)
+	return data
+}
+
+// WriteLocalCheckpoints stores the JSON encoded list of local checkpoints.
+func WriteLocalCheckpoints(db DatabaseWriter, data []byte) {
+	if err := db.Put(localCheckpointsKey, data); err != nil {
+		log.Crit("Failed to store local checkpoints", "err", err)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/log"
+)
+
+// ReadMasternodeENR retrieves the RLP encoded signed node record last seen
+// for the masternode.
+func ReadMasternodeENR(db DatabaseReader, addr common.Address) []byte {
+	data, _ := db.Get(masternodeENRKey(addr))
+	return data
+}
+
+// WriteMasternodeENR stores the RLP encoded signed node record of the masternode.
+func WriteMasternodeENR(db DatabaseWriter, addr common.Address, data []byte) {
+	if err := db.Put(masternodeENRKey(addr), data); err != nil {
+		log.Crit("Failed to store masternode ENR", "err", err)
+	}
+}
+
+// DeleteMasternodeENR removes the node record of the masternode.
+func DeleteMasternodeENR(db DatabaseDeleter, addr common.Address) {
+	if err := db.Delete(masternodeENRKey(addr)); err != nil {
+		log.Crit("Failed to delete masternode ENR", "err", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rlp"
+)
+
+// ReadInternalTransfers retrieves all internal transfers of a block.
+func ReadInternalTransfers(db DatabaseReader, hash common.Hash, number uint64) types.InternalTransfers {
+	data, _ := db.Get(internalTransfersKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	transfers := types.InternalTransfers{}
+	if err := rlp.DecodeBytes(data, &transfers); err != nil {
+		log.Error("Invalid internal transfers RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return transfers
+}
+
+// WriteInternalTransfers stores all internal transfers of a block.
+func WriteInternalTransfers(db DatabaseWriter, hash common.Hash, number uint64, transfers types.InternalTransfers) {
+	bytes, err := rlp.EncodeToBytes(transfers)
+	if err != nil {
+		log.Crit("Failed to encode internal transfers", "err", err)
+	}
+	if err := db.Put(internalTransfersKey(number, hash), bytes); err != nil {
+		log.Crit("Failed to store internal transfers", "err", err)
+	}
+}
+
+// DeleteInternalTransfers removes all internal transfers of a block.
+func DeleteInternalTransfers(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(internalTransfersKey(number, hash)); err != nil {
+		log.Crit("Failed to delete internal transfers", "err", err)
+	}
+}
+
+// HasInternalTransfersIndex checks if internal transfers of a block are
+// already in the per-address index.
+func HasInternalTransfersIndex(db DatabaseReader, hash common.Hash, number uint64) bool {
+	if has, err := db.Has(internalTransfersIndexKey(number, hash)); !has || err != nil {
+		return false
+	}
+	return true
+}
+
+// WriteInternalTransfersIndex marks internal transfers of a block as indexed.
+func WriteInternalTransfersIndex(db DatabaseWriter, hash common.Hash, number uint64) {
+	if err := db.Put(internalTransfersIndexKey(number, hash), []byte{1}); err != nil {
+		log.Crit("Failed to store internal transfers index mark", "err", err)
+	}
+}
+
+// ReadInternalTransferCount retrieves the number of indexed transfers of the address.
+func ReadInternalTransferCount(db DatabaseReader, addr common.Address) uint64 {
+	data, _ := db.Get(internalTransferCountKey(addr))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteInternalTransferCount stores the number of indexed transfers of the address.
+func WriteInternalTransferCount(db DatabaseWriter, addr common.Address, count uint64) {
+	if err := db.Put(internalTransferCountKey(addr), encodeBlockNumber(count)); err != nil {
+		log.Crit("Failed to store internal transfer count", "err", err)
+	}
+}
+
+// ReadInternalTransferEntry retrieves the location of the seq-th transfer of the address.
+func ReadInternalTransferEntry(db DatabaseReader, addr common.Address, seq uint64) *InternalTransferEntry {
+	data, _ := db.Get(internalTransferEntryKey(addr, seq))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(InternalTransferEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid internal transfer entry RLP", "address", addr, "seq", seq, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteInternalTransferEntry stores the location of the seq-th transfer of the address.
+func WriteInternalTransferEntry(db DatabaseWriter, addr common.Address, seq uint64, entry *InternalTransferEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode internal transfer entry", "err", err)
+	}
+	if err := db.Put(internalTransferEntryKey(addr, seq), data); err != nil {
+		log.Crit("Failed to store internal transfer entry", "err", err)
+	}
+}
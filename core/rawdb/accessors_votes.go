@@ -0,0 +1,58 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"nuclear/core/nuclear/log"
+)
+
+// ReadVoteIndexHead retrieves the RLP encoded progress of the governance
+// vote index.
+func ReadVoteIndexHead(db DatabaseReader) []byte {
+	data, _ := db.Get(voteIndexHeadKey)
+	return data
+}
+
+// WriteVoteIndexHead stores the RLP encoded progress of the governance
+// vote index.
+func WriteVoteIndexHead(db DatabaseWriter, data []byte) {
+	if err := db.Put(voteIndexHeadKey, data); err != nil {
+		log.Crit("Failed to store vote index head", "err", err)
+	}
+}
+
+// ReadVoteIndexBlock retrieves the RLP encoded governance votes and
+// proposals of the canonical block.
+func ReadVoteIndexBlock(db DatabaseReader, number uint64) []byte {
+	data, _ := db.Get(voteIndexBlockKey(number))
+	return data
+}
+
+// WriteVoteIndexBlock stores the RLP encoded governance votes and proposals
+// of the canonical block.
+func WriteVoteIndexBlock(db DatabaseWriter, number uint64, data []byte) {
+	if err := db.Put(voteIndexBlockKey(number), data); err != nil {
+		log.Crit("Failed to store vote index block", "err", err)
+	}
+}
+
+// DeleteVoteIndexBlock removes the governance votes and proposals of the block.
+func DeleteVoteIndexBlock(db DatabaseDeleter, number uint64) {
+	if err := db.Delete(voteIndexBlockKey(number)); err != nil {
+		log.Crit("Failed to delete vote index block", "err", err)
+	}
+}
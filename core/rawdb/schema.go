@@ -38,9 +38,15 @@ var (
 	// headFastBlockKey tracks the latest known incomplete block's hash during fast sync.
 	headFastBlockKey = []byte("LastFast")
 
+	// localCheckpointsKey tracks the checkpoints added by the node operator.
+	localCheckpointsKey = []byte("LocalCheckpoints")
+
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// voteIndexHeadKey tracks the latest block of the governance vote index.
+	voteIndexHeadKey = []byte("VoteIndexHead")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -49,6 +55,16 @@ var (
 
 	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	blockRewardsPrefix  = []byte("w") // blockRewardsPrefix + num (uint64 big endian) + hash -> block rewards
+
+	internalTransfersPrefix      = []byte("x") // internalTransfersPrefix + num (uint64 big endian) + hash -> internal transfers
+	internalTransfersIndexPrefix = []byte("X") // internalTransfersIndexPrefix + num (uint64 big endian) + hash -> indexed mark
+	internalTransferCountPrefix  = []byte("y") // internalTransferCountPrefix + address -> count (uint64 big endian)
+	internalTransferEntryPrefix  = []byte("Y") // internalTransferEntryPrefix + address + seq (uint64 big endian) -> transfer lookup
+
+	masternodeENRPrefix = []byte("mnenr-") // masternodeENRPrefix + address -> signed masternode ENR
+
+	voteIndexBlockPrefix = []byte("vote-") // voteIndexBlockPrefix + num (uint64 big endian) -> governance votes of the block
 
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
@@ -71,6 +87,14 @@ type TxLookupEntry struct {
 	Index      uint64
 }
 
+// InternalTransferEntry is a positional metadata of an internal transfer
+// in the per-address index.
+type InternalTransferEntry struct {
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Index       uint64
+}
+
 // encodeBlockNumber encodes a block number as big endian uint64
 func encodeBlockNumber(number uint64) []byte {
 	enc := make([]byte, 8)
@@ -108,6 +132,41 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// blockRewardsKey = blockRewardsPrefix + num (uint64 big endian) + hash
+func blockRewardsKey(number uint64, hash common.Hash) []byte {
+	return append(append(blockRewardsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// internalTransfersKey = internalTransfersPrefix + num (uint64 big endian) + hash
+func internalTransfersKey(number uint64, hash common.Hash) []byte {
+	return append(append(internalTransfersPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// internalTransfersIndexKey = internalTransfersIndexPrefix + num (uint64 big endian) + hash
+func internalTransfersIndexKey(number uint64, hash common.Hash) []byte {
+	return append(append(internalTransfersIndexPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// internalTransferCountKey = internalTransferCountPrefix + address
+func internalTransferCountKey(addr common.Address) []byte {
+	return append(internalTransferCountPrefix, addr.Bytes()...)
+}
+
+// internalTransferEntryKey = internalTransferEntryPrefix + address + seq (uint64 big endian)
+func internalTransferEntryKey(addr common.Address, seq uint64) []byte {
+	return append(append(internalTransferEntryPrefix, addr.Bytes()...), encodeBlockNumber(seq)...)
+}
+
+// masternodeENRKey = masternodeENRPrefix + address
+func masternodeENRKey(addr common.Address) []byte {
+	return append(masternodeENRPrefix, addr.Bytes()...)
+}
+
+// voteIndexBlockKey = voteIndexBlockPrefix + num (uint64 big endian)
+func voteIndexBlockKey(number uint64) []byte {
+	return append(voteIndexBlockPrefix, encodeBlockNumber(number)...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
@@ -603,6 +603,21 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	return s.trie.Hash()
 }
 
+// DirtyAccounts returns the accounts modified since the state was loaded or
+// last committed. Only the finalised changes are included.
+func (s *StateDB) DirtyAccounts() []common.Address {
+	res := make([]common.Address, 0, len(s.stateObjectsDirty))
+	for addr := range s.stateObjectsDirty {
+		res = append(res, addr)
+	}
+	return res
+}
+
+// TxHash returns the current transaction hash set by Prepare.
+func (self *StateDB) TxHash() common.Hash {
+	return self.thash
+}
+
 // Prepare sets the current transaction hash and index and block hash which is
 // used when the EVM emits new state logs.
 func (self *StateDB) Prepare(thash, bhash common.Hash, ti int) {
@@ -0,0 +1,50 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"nuclear/core/nuclear/common"
+)
+
+// Block reward categories
+const (
+	RewardStaker     = "staker"
+	RewardBackbone   = "backbone"
+	RewardTreasury   = "treasury"
+	RewardMasternode = "masternode"
+)
+
+// BlockReward is a single item of the consensus block reward.
+type BlockReward struct {
+	Recipient common.Address
+	Category  string
+	Amount    *big.Int
+}
+
+// BlockRewards is the itemised reward of a block.
+type BlockRewards []*BlockReward
+
+// Total returns the sum of all reward items.
+func (rs BlockRewards) Total() *big.Int {
+	total := new(big.Int)
+	for _, r := range rs {
+		total.Add(total, r.Amount)
+	}
+	return total
+}
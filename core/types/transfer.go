@@ -0,0 +1,35 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"nuclear/core/nuclear/common"
+)
+
+// InternalTransfer is a value transfer made by an internal call of a transaction.
+type InternalTransfer struct {
+	TxHash common.Hash
+	From   common.Address
+	To     common.Address
+	Value  *big.Int
+	Depth  uint64
+}
+
+// InternalTransfers are all internal transfers of a block in execution order.
+type InternalTransfers []*InternalTransfer
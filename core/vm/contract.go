@@ -59,6 +59,8 @@ type Contract struct {
 
 	Gas   uint64
 	value *big.Int
+
+	snapshot int // StateDB revision of the call frame
 }
 
 // NewContract returns a new contract environment for the execution of EVM.
@@ -887,7 +887,9 @@ func opSuicide(pc *uint64, interpreter *EVMInterpreter, contract *Contract, memo
 		return nil, errExecutionReverted
 	}
 
-	interpreter.evm.StateDB.AddBalance(common.BigToAddress(stack.pop()), balance)
+	beneficiary := common.BigToAddress(stack.pop())
+	interpreter.evm.StateDB.AddBalance(beneficiary, balance)
+	interpreter.evm.captureTransfer(contract.snapshot, contract.Address(), beneficiary, balance)
 
 	interpreter.evm.StateDB.Suicide(contract.Address())
 	return nil, nil
@@ -32,6 +32,8 @@ type Config struct {
 	Debug bool
 	// Tracer is the op code logger
 	Tracer Tracer
+	// TransferTracer records internal value transfers, works without Debug
+	TransferTracer TransferTracer
 	// NoRecursion disabled Interpreter call, callcode,
 	// delegate call and create.
 	NoRecursion bool
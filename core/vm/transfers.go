@@ -0,0 +1,52 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+
+	"nuclear/core/nuclear/common"
+)
+
+// TransferTracer receives value transfers of internal calls.
+//
+// Unlike Tracer, it is not called per opcode and so it is suitable for
+// always-on recording. Snapshot is the StateDB revision the transfer belongs
+// to. All transfers of the snapshot and later ones must be discarded on
+// CaptureRevert.
+type TransferTracer interface {
+	CaptureTransfer(env *EVM, snapshot int, from, to common.Address, value *big.Int)
+	CaptureRevert(env *EVM, snapshot int)
+}
+
+func (evm *EVM) captureTransfer(snapshot int, from, to common.Address, value *big.Int) {
+	// NOTE: depth is zero for the top level call of transaction
+	if tt := evm.vmConfig.TransferTracer; tt != nil && evm.depth > 0 && value.Sign() > 0 {
+		tt.CaptureTransfer(evm, snapshot, from, to, value)
+	}
+}
+
+func (evm *EVM) captureRevert(snapshot int) {
+	if tt := evm.vmConfig.TransferTracer; tt != nil {
+		tt.CaptureRevert(evm, snapshot)
+	}
+}
+
+// Depth returns the current call depth.
+func (evm *EVM) Depth() int {
+	return evm.depth
+}
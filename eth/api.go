@@ -168,18 +168,52 @@ func (api *PrivateMinerAPI) RemoveDPoS(contract common.Address) bool {
 	return true
 }
 
-// Updated auto-collateralize mode
-func (api *PrivateMinerAPI) SetAutocollateralize(mode *uint64) (old uint64, err error) {
+// SetAutocollateralize enables or disables auto-collateralize after
+// masternode payouts and returns the old state.
+func (api *PrivateMinerAPI) SetAutocollateralize(enabled *bool) (old bool) {
 	old = api.e.Miner().GetMinerAutocollateral()
 
-	if mode != nil {
-		if *mode <= 2 {
-			api.e.Miner().SetMinerAutocollateral(*mode)
+	if enabled != nil {
+		api.e.Miner().SetMinerAutocollateral(*enabled)
+	}
+
+	return
+}
+
+// AutocollateralizePolicy is the RPC representation of miner.AutoCollateralPolicy.
+type AutocollateralizePolicy struct {
+	Target  *hexutil.Big `json:"target"`
+	Reserve *hexutil.Big `json:"reserve"`
+	MinStep *hexutil.Big `json:"minStep"`
+}
+
+// SetAutocollateralizePolicy updates the non-nil values of autocollateralize
+// policy and returns the old one. Zero value resets the default.
+func (api *PrivateMinerAPI) SetAutocollateralizePolicy(
+	target, reserve, minStep *hexutil.Big,
+) (old AutocollateralizePolicy) {
+	policy := api.e.Miner().GetMinerAutocollateralPolicy()
+
+	old.Target = (*hexutil.Big)(policy.Target)
+	old.Reserve = (*hexutil.Big)(policy.Reserve)
+	old.MinStep = (*hexutil.Big)(policy.MinStep)
+
+	set := func(dst **big.Int, v *hexutil.Big) {
+		if v == nil {
+			return
+		}
+		if v.ToInt().Sign() > 0 {
+			*dst = new(big.Int).Set(v.ToInt())
 		} else {
-			err = fmt.Errorf("Invalid mode %d", *mode)
+			*dst = nil
 		}
 	}
 
+	set(&policy.Target, target)
+	set(&policy.Reserve, reserve)
+	set(&policy.MinStep, minStep)
+	api.e.Miner().SetMinerAutocollateralPolicy(policy)
+
 	return
 }
 
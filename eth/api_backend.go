@@ -113,11 +113,15 @@ func (b *EthAPIBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	// Pending receipts are only known by the miner
+	if block, receipts := b.eth.miner.PendingBlockAndReceipts(); block != nil && block.Hash() == hash {
+		return receipts, nil
+	}
 	return b.eth.blockchain.GetReceiptsByHash(hash), nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
-	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
+	receipts, _ := b.GetReceipts(ctx, hash)
 	if receipts == nil {
 		return nil, nil
 	}
@@ -235,15 +239,21 @@ func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.Ma
 	}
 }
 
-func (b *EthAPIBackend) AddLocalCheckpoint(num uint64, hash common.Hash) error {
-	return b.eth.blockchain.AddCheckpoint(
-		core.Checkpoint{
-			Number: num,
-			Hash:   hash,
-		},
-		[]core.CheckpointSignature{},
-		true,
-	)
+func (b *EthAPIBackend) AddLocalCheckpoint(
+	cp core.Checkpoint,
+	source string,
+	ttl time.Duration,
+	force bool,
+) (*core.CheckpointRollback, error) {
+	return b.eth.blockchain.AddLocalCheckpoint(cp, source, ttl, force)
+}
+
+func (b *EthAPIBackend) RemoveLocalCheckpoint(number uint64) error {
+	return b.eth.blockchain.RemoveLocalCheckpoint(number)
+}
+
+func (b *EthAPIBackend) ListLocalCheckpoints() []core.LocalCheckpoint {
+	return b.eth.blockchain.ListLocalCheckpoints()
 }
 
 func (b *EthAPIBackend) AddDynamicCheckpoint(
@@ -271,6 +281,14 @@ func (b *EthAPIBackend) CheckpointSignatures(cp core.Checkpoint) []core.Checkpoi
 	return b.eth.blockchain.CheckpointSignatures(cp)
 }
 
+func (b *EthAPIBackend) AddTrustedCheckpoint(scp core.SignedCheckpoint) error {
+	return b.eth.blockchain.AddTrustedCheckpoint(scp)
+}
+
+func (b *EthAPIBackend) ExportCheckpoints() []core.SignedCheckpoint {
+	return b.eth.blockchain.ExportCheckpoints()
+}
+
 func (b *EthAPIBackend) IsPublicService() bool {
 	return b.eth.config.PublicService
 }
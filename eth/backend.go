@@ -156,6 +156,18 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 		rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 	}
+	// Spork overrides must be in place before any block is processed
+	if config.SporkOverride != "" {
+		if engine, ok := eth.engine.(*energi.Nuclear); ok {
+			overrides, err := energi.LoadSporkOverrides(config.SporkOverride)
+			if err != nil {
+				return nil, err
+			}
+			if err := engine.SetSporkOverrides(chainConfig.ChainID, overrides); err != nil {
+				return nil, err
+			}
+		}
+	}
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
@@ -164,6 +176,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout, TrieRapidLimit: config.TrieRapidTime}
 	)
+	if config.InternalTransfers {
+		vmConfig.TransferTracer = core.NewTransferRecorder()
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig, eth.shouldPreserve)
 	if err != nil {
 		return nil, err
@@ -187,11 +202,20 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		return nil, err
 	}
 
+	if config.Checkpoint != "" {
+		if err := eth.loadTrustedCheckpoint(config.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
 	if eth.dpos == nil {
 		eth.dpos = make(DPoSMap)
 	}
 	if energi, ok := eth.engine.(*energi.Nuclear); ok {
 		energi.SetMinerNonceCap(config.MinerNonceCap)
+		if err := energi.SetStakingPolicies(config.MinerStakingPolicy); err != nil {
+			return nil, err
+		}
 	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine, config.MinerRecommit, config.MinerGasFloor, config.MinerGasCeil, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.MinerExtraData))
@@ -206,6 +230,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 
 	eth.miner.SetEthAPIBackend(eth.APIBackend)
 	eth.miner.SetMinerAutocollateral(config.MinerAutocollateral)
+	eth.miner.SetMinerAutocollateralPolicy(miner.AutoCollateralPolicy{
+		Target:  config.MinerAutocollateralTarget,
+		Reserve: config.MinerAutocollateralReserve,
+		MinStep: config.MinerAutocollateralMinStep,
+	})
 
 	if energi, ok := eth.engine.(*energi.Nuclear); ok {
 		energi.SetMinerCB(
@@ -328,6 +357,8 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	mnAPI := energi_api.NewMasternodeAPI(s.APIBackend)
+
 	// Append all the local APIs and return
 	apis = append(apis, []rpc.API{
 		{
@@ -377,6 +408,8 @@ func (s *Ethereum) APIs() []rpc.API {
 	}...)
 
 	// Append Nuclear-specific APIs
+	governanceAPI := energi_api.NewGovernanceAPI(s.APIBackend)
+
 	apis = append(apis, []rpc.API{
 		{
 			Namespace: "energi",
@@ -398,15 +431,32 @@ func (s *Ethereum) APIs() []rpc.API {
 		{
 			Namespace: "energi",
 			Version:   "1.0",
-			Service:   energi_api.NewGovernanceAPI(s.APIBackend),
+			Service:   governanceAPI,
 			Public:    true,
 		},
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   energi_api.NewGovernanceAdminAPI(governanceAPI),
+		},
 		{
 			Namespace: "energi",
 			Version:   "1.0",
 			Service:   energi_api.NewMigrationAPI(s.APIBackend),
 			Public:    true,
 		},
+		{
+			Namespace: "energi",
+			Version:   "1.0",
+			Service:   energi_api.NewRewardsAPI(s.APIBackend),
+			Public:    true,
+		},
+		{
+			Namespace: "energi",
+			Version:   "1.0",
+			Service:   energi_api.NewInternalTransfersAPI(s.APIBackend),
+			Public:    true,
+		},
 		{
 			Namespace: "admin",
 			Version:   "1.0",
@@ -415,9 +465,14 @@ func (s *Ethereum) APIs() []rpc.API {
 		{
 			Namespace: "masternode",
 			Version:   "1.0",
-			Service:   energi_api.NewMasternodeAPI(s.APIBackend),
+			Service:   mnAPI,
 			Public:    true,
 		},
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   energi_api.NewMasternodeAdminAPI(mnAPI),
+		},
 	}...)
 
 	// Rename a copy of eth to nrg
@@ -619,6 +674,31 @@ func (s *Ethereum) EthVersion() int                    { return int(s.protocolMa
 func (s *Ethereum) NetVersion() uint64                 { return s.networkID }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
+// loadTrustedCheckpoint enforces the signed checkpoints of the file. The most
+// recent one must also be present in any chain the node syncs from.
+func (s *Ethereum) loadTrustedCheckpoint(path string) error {
+	checkpoints, err := core.ReadCheckpointFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint file: %v", err)
+	}
+	if len(checkpoints) == 0 {
+		return errors.New("no checkpoints in the file")
+	}
+
+	latest := checkpoints[0]
+	for _, scp := range checkpoints {
+		if err := s.blockchain.AddTrustedCheckpoint(scp); err != nil {
+			return fmt.Errorf("invalid checkpoint %d: %v", scp.Number, err)
+		}
+		if scp.Number > latest.Number {
+			latest = scp
+		}
+	}
+
+	s.protocolManager.downloader.SetTrustedCheckpoint(latest.Number, latest.Hash)
+	return nil
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -18,6 +18,7 @@
 package eth
 
 import (
+	"fmt"
 	"math/big"
 	"os"
 	"os/user"
@@ -31,7 +32,10 @@ import (
 	"nuclear/core/nuclear/core"
 	"nuclear/core/nuclear/eth/downloader"
 	"nuclear/core/nuclear/eth/gasprice"
+	"nuclear/core/nuclear/log"
 	"nuclear/core/nuclear/params"
+
+	energi "nuclear/core/nuclear/energi/consensus"
 )
 
 // DefaultConfig contains default settings for use on the Ethereum main net.
@@ -58,7 +62,7 @@ var DefaultConfig = Config{
 	MinerRecommit:  3 * time.Second,
 	MinerNonceCap:  0,
 
-	MinerAutocollateral: 1,
+	MinerAutocollateral: true,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -125,7 +129,12 @@ type Config struct {
 	MinerMigration string  `toml:",omitempty"`
 	MinerNonceCap  uint64  `toml:"-"`
 
-	MinerAutocollateral uint64 `toml:",omitempty"`
+	MinerStakingPolicy []energi.AccountStakingPolicy `toml:",omitempty"`
+
+	MinerAutocollateral        bool
+	MinerAutocollateralTarget  *big.Int `toml:",omitempty"`
+	MinerAutocollateralReserve *big.Int `toml:",omitempty"`
+	MinerAutocollateralMinStep *big.Int `toml:",omitempty"`
 
 	PublicService bool `toml:",omitempty"`
 
@@ -141,6 +150,15 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables recording of internal value transfers
+	InternalTransfers bool `toml:",omitempty"`
+
+	// Signed checkpoint file to enforce before sync
+	Checkpoint string `toml:",omitempty"`
+
+	// Spork override file to use instead of the on-chain sporks
+	SporkOverride string `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -158,5 +176,41 @@ type Config struct {
 }
 
 type configMarshaling struct {
-	MinerExtraData hexutil.Bytes
+	MinerExtraData      hexutil.Bytes
+	MinerAutocollateral autocollateral
+}
+
+// autocollateral also accepts the deprecated numeric modes in TOML.
+type autocollateral bool
+
+func (a *autocollateral) UnmarshalTOML(fn func(interface{}) error) error {
+	var enabled bool
+	if err := fn(&enabled); err == nil {
+		*a = autocollateral(enabled)
+		return nil
+	}
+
+	var mode uint64
+	if err := fn(&mode); err != nil {
+		return err
+	}
+
+	enabled, err := AutocollateralMode(mode)
+	if err != nil {
+		return err
+	}
+
+	log.Warn("Numeric MinerAutocollateral is deprecated, use true or false", "mode", mode)
+	*a = autocollateral(enabled)
+	return nil
+}
+
+// AutocollateralMode translates the deprecated numeric autocollateralize
+// modes: 0 disables it, 1 and 2 (rapid, no longer supported) enable it.
+func AutocollateralMode(mode uint64) (bool, error) {
+	if mode > 2 {
+		return false, fmt.Errorf("invalid autocollateralize mode %d", mode)
+	}
+
+	return mode != 0, nil
 }
@@ -111,6 +111,8 @@ type Downloader struct {
 	peers      *peerSet // Set of active peers from which download can proceed
 	stateDB    ethdb.Database
 
+	trusted atomic.Value // Operator supplied checkpoint any synced chain must contain
+
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
@@ -447,6 +449,10 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	}
 	height := latest.Number.Uint64()
 
+	if err := d.verifyTrusted(p, height); err != nil {
+		return err
+	}
+
 	origin, err := d.findAncestor(p, latest)
 	if err != nil {
 		return err
@@ -0,0 +1,81 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/log"
+)
+
+type trustedCheckpoint struct {
+	number uint64
+	hash   common.Hash
+}
+
+// SetTrustedCheckpoint makes the downloader refuse to sync from any peer
+// whose chain reaches the block height but does not contain the block.
+func (d *Downloader) SetTrustedCheckpoint(number uint64, hash common.Hash) {
+	d.trusted.Store(&trustedCheckpoint{number, hash})
+	log.Info("Trusted sync checkpoint", "number", number, "hash", hash)
+}
+
+// verifyTrusted checks the remote chain against the trusted checkpoint.
+func (d *Downloader) verifyTrusted(p *peerConnection, height uint64) error {
+	cp, _ := d.trusted.Load().(*trustedCheckpoint)
+	if cp == nil || height < cp.number {
+		return nil
+	}
+
+	go p.peer.RequestHeadersByNumber(cp.number, 1, 0, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return errCancelBlockFetch
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 {
+				p.log.Debug("Multiple headers for single request", "headers", len(headers))
+				return errBadPeer
+			}
+			if header := headers[0]; header.Number.Uint64() != cp.number || header.Hash() != cp.hash {
+				p.log.Warn("Remote chain does not contain trusted checkpoint",
+					"number", header.Number, "hash", header.Hash(), "checkpoint", cp.hash)
+				return errInvalidChain
+			}
+			return nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for checkpoint header timed out", "elapsed", ttl)
+			return errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
@@ -59,11 +59,33 @@ type Filter struct {
 	begin, end int64       // Range interval if filtering multiple blocks
 
 	matcher *bloombits.Matcher
+	proxies bool // resolve governed proxies to implementations
 }
 
 // NewRangeFilter creates a new filter which uses a bloom filter on blocks to
-// figure out whether a particular block is interesting or not.
+// figure out whether a particular block is interesting or not. Governed proxy
+// addresses also match the logs of their implementations.
 func NewRangeFilter(backend Backend, begin, end int64, addresses []common.Address, topics [][]common.Hash) *Filter {
+	filter := newRangeFilter(backend, begin, end, addresses, topics)
+	filter.proxies = true
+	return filter
+}
+
+func newRangeFilter(backend Backend, begin, end int64, addresses []common.Address, topics [][]common.Hash) *Filter {
+	size, _ := backend.BloomStatus()
+
+	// Create a generic filter and convert it into a range filter
+	filter := newFilter(backend, addresses, topics)
+
+	filter.matcher = newMatcher(size, addresses, topics)
+	filter.begin = begin
+	filter.end = end
+
+	return filter
+}
+
+// newMatcher creates the bloombits matcher for the filter criteria.
+func newMatcher(size uint64, addresses []common.Address, topics [][]common.Hash) *bloombits.Matcher {
 	// Flatten the address and topic filter clauses into a single bloombits filter
 	// system. Since the bloombits are not positional, nil topics are permitted,
 	// which get flattened into a nil byte slice.
@@ -82,16 +104,8 @@ func NewRangeFilter(backend Backend, begin, end int64, addresses []common.Addres
 		}
 		filters = append(filters, filter)
 	}
-	size, _ := backend.BloomStatus()
 
-	// Create a generic filter and convert it into a range filter
-	filter := newFilter(backend, addresses, topics)
-
-	filter.matcher = bloombits.NewMatcher(size, filters)
-	filter.begin = begin
-	filter.end = end
-
-	return filter
+	return bloombits.NewMatcher(size, filters)
 }
 
 // NewBlockFilter creates a new filter which directly inspects the contents of
@@ -100,6 +114,7 @@ func NewBlockFilter(backend Backend, block common.Hash, addresses []common.Addre
 	// Create a generic filter and convert it into a block filter
 	filter := newFilter(backend, addresses, topics)
 	filter.block = block
+	filter.proxies = true
 	return filter
 }
 
@@ -126,6 +141,10 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		if header == nil {
 			return nil, errors.New("unknown block")
 		}
+		number := header.Number.Uint64()
+		if err := f.resolveProxies(ctx, number, number); err != nil {
+			return nil, err
+		}
 		return f.blockLogs(ctx, header)
 	}
 	// Figure out the limits of the filter range
@@ -142,6 +161,9 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
+	if err := f.resolveProxies(ctx, uint64(f.begin), end); err != nil {
+		return nil, err
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -163,6 +185,27 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	return logs, err
 }
 
+// resolveProxies extends the filter addresses with the implementations the
+// governed proxies pointed to over the block range.
+func (f *Filter) resolveProxies(ctx context.Context, from, to uint64) error {
+	if !f.proxies || !hasProxies(f.addresses) {
+		return nil
+	}
+	f.proxies = false
+
+	history := proxyHistoryFor(f.backend)
+	if err := history.update(ctx, f.backend, to); err != nil {
+		return err
+	}
+
+	f.addresses = history.expand(f.addresses, from, to)
+	if f.matcher != nil {
+		size, _ := f.backend.BloomStatus()
+		f.matcher = newMatcher(size, f.addresses, f.topics)
+	}
+	return nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -93,6 +93,7 @@ type EventSystem struct {
 	backend   Backend
 	lightMode bool
 	lastHead  *types.Header
+	proxies   *proxyHistory
 
 	// Subscriptions
 	txsSub        event.Subscription         // Subscription for new transaction event
@@ -121,6 +122,7 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 		mux:       mux,
 		backend:   backend,
 		lightMode: lightMode,
+		proxies:   proxyHistoryFor(backend),
 		install:   make(chan *subscription),
 		uninstall: make(chan *subscription),
 		txsCh:     make(chan core.NewTxsEvent, txChanSize),
@@ -208,6 +210,17 @@ func (es *EventSystem) SubscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		to = rpc.BlockNumber(crit.ToBlock.Int64())
 	}
 
+	// Learn the implementations of the governed proxies up to now, the
+	// further upgrades are observed along with the new logs.
+	if hasProxies(crit.Addresses) {
+		ctx := context.Background()
+		if head, _ := es.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber); head != nil {
+			if err := es.proxies.update(ctx, es.backend, head.Number.Uint64()); err != nil {
+				log.Warn("Failed to resolve governed proxies", "err", err)
+			}
+		}
+	}
+
 	// only interested in pending logs
 	if from == rpc.PendingBlockNumber && to == rpc.PendingBlockNumber {
 		return es.subscribePendingLogs(crit, logs), nil
@@ -325,15 +338,16 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 	switch e := ev.(type) {
 	case []*types.Log:
 		if len(e) > 0 {
+			es.proxies.observe(e)
 			for _, f := range filters[LogsSubscription] {
-				if matchedLogs := filterLogs(e, f.logsCrit.FromBlock, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
+				if matchedLogs := filterLogs(e, f.logsCrit.FromBlock, f.logsCrit.ToBlock, es.proxies.expandAll(f.logsCrit.Addresses), f.logsCrit.Topics); len(matchedLogs) > 0 {
 					f.logs <- matchedLogs
 				}
 			}
 		}
 	case core.RemovedLogsEvent:
 		for _, f := range filters[LogsSubscription] {
-			if matchedLogs := filterLogs(e.Logs, f.logsCrit.FromBlock, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
+			if matchedLogs := filterLogs(e.Logs, f.logsCrit.FromBlock, f.logsCrit.ToBlock, es.proxies.expandAll(f.logsCrit.Addresses), f.logsCrit.Topics); len(matchedLogs) > 0 {
 				f.logs <- matchedLogs
 			}
 		}
@@ -341,7 +355,7 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		if muxe, ok := e.Data.(core.PendingLogsEvent); ok {
 			for _, f := range filters[PendingLogsSubscription] {
 				if e.Time.After(f.created) {
-					if matchedLogs := filterLogs(muxe.Logs, nil, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
+					if matchedLogs := filterLogs(muxe.Logs, nil, f.logsCrit.ToBlock, es.proxies.expandAll(f.logsCrit.Addresses), f.logsCrit.Topics); len(matchedLogs) > 0 {
 						f.logs <- matchedLogs
 					}
 				}
@@ -362,7 +376,7 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		if es.lightMode && len(filters[LogsSubscription]) > 0 {
 			es.lightFilterNewHead(e.Block.Header(), func(header *types.Header, remove bool) {
 				for _, f := range filters[LogsSubscription] {
-					if matchedLogs := es.lightFilterLogs(header, f.logsCrit.Addresses, f.logsCrit.Topics, remove); len(matchedLogs) > 0 {
+					if matchedLogs := es.lightFilterLogs(header, es.proxies.expandAll(f.logsCrit.Addresses), f.logsCrit.Topics, remove); len(matchedLogs) > 0 {
 						f.logs <- matchedLogs
 					}
 				}
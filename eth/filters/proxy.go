@@ -0,0 +1,277 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/rpc"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+// upgradedTopic is the signature of the GovernedProxy Upgraded event.
+var upgradedTopic = crypto.Keccak256Hash([]byte("Upgraded(address,address)"))
+
+// proxyHistories holds the proxy history per chain database.
+var proxyHistories sync.Map
+
+type proxyUpgrade struct {
+	number uint64
+	impl   common.Address
+}
+
+// proxyHistory tracks the implementations of the governed system proxies.
+// Events of governed contracts are emitted by the implementation, so a filter
+// on a proxy address has to match every implementation the proxy pointed to.
+type proxyHistory struct {
+	scanMu   sync.Mutex
+	mu       sync.Mutex
+	next     uint64      // first block not scanned yet
+	last     common.Hash // hash of the last scanned block
+	upgrades map[common.Address][]proxyUpgrade
+}
+
+func newProxyHistory() *proxyHistory {
+	return &proxyHistory{
+		upgrades: make(map[common.Address][]proxyUpgrade),
+	}
+}
+
+// proxyHistoryFor returns the proxy history shared by all filters of the chain.
+func proxyHistoryFor(backend Backend) *proxyHistory {
+	if h, ok := proxyHistories.Load(backend.ChainDb()); ok {
+		return h.(*proxyHistory)
+	}
+
+	h, _ := proxyHistories.LoadOrStore(backend.ChainDb(), newProxyHistory())
+	return h.(*proxyHistory)
+}
+
+// hasProxies checks if any of the addresses is a governed proxy.
+func hasProxies(addresses []common.Address) bool {
+	for _, addr := range addresses {
+		if _, ok := energi_params.Nuclear_GovernedProxies[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// update scans the chain for upgrades up to the block.
+func (h *proxyHistory) update(ctx context.Context, backend Backend, to uint64) error {
+	// NOTE: the scan runs unlocked not to stall the observation of new logs.
+	h.scanMu.Lock()
+	defer h.scanMu.Unlock()
+
+	h.mu.Lock()
+	from, last := h.next, h.last
+	h.mu.Unlock()
+
+	// Roll back to the common ancestor on reorganization of the scanned part
+	if from > 0 {
+		header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(from-1))
+		if err != nil {
+			return err
+		}
+		if header == nil || header.Hash() != last {
+			ancestor, err := h.findAncestor(ctx, backend, last)
+			if err != nil {
+				return err
+			}
+
+			h.mu.Lock()
+			h.rollback(ancestor)
+			from = h.next
+			h.mu.Unlock()
+		}
+	}
+
+	if to < from {
+		return nil
+	}
+
+	header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(to))
+	if header == nil || err != nil {
+		return err
+	}
+
+	proxies := make([]common.Address, 0, len(energi_params.Nuclear_GovernedProxies))
+	for proxy := range energi_params.Nuclear_GovernedProxies {
+		proxies = append(proxies, proxy)
+	}
+
+	filter := newRangeFilter(backend, int64(from), int64(to), proxies, [][]common.Hash{{upgradedTopic}})
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, log := range logs {
+		h.add(log)
+	}
+
+	h.next = to + 1
+	h.last = header.Hash()
+	return nil
+}
+
+// findAncestor walks back the previously scanned chain from the block until
+// it joins the canonical one. Nil is returned if the old chain is unknown.
+func (h *proxyHistory) findAncestor(ctx context.Context, backend Backend, hash common.Hash) (*types.Header, error) {
+	for {
+		old, err := backend.HeaderByHash(ctx, hash)
+		if old == nil || err != nil {
+			return nil, err
+		}
+
+		canonical, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(old.Number.Uint64()))
+		if err != nil {
+			return nil, err
+		}
+		if canonical != nil && canonical.Hash() == old.Hash() {
+			return old, nil
+		}
+
+		if old.Number.Sign() == 0 {
+			return nil, nil
+		}
+		hash = old.ParentHash
+	}
+}
+
+// rollback forgets the upgrades after the ancestor, everything without one.
+func (h *proxyHistory) rollback(ancestor *types.Header) {
+	if ancestor == nil {
+		h.next = 0
+		h.last = common.Hash{}
+		h.upgrades = make(map[common.Address][]proxyUpgrade)
+		return
+	}
+
+	number := ancestor.Number.Uint64()
+	for proxy, upgrades := range h.upgrades {
+		keep := upgrades[:0]
+		for _, up := range upgrades {
+			if up.number <= number {
+				keep = append(keep, up)
+			}
+		}
+		h.upgrades[proxy] = keep
+	}
+
+	h.next = number + 1
+	h.last = ancestor.Hash()
+}
+
+// observe records upgrades seen in newly imported logs.
+func (h *proxyHistory) observe(logs []*types.Log) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, log := range logs {
+		if log.BlockNumber >= h.next {
+			h.add(log)
+		}
+	}
+}
+
+func (h *proxyHistory) add(log *types.Log) {
+	if log.Removed || len(log.Topics) != 2 || log.Topics[0] != upgradedTopic {
+		return
+	}
+	if _, ok := energi_params.Nuclear_GovernedProxies[log.Address]; !ok {
+		return
+	}
+
+	up := proxyUpgrade{
+		number: log.BlockNumber,
+		impl:   common.BytesToAddress(log.Topics[1].Bytes()),
+	}
+
+	upgrades := h.upgrades[log.Address]
+	for _, known := range upgrades {
+		if known == up {
+			return
+		}
+	}
+
+	upgrades = append(upgrades, up)
+	sort.SliceStable(upgrades, func(i, j int) bool {
+		return upgrades[i].number < upgrades[j].number
+	})
+	h.upgrades[log.Address] = upgrades
+}
+
+// expand adds the implementations in effect over the block range to the
+// proxy addresses. A new slice is returned.
+func (h *proxyHistory) expand(addresses []common.Address, from, to uint64) []common.Address {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	res := append([]common.Address{}, addresses...)
+	seen := make(map[common.Address]bool, len(addresses))
+	for _, addr := range addresses {
+		seen[addr] = true
+	}
+	appendImpl := func(impl common.Address) {
+		if !seen[impl] {
+			seen[impl] = true
+			res = append(res, impl)
+		}
+	}
+
+	for _, addr := range addresses {
+		current, ok := energi_params.Nuclear_GovernedProxies[addr]
+		if !ok {
+			continue
+		}
+
+		var upgraded []common.Address
+		for _, up := range h.upgrades[addr] {
+			if up.number < from {
+				current = up.impl
+			} else if up.number <= to {
+				upgraded = append(upgraded, up.impl)
+			}
+		}
+
+		appendImpl(current)
+		for _, impl := range upgraded {
+			appendImpl(impl)
+		}
+	}
+
+	return res
+}
+
+// expandAll adds every known implementation to the proxy addresses.
+func (h *proxyHistory) expandAll(addresses []common.Address) []common.Address {
+	if !hasProxies(addresses) {
+		return addresses
+	}
+	return h.expand(addresses, 0, math.MaxUint64)
+}
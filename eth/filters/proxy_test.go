@@ -0,0 +1,186 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/consensus/ethash"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/event"
+	"nuclear/core/nuclear/params"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func makeLogReceipt(number uint64, addr common.Address, topics ...common.Hash) *types.Receipt {
+	receipt := types.NewReceipt(nil, false, 0)
+	receipt.Logs = []*types.Log{
+		{Address: addr, Topics: topics, BlockNumber: number},
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	return receipt
+}
+
+func TestProxyFilters(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		proxy   = energi_params.Nuclear_CheckpointRegistry
+		implV1  = energi_params.Nuclear_CheckpointRegistryV1
+		implV2  = common.HexToAddress("0x1234")
+		other   = common.HexToAddress("0x5678")
+		topic   = common.BytesToHash([]byte("event"))
+	)
+
+	genesis := core.GenesisBlockForTesting(db, other, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 20, func(i int, gen *core.BlockGen) {
+		number := uint64(i + 1)
+		switch number {
+		case 3:
+			gen.AddUncheckedReceipt(makeLogReceipt(number, implV1, topic))
+		case 10:
+			gen.AddUncheckedReceipt(makeLogReceipt(number, proxy, upgradedTopic, implV2.Hash()))
+		case 12:
+			gen.AddUncheckedReceipt(makeLogReceipt(number, implV2, topic))
+		case 15:
+			gen.AddUncheckedReceipt(makeLogReceipt(number, other, topic))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+
+	ctx := context.Background()
+	check := func(filter *Filter, expected ...uint64) {
+		logs, err := filter.Logs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) != len(expected) {
+			t.Fatalf("expected %d logs, got %d", len(expected), len(logs))
+		}
+		for i, log := range logs {
+			if log.BlockNumber != expected[i] {
+				t.Errorf("log %d: expected block %d, got %d", i, expected[i], log.BlockNumber)
+			}
+		}
+	}
+
+	// Full history of the proxy including the upgrade itself
+	check(NewRangeFilter(backend, 0, -1, []common.Address{proxy}, nil), 3, 10, 12)
+	check(NewRangeFilter(backend, 0, -1, []common.Address{proxy}, [][]common.Hash{{topic}}), 3, 12)
+
+	// Only the implementations in effect over the range
+	check(NewRangeFilter(backend, 0, 5, []common.Address{proxy}, nil), 3)
+	check(NewRangeFilter(backend, 11, 20, []common.Address{proxy}, nil), 12)
+	check(NewBlockFilter(backend, chain[11].Hash(), []common.Address{proxy}, nil), 12)
+
+	// Regular addresses are not affected
+	check(NewRangeFilter(backend, 0, -1, []common.Address{other}, nil), 15)
+	check(newRangeFilter(backend, 0, -1, []common.Address{proxy}, nil), 10)
+
+	history := proxyHistoryFor(backend)
+	all := history.expandAll([]common.Address{proxy, other})
+	if len(all) != 4 || all[2] != implV1 || all[3] != implV2 {
+		t.Errorf("unexpected implementations: %v", all)
+	}
+
+	// Live upgrades
+	implV3 := common.HexToAddress("0x9abc")
+	history.observe([]*types.Log{
+		{Address: proxy, Topics: []common.Hash{upgradedTopic, implV3.Hash()}, BlockNumber: 21},
+	})
+	if impls := history.expand([]common.Address{proxy}, 21, 21); len(impls) != 3 || impls[2] != implV3 {
+		t.Errorf("unexpected live implementations: %v", impls)
+	}
+}
+
+func TestProxyHistoryReorg(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		proxy   = energi_params.Nuclear_CheckpointRegistry
+		implV2  = common.HexToAddress("0x1234")
+		implV3  = common.HexToAddress("0x9abc")
+		other   = common.HexToAddress("0x5678")
+		ctx     = context.Background()
+	)
+
+	write := func(chain []*types.Block, receipts []types.Receipts) {
+		for i, block := range chain {
+			rawdb.WriteBlock(db, block)
+			rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			rawdb.WriteHeadBlockHash(db, block.Hash())
+			rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		}
+	}
+
+	genesis := core.GenesisBlockForTesting(db, other, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 20, func(i int, gen *core.BlockGen) {
+		if number := uint64(i + 1); number == 10 {
+			gen.AddUncheckedReceipt(makeLogReceipt(number, proxy, upgradedTopic, implV2.Hash()))
+		}
+	})
+	write(chain, receipts)
+
+	history := newProxyHistory()
+	if err := history.update(ctx, backend, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the tip with a fork upgrading the proxy once more
+	fork, fork_receipts := core.GenerateChain(params.TestChainConfig, chain[14], ethash.NewFaker(), db, 5, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(other)
+		if number := uint64(i + 16); number == 17 {
+			gen.AddUncheckedReceipt(makeLogReceipt(number, proxy, upgradedTopic, implV3.Hash()))
+		}
+	})
+	write(fork, fork_receipts)
+
+	// Blocks before the fork point must not be scanned again
+	rawdb.DeleteReceipts(db, chain[9].Hash(), 10)
+
+	if err := history.update(ctx, backend, 20); err != nil {
+		t.Fatal(err)
+	}
+	if history.next != 21 || history.last != fork[4].Hash() {
+		t.Errorf("unexpected scan position: %d %x", history.next, history.last)
+	}
+	if impls := history.expand([]common.Address{proxy}, 11, 20); len(impls) != 3 || impls[1] != implV2 || impls[2] != implV3 {
+		t.Errorf("unexpected implementations: %v", impls)
+	}
+
+	// An unknown old chain is scanned from scratch
+	history.last = common.HexToHash("0xdead")
+	history.next = 30
+	if err := history.update(ctx, backend, 20); err != nil {
+		t.Fatal(err)
+	}
+	if impls := history.expand([]common.Address{proxy}, 11, 20); len(impls) != 3 || impls[1] != energi_params.Nuclear_CheckpointRegistryV1 || impls[2] != implV3 {
+		t.Errorf("unexpected rescanned implementations: %v", impls)
+	}
+}
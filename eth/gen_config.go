@@ -12,6 +12,8 @@ import (
 	"nuclear/core/nuclear/core"
 	"nuclear/core/nuclear/eth/downloader"
 	"nuclear/core/nuclear/eth/gasprice"
+
+	energi "nuclear/core/nuclear/energi/consensus"
 )
 
 var _ = (*configMarshaling)(nil)
@@ -19,42 +21,49 @@ var _ = (*configMarshaling)(nil)
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		NoPruning               bool
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		TrieCleanCache          int
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		TrieRapidTime           time.Duration
-		Etherbase               common.Address `toml:",omitempty"`
-		MinerNotify             []string       `toml:",omitempty"`
-		MinerExtraData          hexutil.Bytes  `toml:",omitempty"`
-		MinerGasFloor           uint64
-		MinerGasCeil            uint64
-		MinerGasPrice           *big.Int
-		MinerRecommit           time.Duration
-		MinerNoverify           bool
-		MinerDPoS               DPoSMap `toml:",omitempty"`
-		MinerMigration          string  `toml:",omitempty"`
-		MinerNonceCap           uint64  `toml:"-"`
-		MinerAutocollateral     uint64  `toml:",omitempty"`
-		PublicService           bool    `toml:",omitempty"`
-		Ethash                  ethash.Config
-		TxPool                  core.TxPoolConfig
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
-		EWASMInterpreter        string
-		EVMInterpreter          string
-		ConstantinopleOverride  *big.Int
-		RPCGasCap               *big.Int `toml:",omitempty"`
+		Genesis                    *core.Genesis `toml:",omitempty"`
+		NetworkId                  uint64
+		SyncMode                   downloader.SyncMode
+		NoPruning                  bool
+		Whitelist                  map[uint64]common.Hash `toml:"-"`
+		LightServ                  int                    `toml:",omitempty"`
+		LightPeers                 int                    `toml:",omitempty"`
+		SkipBcVersionCheck         bool                   `toml:"-"`
+		DatabaseHandles            int                    `toml:"-"`
+		DatabaseCache              int
+		TrieCleanCache             int
+		TrieDirtyCache             int
+		TrieTimeout                time.Duration
+		TrieRapidTime              time.Duration
+		Etherbase                  common.Address `toml:",omitempty"`
+		MinerNotify                []string       `toml:",omitempty"`
+		MinerExtraData             hexutil.Bytes  `toml:",omitempty"`
+		MinerGasFloor              uint64
+		MinerGasCeil               uint64
+		MinerGasPrice              *big.Int
+		MinerRecommit              time.Duration
+		MinerNoverify              bool
+		MinerDPoS                  DPoSMap                       `toml:",omitempty"`
+		MinerMigration             string                        `toml:",omitempty"`
+		MinerNonceCap              uint64                        `toml:"-"`
+		MinerStakingPolicy         []energi.AccountStakingPolicy `toml:",omitempty"`
+		MinerAutocollateral        autocollateral
+		MinerAutocollateralTarget  *big.Int `toml:",omitempty"`
+		MinerAutocollateralReserve *big.Int `toml:",omitempty"`
+		MinerAutocollateralMinStep *big.Int `toml:",omitempty"`
+		PublicService              bool     `toml:",omitempty"`
+		Ethash                     ethash.Config
+		TxPool                     core.TxPoolConfig
+		GPO                        gasprice.Config
+		EnablePreimageRecording    bool
+		InternalTransfers          bool   `toml:",omitempty"`
+		Checkpoint                 string `toml:",omitempty"`
+		SporkOverride              string `toml:",omitempty"`
+		DocRoot                    string `toml:"-"`
+		EWASMInterpreter           string
+		EVMInterpreter             string
+		ConstantinopleOverride     *big.Int
+		RPCGasCap                  *big.Int `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -82,12 +91,19 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.MinerDPoS = c.MinerDPoS
 	enc.MinerMigration = c.MinerMigration
 	enc.MinerNonceCap = c.MinerNonceCap
-	enc.MinerAutocollateral = c.MinerAutocollateral
+	enc.MinerStakingPolicy = c.MinerStakingPolicy
+	enc.MinerAutocollateral = autocollateral(c.MinerAutocollateral)
+	enc.MinerAutocollateralTarget = c.MinerAutocollateralTarget
+	enc.MinerAutocollateralReserve = c.MinerAutocollateralReserve
+	enc.MinerAutocollateralMinStep = c.MinerAutocollateralMinStep
 	enc.PublicService = c.PublicService
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.InternalTransfers = c.InternalTransfers
+	enc.Checkpoint = c.Checkpoint
+	enc.SporkOverride = c.SporkOverride
 	enc.DocRoot = c.DocRoot
 	enc.EWASMInterpreter = c.EWASMInterpreter
 	enc.EVMInterpreter = c.EVMInterpreter
@@ -99,42 +115,49 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		NoPruning               *bool
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		TrieCleanCache          *int
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		TrieRapidTime           *time.Duration
-		Etherbase               *common.Address `toml:",omitempty"`
-		MinerNotify             []string        `toml:",omitempty"`
-		MinerExtraData          *hexutil.Bytes  `toml:",omitempty"`
-		MinerGasFloor           *uint64
-		MinerGasCeil            *uint64
-		MinerGasPrice           *big.Int
-		MinerRecommit           *time.Duration
-		MinerNoverify           *bool
-		MinerDPoS               *DPoSMap `toml:",omitempty"`
-		MinerMigration          *string  `toml:",omitempty"`
-		MinerNonceCap           *uint64  `toml:"-"`
-		MinerAutocollateral     *uint64  `toml:",omitempty"`
-		PublicService           *bool    `toml:",omitempty"`
-		Ethash                  *ethash.Config
-		TxPool                  *core.TxPoolConfig
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
-		EWASMInterpreter        *string
-		EVMInterpreter          *string
-		ConstantinopleOverride  *big.Int
-		RPCGasCap               *big.Int `toml:",omitempty"`
+		Genesis                    *core.Genesis `toml:",omitempty"`
+		NetworkId                  *uint64
+		SyncMode                   *downloader.SyncMode
+		NoPruning                  *bool
+		Whitelist                  map[uint64]common.Hash `toml:"-"`
+		LightServ                  *int                   `toml:",omitempty"`
+		LightPeers                 *int                   `toml:",omitempty"`
+		SkipBcVersionCheck         *bool                  `toml:"-"`
+		DatabaseHandles            *int                   `toml:"-"`
+		DatabaseCache              *int
+		TrieCleanCache             *int
+		TrieDirtyCache             *int
+		TrieTimeout                *time.Duration
+		TrieRapidTime              *time.Duration
+		Etherbase                  *common.Address `toml:",omitempty"`
+		MinerNotify                []string        `toml:",omitempty"`
+		MinerExtraData             *hexutil.Bytes  `toml:",omitempty"`
+		MinerGasFloor              *uint64
+		MinerGasCeil               *uint64
+		MinerGasPrice              *big.Int
+		MinerRecommit              *time.Duration
+		MinerNoverify              *bool
+		MinerDPoS                  *DPoSMap                      `toml:",omitempty"`
+		MinerMigration             *string                       `toml:",omitempty"`
+		MinerNonceCap              *uint64                       `toml:"-"`
+		MinerStakingPolicy         []energi.AccountStakingPolicy `toml:",omitempty"`
+		MinerAutocollateral        *autocollateral
+		MinerAutocollateralTarget  *big.Int `toml:",omitempty"`
+		MinerAutocollateralReserve *big.Int `toml:",omitempty"`
+		MinerAutocollateralMinStep *big.Int `toml:",omitempty"`
+		PublicService              *bool    `toml:",omitempty"`
+		Ethash                     *ethash.Config
+		TxPool                     *core.TxPoolConfig
+		GPO                        *gasprice.Config
+		EnablePreimageRecording    *bool
+		InternalTransfers          *bool   `toml:",omitempty"`
+		Checkpoint                 *string `toml:",omitempty"`
+		SporkOverride              *string `toml:",omitempty"`
+		DocRoot                    *string `toml:"-"`
+		EWASMInterpreter           *string
+		EVMInterpreter             *string
+		ConstantinopleOverride     *big.Int
+		RPCGasCap                  *big.Int `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -215,8 +238,20 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.MinerNonceCap != nil {
 		c.MinerNonceCap = *dec.MinerNonceCap
 	}
+	if dec.MinerStakingPolicy != nil {
+		c.MinerStakingPolicy = dec.MinerStakingPolicy
+	}
 	if dec.MinerAutocollateral != nil {
-		c.MinerAutocollateral = *dec.MinerAutocollateral
+		c.MinerAutocollateral = bool(*dec.MinerAutocollateral)
+	}
+	if dec.MinerAutocollateralTarget != nil {
+		c.MinerAutocollateralTarget = dec.MinerAutocollateralTarget
+	}
+	if dec.MinerAutocollateralReserve != nil {
+		c.MinerAutocollateralReserve = dec.MinerAutocollateralReserve
+	}
+	if dec.MinerAutocollateralMinStep != nil {
+		c.MinerAutocollateralMinStep = dec.MinerAutocollateralMinStep
 	}
 	if dec.PublicService != nil {
 		c.PublicService = *dec.PublicService
@@ -233,6 +268,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.InternalTransfers != nil {
+		c.InternalTransfers = *dec.InternalTransfers
+	}
+	if dec.Checkpoint != nil {
+		c.Checkpoint = *dec.Checkpoint
+	}
+	if dec.SporkOverride != nil {
+		c.SporkOverride = *dec.SporkOverride
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
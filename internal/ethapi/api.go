@@ -352,6 +352,22 @@ func (s *PrivateAccountAPI) UnlockAccount(addr common.Address, password string,
 	return err == nil, err
 }
 
+// RotateStaking reloads the staking policy file of the node. Accounts are
+// only ever unlocked for staking, so the call does not grant any signing
+// rights beyond those. It returns the accounts unlocked by the policy.
+func (s *PrivateAccountAPI) RotateStaking() ([]common.Address, error) {
+	res, err := fetchKeystore(s.am).RotateStaking()
+	if err != nil {
+		log.Warn("Failed staking policy rotation", "err", err)
+	}
+	return res, err
+}
+
+// StakingAccounts returns the accounts unlocked by the staking policy.
+func (s *PrivateAccountAPI) StakingAccounts() []common.Address {
+	return fetchKeystore(s.am).StakingAccounts()
+}
+
 // LockAccount will lock the account associated with the given address when it's unlocked.
 func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 	return fetchKeystore(s.am).Lock(addr) == nil
@@ -956,6 +972,9 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		return nil, err
 	}
 	fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(b.Hash()))
+	if rewards := rawdb.ReadBlockRewards(s.b.ChainDb(), b.Hash(), b.NumberU64()); rewards != nil {
+		fields["rewards"] = energi_api.NewBlockRewardItems(rewards)
+	}
 	return fields, err
 }
 
@@ -148,7 +148,8 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'listGen2Coins',
 			call: 'energi_listGen2Coins',
-			params: 0,
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: web3._extend.formatters.coinSearchFormatter,
 		}),
 		new web3._extend.Method({
@@ -185,12 +186,32 @@ web3._extend({
 			call: 'energi_claimGen2CoinsImport',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'prepareGen2Claim',
+			call: 'energi_prepareGen2Claim',
+			params: 2,
+			inputFormatter: [
+				null,
+				web3._extend.formatters.inputAddressFormatter,
+			],
+		}),
+		new web3._extend.Method({
+			name: 'submitGen2Claim',
+			call: 'energi_submitGen2Claim',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'checkGen2Claim',
+			call: 'energi_checkGen2Claim',
+			params: 1,
+		}),
 
 		// Blacklist
 		new web3._extend.Method({
 			name: 'blacklistInfo',
 			call: 'energi_blacklistInfo',
-			params: 0
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(list) {
 				var res = [];
 				var proposalf = web3._extend.formatters.outputProposalFormatter;
@@ -290,11 +311,41 @@ web3._extend({
 			outputFormatter: console.log,
 		}),
 
+		new web3._extend.Method({
+			name: 'voteAcceptMany',
+			call: 'energi_voteAcceptMany',
+			params: 3,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+				null,
+			],
+		}),
+		new web3._extend.Method({
+			name: 'voteRejectMany',
+			call: 'energi_voteRejectMany',
+			params: 3,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+				null,
+			],
+		}),
+		new web3._extend.Method({
+			name: 'listVotes',
+			call: 'energi_listVotes',
+			params: 1,
+			inputFormatter: [
+				null,
+			],
+		}),
+
 		// Governance upgrades
 		new web3._extend.Method({
 			name: 'upgradeInfo',
 			call: 'energi_upgradeInfo',
-			params: 0
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(status) {
 				var res = {};
 				var proposalf = web3._extend.formatters.outputProposalFormatter;
@@ -354,11 +405,79 @@ web3._extend({
 			outputFormatter: console.log,
 		}),
 
+		// Sporks
+		new web3._extend.Method({
+			name: 'sporkInfo',
+			call: 'energi_sporkInfo',
+			params: 1,
+			inputFormatter: [null],
+			outputFormatter: function(status) {
+				var proposalf = web3._extend.formatters.outputProposalFormatter;
+				var proposals = [];
+				for (var i = 0; i < status.Proposals.length; ++i) {
+					var item = status.Proposals[i];
+					var res_item = proposalf(item);
+					res_item.impl = item.Impl;
+					res_item.proxy = item.Proxy;
+					proposals.push(res_item);
+				}
+				return {
+					impl: status.Impl,
+					onChain: {
+						callGas: status.OnChain.CallGas,
+						xferGas: status.OnChain.XferGas,
+					},
+					active: {
+						callGas: status.Active.CallGas,
+						xferGas: status.Active.XferGas,
+					},
+					override: status.Override,
+					proposals: proposals,
+				};
+			},
+		}),
+		new web3._extend.Method({
+			name: 'sporkPropose',
+			call: 'energi_sporkPropose',
+			params: 5,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+				web3._extend.utils.fromDecimal,
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+			],
+			outputFormatter: console.log,
+		}),
+		new web3._extend.Method({
+			name: 'sporkPerform',
+			call: 'energi_sporkPerform',
+			params: 3,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+			],
+			outputFormatter: console.log,
+		}),
+		new web3._extend.Method({
+			name: 'sporkCollect',
+			call: 'energi_sporkCollect',
+			params: 3,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+			],
+			outputFormatter: console.log,
+		}),
+
 		// Governance budget
 		new web3._extend.Method({
 			name: 'budgetInfo',
 			call: 'energi_budgetInfo',
-			params: 0
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(status) {
 				var proposals = [];
 				var toDecimal = web3._extend.utils.toDecimal;
@@ -393,13 +512,51 @@ web3._extend({
 			],
 			outputFormatter: console.log,
 		}),
+		new web3._extend.Method({
+			name: 'treasuryHistory',
+			call: 'energi_treasuryHistory',
+			params: 2,
+			inputFormatter: [
+				null,
+				null,
+			],
+		}),
+		new web3._extend.Method({
+			name: 'getBlockRewards',
+			call: 'energi_getBlockRewards',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter],
+		}),
+		new web3._extend.Method({
+			name: 'previewFinalize',
+			call: 'energi_previewFinalize',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				function(number) {
+					return number === undefined ? null : web3._extend.utils.fromDecimal(number);
+				},
+			],
+		}),
+		new web3._extend.Method({
+			name: 'getInternalTransfers',
+			call: 'energi_getInternalTransfers',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null],
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionInternalTransfers',
+			call: 'energi_getTransactionInternalTransfers',
+			params: 1,
+		}),
 
 
 		// Compensation Fund
 		new web3._extend.Method({
 			name: 'compensationInfo',
 			call: 'energi_compensationInfo',
-			params: 0
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(status) {
 				var proposals = [];
 				var toDecimal = web3._extend.utils.toDecimal;
@@ -449,7 +606,8 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'checkpointInfo',
 			call: 'energi_checkpointInfo',
-			params: 0
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(status) {
 				var res = {
 					registry: [],
@@ -584,12 +742,33 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'checkpointLocal',
 			call: 'admin_checkpointLocal',
-			params: 2
+			params: 4,
 			inputFormatter: [
 				null,
 				null,
+				null,
+				null,
 			],
-			outputFormatter: console.log,
+		}),
+		new web3._extend.Method({
+			name: 'checkpointLocalRemove',
+			call: 'admin_checkpointLocalRemove',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'checkpointLocalList',
+			call: 'admin_checkpointLocalList',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'checkpointExport',
+			call: 'admin_checkpointExport',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'checkpointImport',
+			call: 'admin_checkpointImport',
+			params: 1
 		}),
 		new web3._extend.Method({
 			name: 'validateMigration',
@@ -597,6 +776,54 @@ web3._extend({
 			params: 1,
 			outputFormatter: console.log,
 		}),
+		new web3._extend.Method({
+			name: 'votingPolicyLoad',
+			call: 'admin_votingPolicyLoad',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'votingPolicyClear',
+			call: 'admin_votingPolicyClear',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'upgradeVerify',
+			call: 'admin_upgradeVerify',
+			params: 4,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+				null,
+			],
+		}),
+		new web3._extend.Method({
+			name: 'watchMasternode',
+			call: 'admin_watchMasternode',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter],
+		}),
+		new web3._extend.Method({
+			name: 'unwatchMasternode',
+			call: 'admin_unwatchMasternode',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter],
+		}),
+		new web3._extend.Method({
+			name: 'addWebhook',
+			call: 'admin_addWebhook',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'removeWebhook',
+			call: 'admin_removeWebhook',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'webhooks',
+			call: 'admin_webhooks',
+			params: 0,
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -971,7 +1198,8 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'listMasternodes',
 			call: 'masternode_listMasternodes',
-			params: 0,
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(list) {
 				var res = [];
 				for (var i = 0; i < list.length; ++i) {
@@ -992,7 +1220,8 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'stats',
 			call: 'masternode_stats',
-			params: 0,
+			params: 1,
+			inputFormatter: [null],
 			outputFormatter: function(status) {
 				return {
 					active: status.Active,
@@ -1015,6 +1244,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null],
 		}),
+		new web3._extend.Method({
+			name: 'masternodeEvents',
+			call: 'masternode_masternodeEvents',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter],
+		}),
 	],
 	properties: []
 });
@@ -1082,6 +1317,17 @@ web3._extend({
 			inputFormatter: [null],
 			outputFormatter: console.log,
 		}),
+		new web3._extend.Method({
+			name: 'setStakingPolicy',
+			call: 'miner_setStakingPolicy',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeStakingPolicy',
+			call: 'miner_removeStakingPolicy',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'setAutocollateralize',
 			call: 'miner_setAutocollateralize',
@@ -1089,6 +1335,12 @@ web3._extend({
 			inputFormatter: [null],
 			outputFormatter: console.log,
 		}),
+		new web3._extend.Method({
+			name: 'setAutocollateralizePolicy',
+			call: 'miner_setAutocollateralizePolicy',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal],
+		}),
 		new web3._extend.Method({
 			name: 'stakingStatus',
 			call: 'miner_stakingStatus',
@@ -1107,8 +1359,13 @@ web3._extend({
 				for (var i = 0; i < raw_accounts.length; ++i) {
 					var raw_acct = raw_accounts[i];
 					res.accounts.push({
-						account: raw_accounts[i].Account,
-						weight: raw_accounts[i].Weight,
+						account: raw_acct.Account,
+						weight: raw_acct.Weight,
+						nonceCap: raw_acct.NonceCap,
+						minWeight: raw_acct.MinWeight,
+						fromHour: raw_acct.FromHour,
+						toHour: raw_acct.ToHour,
+						staking: raw_acct.Staking,
 					});
 				}
 				return res;
@@ -1168,12 +1425,21 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'rotateStaking',
+			call: 'personal_rotateStaking',
+			params: 0
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'listWallets',
 			getter: 'personal_listWallets'
 		}),
+		new web3._extend.Property({
+			name: 'stakingAccounts',
+			getter: 'personal_stakingAccounts'
+		}),
 	]
 })
 `
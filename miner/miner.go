@@ -170,6 +170,12 @@ func (self *Miner) PendingBlock() *types.Block {
 	return self.worker.pendingBlock()
 }
 
+// PendingBlockAndReceipts returns the currently pending block and corresponding receipts.
+// With the Nuclear engine, it includes the consensus transactions of the etherbase.
+func (self *Miner) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
+	return self.worker.pendingBlockAndReceipts()
+}
+
 func (self *Miner) SetEtherbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setEtherbase(addr)
@@ -179,14 +185,22 @@ func (self *Miner) SetMigration(migration string) {
 	self.worker.setMigration(migration)
 }
 
-func (self *Miner) SetMinerAutocollateral(autocollateral uint64) {
+func (self *Miner) SetMinerAutocollateral(autocollateral bool) {
 	self.worker.setAutocollateral(autocollateral)
 }
 
-func (self *Miner) GetMinerAutocollateral() uint64 {
+func (self *Miner) GetMinerAutocollateral() bool {
 	return self.worker.getAutocollateral()
 }
 
+func (self *Miner) SetMinerAutocollateralPolicy(policy AutoCollateralPolicy) {
+	self.worker.setAutocollateralPolicy(policy)
+}
+
+func (self *Miner) GetMinerAutocollateralPolicy() AutoCollateralPolicy {
+	return self.worker.getAutocollateralPolicy()
+}
+
 func (self *Miner) SetEthAPIBackend(api bind.ContractBackend) {
 	self.worker.setEthAPIBackend(api)
 }
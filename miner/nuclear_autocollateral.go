@@ -35,17 +35,23 @@ import (
 
 const maxAutoCollateralBlockAge = time.Duration(time.Minute)
 
-const (
-	acDisabled   uint64 = 0
-	acPostReward uint64 = 1
-	acRapid      uint64 = 2
-)
+// AutoCollateralPolicy controls how much of the owner balance gets deposited
+// as masternode collateral. Nil values mean defaults.
+type AutoCollateralPolicy struct {
+	// Target is the desired collateral, the maximum allowed by default.
+	Target *big.Int
+	// Reserve is the balance to keep liquid, none by default.
+	Reserve *big.Int
+	// MinStep is the minimal deposit, the minimal collateral by default.
+	MinStep *big.Int
+}
 
 func (w *worker) tryAutocollateral() {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
-	if _, ok := w.engine.(*energi.Nuclear); !ok {
+	engine, ok := w.engine.(*energi.Nuclear)
+	if !ok {
 		// Nuclear consensus engine not running.
 		log.Debug("energi consensus engine not running")
 		return
@@ -77,6 +83,12 @@ func (w *worker) tryAutocollateral() {
 		return
 	}
 
+	stateDb, err := w.eth.BlockChain().StateAt(block.Root())
+	if err != nil {
+		log.Error("Failed to get state", "err", err)
+		return
+	}
+
 	log.Debug("Auto-Collateralize loop")
 
 	for _, wallet := range w.eth.AccountManager().Wallets() {
@@ -84,15 +96,19 @@ func (w *worker) tryAutocollateral() {
 			if wallet.IsUnlockedForStaking(account) {
 				log.Debug("Auto-Collateralize checking", "account", account)
 
-				amount, err := w.hasJustReceivedRewards(account.Address, block, mnReward)
+				justPaid, err := w.hasJustReceivedRewards(engine, account.Address, block)
 				if err != nil {
-					log.Debug(err.Error())
-					if amount == nil || w.autocollateral != acRapid {
-						continue
-					}
+					log.Debug("Auto-Collateralize payout check failed", "err", err)
+					continue
 				}
 
-				if _, coins, err := w.doAutocollateral(account.Address, amount); err != nil {
+				if !justPaid {
+					continue
+				}
+
+				balance := stateDb.GetBalance(account.Address)
+
+				if _, coins, err := w.doAutocollateral(account.Address, balance); err != nil {
 					// Most likely, an invalid amount to deposit was found in the account.
 					log.Debug("Auto-Collateralize failed", "err", err.Error())
 				} else {
@@ -104,75 +120,133 @@ func (w *worker) tryAutocollateral() {
 	}
 }
 
-func (w *worker) getBalanceAtBlock(block *types.Block, address common.Address) (*big.Int, error) {
-	stateDb, err := w.eth.BlockChain().StateAt(block.Root())
-	if err != nil {
-		return nil, err
+// mnPayout returns the masternode payout of the owner in the block
+// based on the itemised block reward.
+func (w *worker) mnPayout(
+	engine *energi.Nuclear,
+	block *types.Block,
+	mnOwner common.Address,
+) (*big.Int, error) {
+	rewards := w.eth.BlockChain().GetBlockRewards(block.Hash(), block.NumberU64())
+	if rewards == nil {
+		var ok bool
+		if rewards, ok = engine.BlockRewards(block.Root()); !ok {
+			return nil, errors.New("Unknown block rewards")
+		}
 	}
-	return stateDb.GetBalance(address), nil
+
+	return masternodePayout(rewards, mnOwner), nil
 }
 
-// isMNPayouts checks if the provided block in relation to the previous block has
-// a masternode block payout.
-func (w *worker) isMNPayouts(currentblock *types.Block, mnOwner common.Address, blockReward *big.Int) (bool, *big.Int, error) {
-	blockNo := currentblock.Number().Uint64()
-	if blockNo <= 0 {
-		return false, nil, errors.New("Invalid block cannot posses MN payouts")
+// masternodePayout sums masternode rewards of the owner.
+func masternodePayout(rewards types.BlockRewards, mnOwner common.Address) *big.Int {
+	payout := new(big.Int)
+
+	for _, r := range rewards {
+		if r.Recipient == mnOwner && r.Category == types.RewardMasternode {
+			payout.Add(payout, r.Amount)
+		}
 	}
 
-	balanceNow, err := w.getBalanceAtBlock(currentblock, mnOwner)
-	if err != nil {
-		return false, nil, err
+	return payout
+}
+
+// hasJustReceivedRewards checks if masternode payouts of the owner have just
+// ended: there is a payout in the previous block, but none in the current one.
+func (w *worker) hasJustReceivedRewards(
+	engine *energi.Nuclear,
+	account common.Address,
+	block *types.Block,
+) (bool, error) {
+	blockNo := block.Number().Uint64()
+	if blockNo <= 1 {
+		return false, errors.New("Invalid block cannot posses MN payouts")
 	}
 
 	// MN-17 - 5
-	// (a).ii Ensure the change was not due to stake reward
-	if currentblock.Coinbase() == mnOwner {
-		return false, balanceNow, errors.New("Stake reward")
+	// (a).i Confirm no MN payouts in the current block.
+	currPayout, err := w.mnPayout(engine, block, account)
+	if err != nil {
+		return false, err
+	}
+
+	if currPayout.Sign() > 0 {
+		return false, nil
 	}
 
+	// MN-17 - 5
+	// (a).ii Confirm atleast 1 masternode payout in the previous block.
 	prevBlock := w.eth.BlockChain().GetBlockByNumber(blockNo - 1)
-	balancePrev, err := w.getBalanceAtBlock(prevBlock, mnOwner)
-	if err != nil {
-		return false, balanceNow, err
+	if prevBlock == nil {
+		return false, errors.New("Missing previous block")
 	}
 
-	diff := new(big.Int).Sub(balanceNow, balancePrev)
+	prevPayout, err := w.mnPayout(engine, prevBlock, account)
+	if err != nil {
+		return false, err
+	}
 
-	// Should be: 0 < diff <= mnPayout
-	status := diff.Cmp(blockReward) <= 0 && diff.Cmp(common.Big0) > 0
-	return status, balanceNow, nil
+	return prevPayout.Sign() > 0, nil
 }
 
-func (w *worker) hasJustReceivedRewards(account common.Address, block *types.Block, mnReward *big.Int) (*big.Int, error) {
+// autocollateralAmount returns the amount to deposit as the collateral
+// according to the policy.
+func autocollateralAmount(
+	balance *big.Int,
+	collateral *big.Int,
+	minLimit *big.Int,
+	maxLimit *big.Int,
+	policy AutoCollateralPolicy,
+) (*big.Int, error) {
 	// MN-17 - 5
-	// (a).i Confirm no MN payouts in the current block.
-	isCurrentMNPayout, balanceNow, err := w.isMNPayouts(block, account, mnReward)
-	if err != nil {
-		return nil, err
+	// (c) Ensure that the current collateral is more than zero.
+	if collateral.Cmp(common.Big0) <= 0 {
+		return nil, errors.New("No collateral exists")
 	}
 
-	if isCurrentMNPayout {
-		return balanceNow, errors.New("Current block masternode payout is active")
+	target := maxLimit
+	if policy.Target != nil && policy.Target.Sign() > 0 && policy.Target.Cmp(maxLimit) < 0 {
+		target = policy.Target
 	}
 
 	// MN-17 - 5
-	// (a).ii Confirm atleast 1 masternode payout in the previous block.
-	prevBlock := w.eth.BlockChain().GetBlockByNumber(block.Number().Uint64() - 1)
-	isPrevPayout, _, err := w.isMNPayouts(prevBlock, account, mnReward)
-	if err != nil {
-		return balanceNow, err
+	// (c) Ensure that the current collateral is below the target.
+	if collateral.Cmp(target) >= 0 {
+		return nil, errors.New("Target collateral already achieved")
+	}
+
+	available := new(big.Int).Set(balance)
+	if policy.Reserve != nil {
+		available.Sub(available, policy.Reserve)
+	}
+
+	// Only whole multiples of the minimal collateral can be deposited
+	amount := new(big.Int).Sub(available, new(big.Int).Mod(available, minLimit))
+	missing := new(big.Int).Sub(target, collateral)
+	missing.Sub(missing, new(big.Int).Mod(missing, minLimit))
+
+	if amount.Cmp(missing) > 0 {
+		// Gets the maximum amount to deposit since all the available amount
+		// could breach the target collateral if deposited in full.
+		amount = missing
+	}
+
+	// MN-17 - 5
+	// (b) Ensures that the deposit is at least one minimal step.
+	step := minLimit
+	if policy.MinStep != nil && policy.MinStep.Cmp(minLimit) > 0 {
+		step = policy.MinStep
 	}
 
-	if !isPrevPayout {
-		return balanceNow, errors.New("Expected at least one payout from a previous block")
+	if amount.Cmp(step) < 0 {
+		return nil, errors.New("Amount found is less than the minimum required")
 	}
 
-	return balanceNow, nil
+	return amount, nil
 }
 
 // canAutocollateralize returns the maximum amount that can be deposited as the
-// collateral if the maximum collateral amount is not yet reached.
+// collateral if the target collateral amount is not yet reached.
 func (w *worker) canAutocollateralize(
 	account common.Address,
 	amount *big.Int,
@@ -183,36 +257,12 @@ func (w *worker) canAutocollateralize(
 		return nil, err
 	}
 
-	// MN-17 - 5
-	// (b) Ensures that available balance is at least one minimal collateral.
-	if amount.Cmp(minLimit) < 0 {
-		return nil, errors.New("Amount found is less than the minimum required")
-	}
-
 	tokenBalance, err := api.BalanceOf(account)
 	if err != nil {
 		return nil, err
 	}
 
-	// MN-17 - 5
-	// (c) Ensure that the current collateral is below the maximum allowed and more than zero.
-	if tokenBalance.Cmp(common.Big0) <= 0 {
-		return nil, errors.New("No collateral exists")
-	} else if tokenBalance.Cmp(maxLimit) >= 0 {
-		return nil, errors.New("Maximum collateral supported already achieved")
-	}
-
-	modAmount := new(big.Int).Mod(amount, minLimit)
-	amountToDeposit := new(big.Int).Sub(amount, modAmount)
-
-	totalAmount := new(big.Int).Add(tokenBalance, amountToDeposit)
-	if totalAmount.Cmp(maxLimit) == 1 {
-		// Gets the maximum amount to deposit since all the available amount
-		// could breach the max collateral limit if deposited in full.
-		amountToDeposit = new(big.Int).Sub(maxLimit, tokenBalance)
-	}
-
-	return amountToDeposit, nil
+	return autocollateralAmount(amount, tokenBalance, minLimit, maxLimit, w.acPolicy)
 }
 
 func (w *worker) doAutocollateral(account common.Address, amount *big.Int) (common.Hash, *big.Int, error) {
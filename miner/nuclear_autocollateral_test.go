@@ -0,0 +1,85 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/types"
+
+	"github.com/stretchr/testify/assert"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func TestMasternodePayout(t *testing.T) {
+	t.Parallel()
+
+	owner := common.HexToAddress("0x1234")
+
+	rewards := types.BlockRewards{
+		{Recipient: owner, Category: types.RewardStaker, Amount: big.NewInt(5)},
+		{Recipient: owner, Category: types.RewardMasternode, Amount: big.NewInt(10)},
+		{Recipient: energi_params.Nuclear_Treasury, Category: types.RewardTreasury, Amount: big.NewInt(20)},
+	}
+
+	assert.Equal(t, big.NewInt(10), masternodePayout(rewards, owner))
+	assert.Equal(t, big.NewInt(0), masternodePayout(rewards, common.HexToAddress("0x1")))
+	assert.Equal(t, big.NewInt(0), masternodePayout(nil, owner))
+}
+
+func TestAutocollateralAmount(t *testing.T) {
+	t.Parallel()
+
+	min := big.NewInt(1000)
+	max := big.NewInt(100000)
+
+	check := func(balance, collateral int64, policy AutoCollateralPolicy, expected int64, expErr string) {
+		amount, err := autocollateralAmount(
+			big.NewInt(balance), big.NewInt(collateral), min, max, policy)
+		if expErr != "" {
+			assert.Equal(t, expErr, err.Error())
+			return
+		}
+
+		assert.Empty(t, err)
+		assert.Equal(t, big.NewInt(expected), amount)
+	}
+
+	// Defaults
+	check(5500, 1000, AutoCollateralPolicy{}, 5000, "")
+	check(500, 1000, AutoCollateralPolicy{}, 0, "Amount found is less than the minimum required")
+	check(5500, 0, AutoCollateralPolicy{}, 0, "No collateral exists")
+	check(5500, 100000, AutoCollateralPolicy{}, 0, "Target collateral already achieved")
+	check(5500, 98000, AutoCollateralPolicy{}, 2000, "")
+
+	// Target
+	check(5500, 1000, AutoCollateralPolicy{Target: big.NewInt(3500)}, 2000, "")
+	check(5500, 3000, AutoCollateralPolicy{Target: big.NewInt(3000)}, 0, "Target collateral already achieved")
+	check(5500, 1000, AutoCollateralPolicy{Target: big.NewInt(200000)}, 5000, "")
+
+	// Reserve
+	check(5500, 1000, AutoCollateralPolicy{Reserve: big.NewInt(2000)}, 3000, "")
+	check(5500, 1000, AutoCollateralPolicy{Reserve: big.NewInt(6000)}, 0, "Amount found is less than the minimum required")
+
+	// Minimal step
+	check(5500, 1000, AutoCollateralPolicy{MinStep: big.NewInt(5000)}, 5000, "")
+	check(4500, 1000, AutoCollateralPolicy{MinStep: big.NewInt(5000)}, 0, "Amount found is less than the minimum required")
+	check(1500, 1000, AutoCollateralPolicy{MinStep: big.NewInt(10)}, 1000, "")
+}
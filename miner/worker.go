@@ -36,6 +36,7 @@ import (
 	mapset "github.com/deckarep/golang-set"
 
 	energi_consensus "nuclear/core/nuclear/energi/consensus"
+	energi_params "nuclear/core/nuclear/energi/params"
 )
 
 const (
@@ -161,15 +162,17 @@ type worker struct {
 
 	// Nuclear params
 	migration      string
-	autocollateral uint64
+	autocollateral bool
+	acPolicy       AutoCollateralPolicy
 	apiBackend     bind.ContractBackend
 
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
 
-	snapshotMu    sync.RWMutex // The lock used to protect the block snapshot and state snapshot
-	snapshotBlock *types.Block
-	snapshotState *state.StateDB
+	snapshotMu       sync.RWMutex // The lock used to protect the block snapshot and state snapshot
+	snapshotBlock    *types.Block
+	snapshotReceipts types.Receipts
+	snapshotState    *state.StateDB
 
 	// atomic status counters
 	running int32 // The indicator whether the consensus engine is running or not.
@@ -262,18 +265,30 @@ func (w *worker) setMigration(migration string) {
 	w.migration = migration
 }
 
-func (w *worker) setAutocollateral(autocollateral uint64) {
+func (w *worker) setAutocollateral(autocollateral bool) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.autocollateral = autocollateral
 }
 
-func (w *worker) getAutocollateral() uint64 {
+func (w *worker) getAutocollateral() bool {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	return w.autocollateral
 }
 
+func (w *worker) setAutocollateralPolicy(policy AutoCollateralPolicy) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.acPolicy = policy
+}
+
+func (w *worker) getAutocollateralPolicy() AutoCollateralPolicy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.acPolicy
+}
+
 // setExtra sets the content used to initialize the block extra field.
 func (w *worker) setExtra(extra []byte) {
 	w.mu.Lock()
@@ -311,6 +326,14 @@ func (w *worker) pendingBlock() *types.Block {
 	return w.snapshotBlock
 }
 
+// pendingBlockAndReceipts returns pending block and corresponding receipts.
+func (w *worker) pendingBlockAndReceipts() (*types.Block, types.Receipts) {
+	// return a snapshot to avoid contention on currentMu mutex
+	w.snapshotMu.RLock()
+	defer w.snapshotMu.RUnlock()
+	return w.snapshotBlock, w.snapshotReceipts
+}
+
 // start sets the running status as 1 and triggers new work submitting.
 func (w *worker) start() {
 	atomic.StoreInt32(&w.running, 1)
@@ -397,7 +420,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 			clearPending(head.Block.NumberU64())
 			timestamp = time.Now().Unix()
 			commit(false, commitInterruptNewHead)
-			if w.autocollateral != acDisabled {
+			if w.autocollateral {
 				go w.tryAutocollateral()
 			}
 
@@ -744,8 +767,29 @@ func (w *worker) updateSnapshot() {
 		uncles,
 		w.current.receipts,
 	)
+	w.snapshotReceipts = copyReceipts(w.current.receipts)
 
 	w.snapshotState = w.current.state.Copy()
+
+	// PoS chooses the coinbase only at sealing, so the consensus transactions
+	// of the pending block are the ones the etherbase would get.
+	engine, ok := w.engine.(*energi_consensus.Nuclear)
+	if !ok || w.coinbase == (common.Address{}) || w.coinbase == energi_params.Nuclear_Ephemeral {
+		return
+	}
+
+	header := types.CopyHeader(w.current.header)
+	header.Coinbase = w.coinbase
+	s := w.current.state.Copy()
+	block, receipts, err := engine.FinalizePending(w.chain, header, s, w.current.txs, copyReceipts(w.current.receipts))
+	if err != nil {
+		log.Debug("Failed to finalize pending block", "err", err)
+		return
+	}
+
+	w.snapshotBlock = block
+	w.snapshotReceipts = receipts
+	w.snapshotState = s
 }
 
 func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Address) ([]*types.Log, error) {
@@ -1055,6 +1099,9 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 		*receipts[i] = *l
 	}
 	s := w.current.state.Copy()
+	if rec := w.chain.TransferRecorder(); rec != nil {
+		rec.Fork(w.current.state, s)
+	}
 	block, receipts, err := w.engine.Finalize(w.chain, w.current.header, s, w.current.txs, uncles, w.current.receipts)
 	if err != nil {
 		return err
@@ -1085,3 +1132,13 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 	}
 	return nil
 }
+
+// copyReceipts makes a deep copy of the given receipts.
+func copyReceipts(receipts []*types.Receipt) []*types.Receipt {
+	result := make([]*types.Receipt, len(receipts))
+	for i, l := range receipts {
+		cpy := *l
+		result[i] = &cpy
+	}
+	return result
+}
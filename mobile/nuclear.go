@@ -0,0 +1,578 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the wrappers of the Nuclear masternode, governance and staking
+// features.
+//
+// The contract state is read with plain eth_call requests, so it is available
+// through both an embedded light node and a remote connection.
+
+package geth
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/ethclient"
+	"nuclear/core/nuclear/nuclearclient"
+	"nuclear/core/nuclear/rpc"
+
+	"github.com/pborman/uuid"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_api "nuclear/core/nuclear/energi/api"
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi "nuclear/core/nuclear/energi/consensus"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+// proposalCallGas is the gas limit of the proposal voting transactions.
+const proposalCallGas int64 = 3000000
+
+// NuclearClient provides access to the Nuclear specific features.
+type NuclearClient struct {
+	client  *ethclient.Client
+	nuclear *nuclearclient.Client
+}
+
+// NewNuclearClient connects a client to the given URL.
+func NewNuclearClient(rawurl string) (client *NuclearClient, _ error) {
+	rawClient, err := rpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return newNuclearClient(rawClient), nil
+}
+
+func newNuclearClient(c *rpc.Client) *NuclearClient {
+	return &NuclearClient{
+		client:  ethclient.NewClient(c),
+		nuclear: nuclearclient.NewClient(c),
+	}
+}
+
+// GetNuclearClient retrieves a client to access the Nuclear features.
+func (n *Node) GetNuclearClient() (client *NuclearClient, _ error) {
+	rpc, err := n.node.Attach()
+	if err != nil {
+		return nil, err
+	}
+	return newNuclearClient(rpc), nil
+}
+
+func (nc *NuclearClient) callOpts(ctx *Context) *bind.CallOpts {
+	return &bind.CallOpts{
+		Context:  ctx.context,
+		GasLimit: energi_params.UnlimitedGas,
+	}
+}
+
+// Masternodes
+
+// MasternodeInfo represents a registered masternode.
+type MasternodeInfo struct {
+	info energi_api.MNInfo
+}
+
+func (mi *MasternodeInfo) GetMasternode() *Address  { return &Address{mi.info.Masternode} }
+func (mi *MasternodeInfo) GetOwner() *Address       { return &Address{mi.info.Owner} }
+func (mi *MasternodeInfo) GetCollateral() *BigInt   { return &BigInt{mi.info.Collateral.ToInt()} }
+func (mi *MasternodeInfo) GetAnnouncedBlock() int64 { return int64(mi.info.AnnouncedBlock) }
+func (mi *MasternodeInfo) IsActive() bool           { return mi.info.IsActive }
+func (mi *MasternodeInfo) GetSWVersion() string     { return mi.info.SWVersion }
+func (mi *MasternodeInfo) GetSWFeatures() *BigInt   { return &BigInt{mi.info.SWFeatures.ToInt()} }
+
+// MasternodeInfos represents a slice of masternodes.
+type MasternodeInfos struct{ infos []energi_api.MNInfo }
+
+// Size returns the number of masternodes in the slice.
+func (mi *MasternodeInfos) Size() int {
+	return len(mi.infos)
+}
+
+// Get returns the masternode at the given index from the slice.
+func (mi *MasternodeInfos) Get(index int) (info *MasternodeInfo, _ error) {
+	if index < 0 || index >= len(mi.infos) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &MasternodeInfo{mi.infos[index]}, nil
+}
+
+// MasternodeStats represents the masternode registry statistics.
+type MasternodeStats struct {
+	stats energi_api.MasternodeStats
+}
+
+func (ms *MasternodeStats) GetActive() int64 { return int64(ms.stats.Active) }
+func (ms *MasternodeStats) GetTotal() int64  { return int64(ms.stats.Total) }
+func (ms *MasternodeStats) GetActiveCollateral() *BigInt {
+	return &BigInt{ms.stats.ActiveCollateral.ToInt()}
+}
+func (ms *MasternodeStats) GetTotalCollateral() *BigInt {
+	return &BigInt{ms.stats.TotalCollateral.ToInt()}
+}
+func (ms *MasternodeStats) GetMaxOfAllTimes() *BigInt { return &BigInt{ms.stats.MaxOfAllTimes.ToInt()} }
+
+func (nc *NuclearClient) registry() (*energi_abi.IMasternodeRegistryV2Caller, error) {
+	return energi_abi.NewIMasternodeRegistryV2Caller(
+		energi_params.Nuclear_MasternodeRegistry, nc.client)
+}
+
+func (nc *NuclearClient) masternodeInfo(
+	ctx *Context,
+	registry *energi_abi.IMasternodeRegistryV2Caller,
+	mn common.Address,
+) (info energi_api.MNInfo, err error) {
+	call_opts := nc.callOpts(ctx)
+
+	mninfo, err := registry.Info(call_opts, mn)
+	if err != nil {
+		return
+	}
+
+	isActive, err := registry.IsActive(call_opts, mn)
+	if err != nil {
+		return
+	}
+
+	info = energi_api.MNInfo{
+		Masternode:     mn,
+		Owner:          mninfo.Owner,
+		Collateral:     (*hexutil.Big)(mninfo.Collateral),
+		AnnouncedBlock: mninfo.AnnouncedBlock.Uint64(),
+		IsActive:       isActive,
+		SWFeatures:     (*hexutil.Big)(mninfo.SwFeatures),
+		SWVersion:      energi_common.SWVersionIntToString(mninfo.SwFeatures),
+	}
+	return
+}
+
+// GetMasternodes returns all the registered masternodes.
+func (nc *NuclearClient) GetMasternodes(ctx *Context) (infos *MasternodeInfos, _ error) {
+	registry, err := nc.registry()
+	if err != nil {
+		return nil, err
+	}
+
+	masternodes, err := registry.Enumerate(nc.callOpts(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]energi_api.MNInfo, 0, len(masternodes))
+	for _, mn := range masternodes {
+		info, err := nc.masternodeInfo(ctx, registry, mn)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info)
+	}
+
+	return &MasternodeInfos{res}, nil
+}
+
+// GetMasternodeByOwner returns the masternode of the owner. Nil is returned
+// if the owner has no masternode announced.
+func (nc *NuclearClient) GetMasternodeByOwner(ctx *Context, owner *Address) (info *MasternodeInfo, _ error) {
+	registry, err := nc.registry()
+	if err != nil {
+		return nil, err
+	}
+
+	owner_registry, err := energi_abi.NewIMasternodeRegistryV2Caller(
+		energi_params.Nuclear_MasternodeRegistry, energi_common.RevertCaller{ContractCaller: nc.client})
+	if err != nil {
+		return nil, err
+	}
+
+	ownerinfo, err := owner_registry.OwnerInfo(nc.callOpts(ctx), owner.address)
+	if err == energi_common.ErrReverted {
+		// NOTE: the registry reverts for unknown owners
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	res, err := nc.masternodeInfo(ctx, registry, ownerinfo.Masternode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MasternodeInfo{res}, nil
+}
+
+// GetMasternodeStats returns the masternode registry statistics.
+func (nc *NuclearClient) GetMasternodeStats(ctx *Context) (stats *MasternodeStats, _ error) {
+	registry, err := nc.registry()
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := registry.Count(nc.callOpts(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &MasternodeStats{energi_api.MasternodeStats{
+		Active:           count.Active.Uint64(),
+		Total:            count.Total.Uint64(),
+		ActiveCollateral: (*hexutil.Big)(count.ActiveCollateral),
+		TotalCollateral:  (*hexutil.Big)(count.TotalCollateral),
+		MaxOfAllTimes:    (*hexutil.Big)(count.MaxOfAllTimes),
+	}}, nil
+}
+
+// Collateral
+
+// GetCollateralBalance returns the masternode collateral of the owner.
+func (nc *NuclearClient) GetCollateralBalance(ctx *Context, owner *Address) (balance *BigInt, _ error) {
+	token, err := energi_abi.NewIMasternodeTokenCaller(
+		energi_params.Nuclear_MasternodeToken, nc.client)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := token.BalanceOf(nc.callOpts(ctx), owner.address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BigInt{res}, nil
+}
+
+// GetCollateralLimits returns the minimum collateral step and the maximum
+// collateral of a masternode.
+func (nc *NuclearClient) GetCollateralLimits(ctx *Context) (limits *BigInts, _ error) {
+	registry, err := nc.registry()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := registry.CollateralLimits(nc.callOpts(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &BigInts{[]*big.Int{res.Min, res.Max}}, nil
+}
+
+func newContractTx(
+	contract string,
+	to common.Address,
+	nonce int64,
+	value *big.Int,
+	gasLimit int64,
+	gasPrice *BigInt,
+	method string,
+	args ...interface{},
+) (*Transaction, error) {
+	parsed, err := abi.JSON(strings.NewReader(contract))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{types.NewTransaction(
+		uint64(nonce), to, value, uint64(gasLimit), gasPrice.bigint, data)}, nil
+}
+
+// NewCollateralDepositTx creates an unsigned transaction to deposit the
+// amount to the masternode collateral of the sender.
+func NewCollateralDepositTx(nonce int64, amount *BigInt, gasPrice *BigInt) (tx *Transaction, _ error) {
+	return newContractTx(
+		energi_abi.IMasternodeTokenABI, energi_params.Nuclear_MasternodeToken,
+		nonce, amount.bigint, int64(energi_params.MasternodeCallGas), gasPrice,
+		"depositCollateral")
+}
+
+// NewCollateralWithdrawTx creates an unsigned transaction to withdraw the
+// amount from the masternode collateral of the sender.
+func NewCollateralWithdrawTx(nonce int64, amount *BigInt, gasPrice *BigInt) (tx *Transaction, _ error) {
+	return newContractTx(
+		energi_abi.IMasternodeTokenABI, energi_params.Nuclear_MasternodeToken,
+		nonce, common.Big0, int64(energi_params.MasternodeCallGas), gasPrice,
+		"withdrawCollateral", amount.bigint)
+}
+
+// Governance
+
+// Proposal represents a governance proposal. The budget fields are only set
+// for the treasury proposals.
+type Proposal struct {
+	info energi_api.BudgetProposalInfo
+}
+
+func (p *Proposal) GetProposal() *Address      { return &Address{p.info.Proposal} }
+func (p *Proposal) GetProposer() *Address      { return &Address{p.info.Proposer} }
+func (p *Proposal) GetCreatedBlock() int64     { return int64(p.info.CreatedBlock) }
+func (p *Proposal) GetDeadline() int64         { return int64(p.info.Deadline) }
+func (p *Proposal) GetQuorumWeight() *BigInt   { return &BigInt{p.info.QuorumWeight.ToInt()} }
+func (p *Proposal) GetTotalWeight() *BigInt    { return &BigInt{p.info.TotalWeight.ToInt()} }
+func (p *Proposal) GetRejectWeight() *BigInt   { return &BigInt{p.info.RejectWeight.ToInt()} }
+func (p *Proposal) GetAcceptWeight() *BigInt   { return &BigInt{p.info.AcceptWeight.ToInt()} }
+func (p *Proposal) IsFinished() bool           { return p.info.Finished }
+func (p *Proposal) IsAccepted() bool           { return p.info.Accepted }
+func (p *Proposal) GetProposedAmount() *BigInt { return &BigInt{bigOrZero(p.info.ProposedAmount)} }
+func (p *Proposal) GetPaidAmount() *BigInt     { return &BigInt{bigOrZero(p.info.PaidAmount)} }
+func (p *Proposal) GetRefUUID() string         { return p.info.RefUUID }
+
+func bigOrZero(value *hexutil.Big) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value.ToInt()
+}
+
+// Proposals represents a slice of proposals.
+type Proposals struct {
+	proposals []energi_api.BudgetProposalInfo
+}
+
+// Size returns the number of proposals in the slice.
+func (p *Proposals) Size() int {
+	return len(p.proposals)
+}
+
+// Get returns the proposal at the given index from the slice.
+func (p *Proposals) Get(index int) (proposal *Proposal, _ error) {
+	if index < 0 || index >= len(p.proposals) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Proposal{p.proposals[index]}, nil
+}
+
+func (nc *NuclearClient) proposalInfo(ctx *Context, address common.Address) (info energi_api.ProposalInfo, err error) {
+	proposal, err := energi_abi.NewIProposalCaller(address, nc.client)
+	if err != nil {
+		return
+	}
+
+	call_opts := nc.callOpts(ctx)
+	info.Proposal = address
+
+	if info.Proposer, err = proposal.FeePayer(call_opts); err != nil {
+		return
+	}
+
+	created, err := proposal.CreatedBlock(call_opts)
+	if err != nil {
+		return
+	}
+	info.CreatedBlock = created.Uint64()
+
+	deadline, err := proposal.Deadline(call_opts)
+	if err != nil {
+		return
+	}
+	info.Deadline = deadline.Uint64()
+
+	weights := []struct {
+		dst   **hexutil.Big
+		query func(*bind.CallOpts) (*big.Int, error)
+	}{
+		{&info.QuorumWeight, proposal.QuorumWeight},
+		{&info.TotalWeight, proposal.TotalWeight},
+		{&info.RejectWeight, proposal.RejectedWeight},
+		{&info.AcceptWeight, proposal.AcceptedWeight},
+	}
+	for _, w := range weights {
+		value, err := w.query(call_opts)
+		if err != nil {
+			return info, err
+		}
+		*w.dst = (*hexutil.Big)(value)
+	}
+
+	if info.Finished, err = proposal.IsFinished(call_opts); err != nil {
+		return
+	}
+	if info.Accepted, err = proposal.IsAccepted(call_opts); err != nil {
+		return
+	}
+
+	balance, err := nc.client.BalanceAt(ctx.context, address, nil)
+	if err != nil {
+		return
+	}
+	info.Balance = (*hexutil.Big)(balance)
+
+	return
+}
+
+// GetProposal returns the state of the proposal.
+func (nc *NuclearClient) GetProposal(ctx *Context, proposal *Address) (info *Proposal, _ error) {
+	res, err := nc.proposalInfo(ctx, proposal.address)
+	if err != nil {
+		return nil, err
+	}
+	return &Proposal{energi_api.BudgetProposalInfo{ProposalInfo: res}}, nil
+}
+
+// GetBudgetProposals returns the treasury budget proposals.
+func (nc *NuclearClient) GetBudgetProposals(ctx *Context) (proposals *Proposals, _ error) {
+	treasury, err := energi_abi.NewITreasuryCaller(
+		energi_params.Nuclear_Treasury, nc.client)
+	if err != nil {
+		return nil, err
+	}
+
+	call_opts := nc.callOpts(ctx)
+
+	addresses, err := treasury.ListProposals(call_opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]energi_api.BudgetProposalInfo, 0, len(addresses))
+	for _, addr := range addresses {
+		info, err := nc.proposalInfo(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		budget, err := energi_abi.NewIBudgetProposalCaller(addr, nc.client)
+		if err != nil {
+			return nil, err
+		}
+
+		proposed, err := budget.ProposedAmount(call_opts)
+		if err != nil {
+			return nil, err
+		}
+		paid, err := budget.PaidAmount(call_opts)
+		if err != nil {
+			return nil, err
+		}
+		ref_uuid, err := budget.RefUuid(call_opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, energi_api.BudgetProposalInfo{
+			ProposalInfo:   info,
+			ProposedAmount: (*hexutil.Big)(proposed),
+			PaidAmount:     (*hexutil.Big)(paid),
+			RefUUID:        uuid.UUID(common.LeftPadBytes(ref_uuid.Bytes(), 16)).String(),
+		})
+	}
+
+	return &Proposals{res}, nil
+}
+
+// GetUpgradeProposals returns the upgrade proposals of the governed proxy.
+func (nc *NuclearClient) GetUpgradeProposals(ctx *Context, proxy *Address) (proposals *Proposals, _ error) {
+	proxy_obj, err := energi_abi.NewIGovernedProxyCaller(proxy.address, nc.client)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := proxy_obj.ListUpgradeProposals(nc.callOpts(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]energi_api.BudgetProposalInfo, 0, len(addresses))
+	for _, addr := range addresses {
+		info, err := nc.proposalInfo(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, energi_api.BudgetProposalInfo{ProposalInfo: info})
+	}
+
+	return &Proposals{res}, nil
+}
+
+// CanVote checks if the masternode owner is allowed to vote on the proposal.
+func (nc *NuclearClient) CanVote(ctx *Context, proposal *Address, owner *Address) (bool, error) {
+	contract, err := energi_abi.NewIProposalCaller(proposal.address, nc.client)
+	if err != nil {
+		return false, err
+	}
+
+	return contract.CanVote(nc.callOpts(ctx), owner.address)
+}
+
+// NewVoteAcceptTx creates an unsigned transaction to vote for the proposal.
+// It must be signed by the masternode owner.
+func NewVoteAcceptTx(nonce int64, proposal *Address, gasPrice *BigInt) (tx *Transaction, _ error) {
+	return newContractTx(
+		energi_abi.IProposalABI, proposal.address,
+		nonce, common.Big0, proposalCallGas, gasPrice,
+		"voteAccept")
+}
+
+// NewVoteRejectTx creates an unsigned transaction to vote against the
+// proposal. It must be signed by the masternode owner.
+func NewVoteRejectTx(nonce int64, proposal *Address, gasPrice *BigInt) (tx *Transaction, _ error) {
+	return newContractTx(
+		energi_abi.IProposalABI, proposal.address,
+		nonce, common.Big0, proposalCallGas, gasPrice,
+		"voteReject")
+}
+
+// Staking
+
+// StakingAccount represents a local account of a staking node.
+type StakingAccount struct {
+	account energi.StakingAccount
+}
+
+func (sa *StakingAccount) GetAccount() *Address { return &Address{sa.account.Account} }
+func (sa *StakingAccount) GetWeight() int64     { return int64(sa.account.Weight) }
+func (sa *StakingAccount) GetNonceCap() int64   { return int64(sa.account.NonceCap) }
+func (sa *StakingAccount) IsStaking() bool      { return sa.account.Staking }
+
+// StakingStatus represents the staking status of a node.
+type StakingStatus struct {
+	status *energi.StakingStatusInfo
+}
+
+func (ss *StakingStatus) GetHash() *Hash        { return &Hash{ss.status.Hash} }
+func (ss *StakingStatus) GetHeight() int64      { return int64(ss.status.Height) }
+func (ss *StakingStatus) IsMiner() bool         { return ss.status.Miner }
+func (ss *StakingStatus) IsStaking() bool       { return ss.status.Staking }
+func (ss *StakingStatus) GetNonceCap() int64    { return int64(ss.status.NonceCap) }
+func (ss *StakingStatus) GetTotalWeight() int64 { return int64(ss.status.TotalWeight) }
+func (ss *StakingStatus) GetAccountCount() int  { return len(ss.status.Accounts) }
+
+// GetAccount returns the staking account at the given index.
+func (ss *StakingStatus) GetAccount(index int) (account *StakingAccount, _ error) {
+	if index < 0 || index >= len(ss.status.Accounts) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &StakingAccount{ss.status.Accounts[index]}, nil
+}
+
+// GetStakingStatus returns the staking status of the connected node. Light
+// nodes do not stake, so it requires a full node.
+func (nc *NuclearClient) GetStakingStatus(ctx *Context) (status *StakingStatus, _ error) {
+	res, err := nc.nuclear.StakingStatus(ctx.context)
+	if err != nil {
+		return nil, err
+	}
+	return &StakingStatus{res}, nil
+}
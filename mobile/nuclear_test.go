@@ -0,0 +1,194 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package geth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/eth"
+	"nuclear/core/nuclear/node"
+	"nuclear/core/nuclear/p2p"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func selector(method string) []byte {
+	return crypto.Keccak256([]byte(method))[:4]
+}
+
+func TestNuclearTxBuilders(t *testing.T) {
+	amount := NewBigInt(10000)
+	gasPrice := NewBigInt(1)
+
+	tx, err := NewCollateralDepositTx(3, amount, gasPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.GetTo().address != energi_params.Nuclear_MasternodeToken {
+		t.Fatalf("wrong deposit destination: %s", tx.GetTo().GetHex())
+	}
+	if tx.GetNonce() != 3 || tx.GetValue().GetInt64() != 10000 {
+		t.Fatalf("wrong deposit nonce or value: %d %d", tx.GetNonce(), tx.GetValue().GetInt64())
+	}
+	if !bytes.Equal(tx.GetData(), selector("depositCollateral()")) {
+		t.Fatalf("wrong deposit data: %x", tx.GetData())
+	}
+
+	tx, err = NewCollateralWithdrawTx(4, amount, gasPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.GetValue().GetInt64() != 0 {
+		t.Fatalf("withdrawal must not transfer value")
+	}
+	if data := tx.GetData(); len(data) != 36 || !bytes.Equal(data[:4], selector("withdrawCollateral(uint256)")) {
+		t.Fatalf("wrong withdrawal data: %x", data)
+	}
+
+	proposal, _ := NewAddressFromHex("0x1111111111111111111111111111111111111111")
+
+	tx, err = NewVoteAcceptTx(5, proposal, gasPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.GetTo().address != proposal.address || !bytes.Equal(tx.GetData(), selector("voteAccept()")) {
+		t.Fatalf("wrong accept vote: %s %x", tx.GetTo().GetHex(), tx.GetData())
+	}
+
+	tx, err = NewVoteRejectTx(6, proposal, gasPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tx.GetData(), selector("voteReject()")) {
+		t.Fatalf("wrong reject vote: %x", tx.GetData())
+	}
+}
+
+// newTestNuclearClient starts an in-process full node at the testnet genesis
+// with the governance contracts deployed.
+func newTestNuclearClient(t *testing.T) (*node.Node, *NuclearClient) {
+	datadir, err := ioutil.TempDir("", "nuclear-mobile-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stack, err := node.New(&node.Config{
+		DataDir: datadir,
+		P2P:     p2p.Config{NoDiscovery: true, MaxPeers: 0},
+		NoUSB:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ethConfig := eth.DefaultConfig
+	ethConfig.Genesis = core.DefaultNuclearTestnetGenesisBlock()
+	err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return eth.New(ctx, &ethConfig)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	rpcClient, err := stack.Attach()
+	if err != nil {
+		stack.Stop()
+		t.Fatal(err)
+	}
+	return stack, newNuclearClient(rpcClient)
+}
+
+func TestNuclearClientQueries(t *testing.T) {
+	stack, client := newTestNuclearClient(t)
+	defer os.RemoveAll(stack.DataDir())
+	defer stack.Stop()
+
+	ctx := NewContext()
+	owner, _ := NewAddressFromHex("0x1111111111111111111111111111111111111111")
+
+	mns, err := client.GetMasternodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mns.Size() != 0 {
+		t.Fatalf("unexpected masternodes at genesis: %d", mns.Size())
+	}
+	if _, err := mns.Get(0); err == nil {
+		t.Fatal("out of bounds masternode returned")
+	}
+
+	info, err := client.GetMasternodeByOwner(ctx, owner)
+	if err != nil || info != nil {
+		t.Fatalf("unknown owner must have no masternode: %v %v", info, err)
+	}
+
+	stats, err := client.GetMasternodeStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.GetTotal() != 0 || stats.GetActive() != 0 || stats.GetTotalCollateral().GetInt64() != 0 {
+		t.Fatalf("unexpected masternode stats at genesis: %+v", stats.stats)
+	}
+
+	balance, err := client.GetCollateralBalance(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.GetInt64() != 0 {
+		t.Fatalf("unexpected collateral: %v", balance)
+	}
+
+	limits, err := client.GetCollateralLimits(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.Size() != 2 || limits.bigints[0].Sign() <= 0 || limits.bigints[0].Cmp(limits.bigints[1]) > 0 {
+		t.Fatalf("wrong collateral limits: %v", limits.bigints)
+	}
+
+	proposals, err := client.GetBudgetProposals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proposals.Size() != 0 {
+		t.Fatalf("unexpected budget proposals at genesis: %d", proposals.Size())
+	}
+
+	proxy := &Address{energi_params.Nuclear_MasternodeRegistry}
+	proposals, err = client.GetUpgradeProposals(ctx, proxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proposals.Size() != 0 {
+		t.Fatalf("unexpected upgrade proposals at genesis: %d", proposals.Size())
+	}
+
+	// Connection failures are not reported as unknown owners
+	cancelled := ctx.WithCancel()
+	cancelled.cancel()
+	info, err = client.GetMasternodeByOwner(cancelled, owner)
+	if err == nil || info != nil {
+		t.Fatalf("failed query must return the error: %v %v", info, err)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build none
+
+/*
+The gen command regenerates the Go bindings of the Nuclear contracts. It is
+run by "go generate" in the package directory.
+
+Usage: go run gen.go [ -solc path ] [ -artifacts dir ] [ -precompiled ] [ -check ]
+
+The Solidity sources are compiled by the pinned solc version with the same
+options as "make energi-contracts". The solc output is kept in the artifacts
+directory, which is also used by "nuclear upgrade verify". It includes the
+<Name>.storage.json storage layouts, which solc provides only through the
+standard JSON interface. With -precompiled the existing solc output in the
+artifacts directory is used instead, so no compiler is required.
+
+The bindings include the deployment and the runtime code used for the
+genesis governance deployment. With -check nothing is written, but the
+command fails if any checked-in binding differs from the generated one.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"nuclear/core/nuclear/accounts/abi/bind"
+)
+
+const solcVersion = "0.5.16"
+
+var solcOptions = []string{
+	"--asm",
+	"--asm-json",
+	"--bin",
+	"--bin-runtime",
+	"--abi",
+	"--optimize",
+	"--optimize-runs=999999999",
+	"--overwrite",
+	"--evm-version", "petersburg",
+}
+
+// The contracts to bind, keep in sync with ENERGI_CONTRACTS of Makefile.include
+var contracts = []string{
+	"BackboneRewardV1",
+	"BlacklistRegistryV1",
+	"BlockRewardV1",
+	"CheckpointRegistryV2",
+	"DummyAccount",
+	"IBlacklistRegistry",
+	"IBlockReward",
+	"IBudgetProposal",
+	"ICheckpointV2",
+	"ICheckpointRegistry",
+	"IDelegatedPoS",
+	"IGovernedProxy",
+	"IMasternodeRegistryV2",
+	"IMasternodeToken",
+	"IProposal",
+	"ISporkRegistry",
+	"ITreasury",
+	"Gen2Migration",
+	"GovernedProxy",
+	"MasternodeTokenV2",
+	"MasternodeRegistryV2",
+	"SporkRegistryV2",
+	"StakerRewardV1",
+	"TreasuryV1",
+}
+
+var (
+	solcFlag        = flag.String("solc", defaultSolc(), "Path to the solc "+solcVersion+" binary")
+	srcFlag         = flag.String("src", "../contracts/src", "Directory of the Solidity sources")
+	artifactsFlag   = flag.String("artifacts", "../../build/contracts/energi", "Directory of the solc output")
+	precompiledFlag = flag.Bool("precompiled", false, "Use the solc output in the artifacts directory")
+	checkFlag       = flag.Bool("check", false, "Fail if the checked-in bindings differ instead of writing")
+)
+
+func defaultSolc() string {
+	if solc := os.Getenv("SOLC"); solc != "" {
+		return solc
+	}
+	return "../../build/bin/solc-" + solcVersion
+}
+
+func main() {
+	flag.Parse()
+
+	if !*precompiledFlag {
+		if err := checkSolc(*solcFlag); err != nil {
+			fatalf("Unusable compiler: %v", err)
+		}
+		if err := os.MkdirAll(*artifactsFlag, 0755); err != nil {
+			fatalf("Failed to create artifacts directory: %v", err)
+		}
+	}
+
+	var drift []string
+	for _, name := range contracts {
+		if !*precompiledFlag {
+			if err := compile(name); err != nil {
+				fatalf("Failed to compile %s: %v", name, err)
+			}
+			if err := storageLayout(name); err != nil {
+				fatalf("Failed to get storage layout of %s: %v", name, err)
+			}
+		}
+
+		code, err := generate(name)
+		if err != nil {
+			fatalf("Failed to generate %s: %v", name, err)
+		}
+
+		file := name + ".go"
+		if *checkFlag {
+			current, err := ioutil.ReadFile(file)
+			if err != nil || !bytes.Equal(current, code) {
+				drift = append(drift, file)
+			}
+			continue
+		}
+
+		if err := ioutil.WriteFile(file, code, 0644); err != nil {
+			fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	if len(drift) > 0 {
+		fatalf("Bindings are out of date with the sources: %s", strings.Join(drift, ", "))
+	}
+}
+
+// checkSolc makes sure the bytecode is reproducible with the compiler.
+func checkSolc(solc string) error {
+	out, err := exec.Command(solc, "--version").CombinedOutput()
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(out), "Version: "+solcVersion+"+") {
+		return fmt.Errorf("%s is not solc %s", solc, solcVersion)
+	}
+	return nil
+}
+
+// compile puts the solc output of the contract into the artifacts directory.
+func compile(name string) error {
+	args := append([]string{}, solcOptions...)
+	args = append(args, "-o", *artifactsFlag, filepath.Join(*srcFlag, name+".sol"))
+
+	out, err := exec.Command(*solcFlag, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\n%s", err, out)
+	}
+	return nil
+}
+
+// storageLayout puts the solc storage layout of the contract into the
+// artifacts directory. The settings must match solcOptions.
+func storageLayout(name string) error {
+	source := filepath.Join(*srcFlag, name+".sol")
+
+	input, err := json.Marshal(map[string]interface{}{
+		"language": "Solidity",
+		"sources": map[string]interface{}{
+			source: map[string]interface{}{"urls": []string{source}},
+		},
+		"settings": map[string]interface{}{
+			"optimizer":  map[string]interface{}{"enabled": true, "runs": 999999999},
+			"evmVersion": "petersburg",
+			"outputSelection": map[string]interface{}{
+				source: map[string]interface{}{name: []string{"storageLayout"}},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(*solcFlag, "--standard-json", "--allow-paths", *srcFlag)
+	cmd.Stdin = bytes.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	var output struct {
+		Errors []struct {
+			Severity         string `json:"severity"`
+			FormattedMessage string `json:"formattedMessage"`
+		} `json:"errors"`
+		Contracts map[string]map[string]struct {
+			StorageLayout json.RawMessage `json:"storageLayout"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(out, &output); err != nil {
+		return err
+	}
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			return fmt.Errorf("%s", e.FormattedMessage)
+		}
+	}
+
+	layout := output.Contracts[source][name].StorageLayout
+	if len(layout) == 0 {
+		return fmt.Errorf("no storage layout in the solc output")
+	}
+
+	return ioutil.WriteFile(filepath.Join(*artifactsFlag, name+".storage.json"), layout, 0644)
+}
+
+// generate binds the solc output of the contract.
+func generate(name string) ([]byte, error) {
+	read := func(ext string) (string, error) {
+		data, err := ioutil.ReadFile(filepath.Join(*artifactsFlag, name+ext))
+		return string(data), err
+	}
+
+	abi, err := read(".abi")
+	if err != nil {
+		return nil, err
+	}
+	bin, err := read(".bin")
+	if err != nil {
+		return nil, err
+	}
+	runtime, err := read(".bin-runtime")
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := bind.Bind([]string{name}, []string{abi}, []string{bin}, []string{runtime}, "abi", bind.LangGo)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(code), nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -19,7 +19,9 @@ package api
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"time"
 
 	"nuclear/core/nuclear/accounts"
 	"nuclear/core/nuclear/accounts/abi/bind"
@@ -33,6 +35,8 @@ import (
 	"nuclear/core/nuclear/event"
 	"nuclear/core/nuclear/params"
 	"nuclear/core/nuclear/rpc"
+
+	energi_params "nuclear/core/nuclear/energi/params"
 )
 
 // Backend interface provides the common API services (that are provided by
@@ -73,14 +77,84 @@ type Backend interface {
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
 
-	AddLocalCheckpoint(num uint64, hash common.Hash) error
+	AddLocalCheckpoint(cp core.Checkpoint, source string, ttl time.Duration, force bool) (*core.CheckpointRollback, error)
+	RemoveLocalCheckpoint(number uint64) error
+	ListLocalCheckpoints() []core.LocalCheckpoint
 	ListCheckpoints() []core.CheckpointInfo
 	CheckpointSignatures(cp core.Checkpoint) []core.CheckpointSignature
+	AddTrustedCheckpoint(scp core.SignedCheckpoint) error
+	ExportCheckpoints() []core.SignedCheckpoint
 
 	IsPublicService() bool
 	OnSyncedHeadUpdates(cb func())
 }
 
+// queryBlock resolves the optional block of the cached read APIs. Nil is
+// returned for the current block. A block selected by hash must be canonical
+// as the contract calls are made by number.
+func queryBlock(backend Backend, block *rpc.BlockNumberOrHash) (*types.Block, error) {
+	if block == nil {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+
+	if hash, ok := block.Hash(); ok {
+		res, err := backend.GetBlock(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			return nil, errors.New("block not found")
+		}
+
+		header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(res.NumberU64()))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil || header.Hash() != hash {
+			return nil, errors.New("block is not canonical")
+		}
+
+		return res, nil
+	}
+
+	number, ok := block.Number()
+	if !ok {
+		return nil, errors.New("block hash or number is required")
+	}
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return nil, nil
+	}
+
+	res, err := backend.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("block not found")
+	}
+
+	return res, nil
+}
+
+// blockCallOpts returns the options to call contracts at the block state.
+// The current block keeps using the pending state like before blocks could
+// be selected.
+func blockCallOpts(backend Backend, num *big.Int) *bind.CallOpts {
+	if num == nil || num.Cmp(backend.CurrentBlock().Number()) >= 0 {
+		return &bind.CallOpts{
+			Pending:  true,
+			GasLimit: energi_params.UnlimitedGas,
+		}
+	}
+
+	return &bind.CallOpts{
+		BlockNumber: num,
+		GasLimit:    energi_params.UnlimitedGas,
+	}
+}
+
 func createSignerCallback(
 	backend Backend,
 	password *string,
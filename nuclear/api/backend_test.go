@@ -0,0 +1,44 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"testing"
+
+	"nuclear/core/nuclear/rpc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBlock(t *testing.T) {
+	t.Parallel()
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	pending := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+
+	// The current block is served without a lookup
+	for _, block := range []*rpc.BlockNumberOrHash{nil, &latest, &pending} {
+		res, err := queryBlock(nil, block)
+		assert.Empty(t, err)
+		assert.Nil(t, res)
+	}
+
+	// An empty selector is not the genesis block
+	res, err := queryBlock(nil, &rpc.BlockNumberOrHash{})
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
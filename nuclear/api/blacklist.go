@@ -24,6 +24,7 @@ import (
 	"nuclear/core/nuclear/common"
 	"nuclear/core/nuclear/common/hexutil"
 	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rpc"
 
 	energi_abi "nuclear/core/nuclear/energi/abi"
 	energi_common "nuclear/core/nuclear/energi/common"
@@ -43,8 +44,8 @@ func NewBlacklistAPI(b Backend) *BlacklistAPI {
 		compCache: energi_common.NewCacheStorage(),
 	}
 	b.OnSyncedHeadUpdates(func() {
-		r.BlacklistInfo()
-		r.CompensationInfo()
+		r.BlacklistInfo(nil)
+		r.CompensationInfo(nil)
 	})
 	return r
 }
@@ -96,8 +97,13 @@ type BLInfo struct {
 	Blocked bool
 }
 
-func (b *BlacklistAPI) BlacklistInfo() (res []BLInfo, err error) {
-	data, err := b.infoCache.Get(b.backend, b.blacklistInfo)
+func (b *BlacklistAPI) BlacklistInfo(block *rpc.BlockNumberOrHash) (res []BLInfo, err error) {
+	at, err := queryBlock(b.backend, block)
+	if err != nil {
+		return
+	}
+
+	data, err := b.infoCache.GetAt(b.backend, at, b.blacklistInfo)
 	if err != nil || data == nil {
 		log.Error("BlacklistInfo failed", "err", err)
 		return
@@ -116,10 +122,7 @@ func (b *BlacklistAPI) blacklistInfo(num *big.Int) (interface{}, error) {
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(b.backend, num)
 	addresses, err := registry.EnumerateAll(call_opts)
 	if err != nil {
 		log.Error("Failed", "err", err)
@@ -141,17 +144,17 @@ func (b *BlacklistAPI) blacklistInfo(num *big.Int) (interface{}, error) {
 			continue
 		}
 
-		enforceInfo, err := proposalInfo(b.backend, proposals.Enforce)
+		enforceInfo, err := proposalInfo(b.backend, num, proposals.Enforce)
 		if err != nil {
 			log.Debug("Enforce info error", "addr", addr, "err", err)
 		}
 
-		revokeInfo, err := proposalInfo(b.backend, proposals.Revoke)
+		revokeInfo, err := proposalInfo(b.backend, num, proposals.Revoke)
 		if err != nil {
 			log.Debug("Revoke info error", "addr", addr, "err", err)
 		}
 
-		drainInfo, err := proposalInfo(b.backend, proposals.Drain)
+		drainInfo, err := proposalInfo(b.backend, num, proposals.Drain)
 		if err != nil {
 			log.Debug("Drain info error", "addr", addr, "err", err)
 		}
@@ -272,8 +275,13 @@ func (b *BlacklistAPI) BlacklistCollect(
 	return
 }
 
-func (b *BlacklistAPI) CompensationInfo() (*BudgetInfo, error) {
-	data, err := b.compCache.Get(b.backend, b.compensationInfo)
+func (b *BlacklistAPI) CompensationInfo(block *rpc.BlockNumberOrHash) (*BudgetInfo, error) {
+	at, err := queryBlock(b.backend, block)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := b.compCache.GetAt(b.backend, at, b.compensationInfo)
 	if err != nil || data == nil {
 		log.Error("CompensationInfo failed", "err", err)
 		return nil, err
@@ -288,7 +296,7 @@ func (b *BlacklistAPI) compensationInfo(num *big.Int) (interface{}, error) {
 		return nil, err
 	}
 
-	return treasuryInfo(comp_fund, b.backend)
+	return treasuryInfo(comp_fund, b.backend, num)
 }
 
 func (b *BlacklistAPI) CompensationPropose(
@@ -18,11 +18,14 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"math/big"
+	"time"
 
 	"nuclear/core/nuclear/accounts"
 	"nuclear/core/nuclear/accounts/abi/bind"
 	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
 	"nuclear/core/nuclear/log"
 	"nuclear/core/nuclear/rpc"
 
@@ -42,7 +45,7 @@ func NewCheckpointAPI(b Backend) *CheckpointAPI {
 		cpCache: energi_common.NewCacheStorage(),
 	}
 	b.OnSyncedHeadUpdates(func() {
-		r.CheckpointInfo()
+		r.CheckpointInfo(nil)
 	})
 	return r
 }
@@ -104,12 +107,20 @@ type CheckpointInfo struct {
 
 type AllCheckpointInfo struct {
 	Registry []CheckpointInfo
-	Active   []CheckpointInfo
+	Active   []CheckpointInfo // local state of the node when first queried
 }
 
-func (b *CheckpointAPI) CheckpointInfo() (res *AllCheckpointInfo, err error) {
+// CheckpointInfo returns the registry proposals at the block. The active
+// checkpoints are not tracked per block, so they are always the ones the
+// node enforced when the block data got cached.
+func (b *CheckpointAPI) CheckpointInfo(block *rpc.BlockNumberOrHash) (res *AllCheckpointInfo, err error) {
+	at, err := queryBlock(b.backend, block)
+	if err != nil {
+		return
+	}
+
 	var data interface{}
-	data, err = b.cpCache.Get(b.backend, b.checkpointInfo)
+	data, err = b.cpCache.GetAt(b.backend, at, b.checkpointInfo)
 	if err != nil || data == nil {
 		log.Error("CheckpointInfo failed", "err", err)
 		return
@@ -128,10 +139,7 @@ func (b *CheckpointAPI) checkpointInfo(num *big.Int) (interface{}, error) {
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(b.backend, num)
 	addresses, err := registry.Checkpoints(call_opts)
 	if err != nil {
 		log.Error("Failed", "err", err)
@@ -234,9 +242,61 @@ func NewCheckpointAdminAPI(b Backend) *CheckpointAdminAPI {
 	return &CheckpointAdminAPI{b}
 }
 
+// CheckpointLocal enforces a checkpoint on the local node only. A checkpoint
+// which would roll back the canonical chain is refused unless forced.
+// The optional ttl is in seconds.
 func (b *CheckpointAdminAPI) CheckpointLocal(
 	number uint64,
 	hash common.Hash,
-) error {
-	return b.backend.AddLocalCheckpoint(number, hash)
+	force *bool,
+	ttl *uint64,
+) (*core.CheckpointRollback, error) {
+	cp := core.Checkpoint{
+		Number: number,
+		Hash:   hash,
+	}
+
+	var expiry time.Duration
+	if ttl != nil {
+		expiry = time.Duration(*ttl) * time.Second
+	}
+
+	rollback, err := b.backend.AddLocalCheckpoint(
+		cp, "admin_checkpointLocal", expiry, force != nil && *force)
+	if err == core.ErrCheckpointConflict {
+		return nil, fmt.Errorf("%v: would drop %d blocks from #%d (head #%d, %v)",
+			err, rollback.Blocks, rollback.Number, rollback.Head, rollback.Canonical.Hex())
+	}
+
+	return rollback, err
+}
+
+// CheckpointLocalRemove stops enforcement of a local checkpoint.
+func (b *CheckpointAdminAPI) CheckpointLocalRemove(number uint64) error {
+	return b.backend.RemoveLocalCheckpoint(number)
+}
+
+// CheckpointLocalList returns the local checkpoints with their origin.
+func (b *CheckpointAdminAPI) CheckpointLocalList() []core.LocalCheckpoint {
+	return b.backend.ListLocalCheckpoints()
+}
+
+// CheckpointExport returns the validated checkpoints signed by CPP_signer.
+func (b *CheckpointAdminAPI) CheckpointExport() []core.SignedCheckpoint {
+	return b.backend.ExportCheckpoints()
+}
+
+// CheckpointImport enforces the signed checkpoints. It stops at the first
+// invalid one and returns the number of imported checkpoints.
+func (b *CheckpointAdminAPI) CheckpointImport(
+	checkpoints []core.SignedCheckpoint,
+) (int, error) {
+	for i, scp := range checkpoints {
+		if err := b.backend.AddTrustedCheckpoint(scp); err != nil {
+			log.Error("Failed to import checkpoint", "number", scp.Number, "hash", scp.Hash, "err", err)
+			return i, err
+		}
+	}
+
+	return len(checkpoints), nil
 }
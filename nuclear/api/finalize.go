@@ -0,0 +1,183 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rpc"
+
+	energi_consensus "nuclear/core/nuclear/energi/consensus"
+)
+
+type ConsensusTxInfo struct {
+	Hash    common.Hash     `json:"hash"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	Input   hexutil.Bytes   `json:"input"`
+	Status  hexutil.Uint64  `json:"status"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Logs    []*types.Log    `json:"logs"`
+}
+
+type AccountDelta struct {
+	Address        common.Address `json:"address"`
+	BalanceBefore  *hexutil.Big   `json:"balanceBefore"`
+	BalanceAfter   *hexutil.Big   `json:"balanceAfter"`
+	Delta          *hexutil.Big   `json:"delta"`
+	NonceBefore    hexutil.Uint64 `json:"nonceBefore"`
+	NonceAfter     hexutil.Uint64 `json:"nonceAfter"`
+	StorageChanged bool           `json:"storageChanged"`
+}
+
+type FinalizePreview struct {
+	Number       uint64            `json:"number"`
+	ParentHash   common.Hash       `json:"parentHash"`
+	Coinbase     common.Address    `json:"coinbase"`
+	Root         common.Hash       `json:"root"`
+	Transactions []ConsensusTxInfo `json:"transactions"`
+	Rewards      []BlockRewardItem `json:"rewards"`
+	Deltas       []AccountDelta    `json:"deltas"`
+}
+
+// PreviewFinalize runs the finalization of a hypothetical block with the
+// given coinbase on top of the latest or the specified parent block. It
+// returns the consensus transactions the block would get and the account
+// changes they would cause, assuming no regular transaction is included.
+//
+// NOTE: nothing is persisted, but the migration block can not be previewed.
+func (r *RewardsAPI) PreviewFinalize(
+	coinbase common.Address,
+	number *hexutil.Uint64,
+) (*FinalizePreview, error) {
+	engine, ok := r.backend.BlockChain().Engine().(*energi_consensus.Nuclear)
+	if !ok {
+		return nil, errors.New("Not a Nuclear consensus engine")
+	}
+
+	parent_nr := rpc.LatestBlockNumber
+	if number != nil {
+		if *number == 0 {
+			return nil, errors.New("Genesis block can not be finalized")
+		}
+		parent_nr = rpc.BlockNumber(*number - 1)
+	}
+
+	statedb, parent, err := r.backend.StateAndHeaderByNumber(context.Background(), parent_nr)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil || statedb == nil {
+		return nil, errors.New("Unknown parent block")
+	}
+
+	before := statedb.Copy()
+	header, txs, receipts, rewards, err := engine.PreviewFinalize(
+		r.backend.BlockChain(), parent, coinbase, statedb)
+	if err != nil {
+		log.Error("Failed to preview finalization", "err", err)
+		return nil, err
+	}
+
+	ret := &FinalizePreview{
+		Number:       header.Number.Uint64(),
+		ParentHash:   header.ParentHash,
+		Coinbase:     header.Coinbase,
+		Root:         header.Root,
+		Transactions: make([]ConsensusTxInfo, len(txs)),
+		Rewards:      NewBlockRewardItems(rewards),
+		Deltas:       accountDeltas(before, statedb),
+	}
+
+	for i, tx := range txs {
+		info := ConsensusTxInfo{
+			Hash:  tx.Hash(),
+			From:  tx.ConsensusSender(),
+			To:    tx.To(),
+			Value: (*hexutil.Big)(tx.Value()),
+			Gas:   hexutil.Uint64(tx.Gas()),
+			Input: tx.Data(),
+			Logs:  []*types.Log{},
+		}
+
+		if i < len(receipts) {
+			receipt := receipts[i]
+			info.Status = hexutil.Uint64(receipt.Status)
+			info.GasUsed = hexutil.Uint64(receipt.GasUsed)
+			if receipt.Logs != nil {
+				info.Logs = receipt.Logs
+			}
+		}
+
+		ret.Transactions[i] = info
+	}
+
+	return ret, nil
+}
+
+// accountDeltas lists the accounts changed from the before state in the
+// address order.
+func accountDeltas(before, after *state.StateDB) []AccountDelta {
+	addresses := after.DirtyAccounts()
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Big().Cmp(addresses[j].Big()) < 0
+	})
+
+	res := []AccountDelta{}
+	for _, addr := range addresses {
+		balance_before := before.GetBalance(addr)
+		balance_after := after.GetBalance(addr)
+		nonce_before := before.GetNonce(addr)
+		nonce_after := after.GetNonce(addr)
+		storage_changed := storageHash(before, addr) != storageHash(after, addr)
+
+		if balance_before.Cmp(balance_after) == 0 &&
+			nonce_before == nonce_after &&
+			!storage_changed {
+			continue
+		}
+
+		res = append(res, AccountDelta{
+			Address:        addr,
+			BalanceBefore:  (*hexutil.Big)(balance_before),
+			BalanceAfter:   (*hexutil.Big)(balance_after),
+			Delta:          (*hexutil.Big)(new(big.Int).Sub(balance_after, balance_before)),
+			NonceBefore:    hexutil.Uint64(nonce_before),
+			NonceAfter:     hexutil.Uint64(nonce_after),
+			StorageChanged: storage_changed,
+		})
+	}
+
+	return res
+}
+
+func storageHash(statedb *state.StateDB, addr common.Address) common.Hash {
+	if trie := statedb.StorageTrie(addr); trie != nil {
+		return trie.Hash()
+	}
+	return common.Hash{}
+}
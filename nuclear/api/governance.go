@@ -25,6 +25,7 @@ import (
 	"nuclear/core/nuclear/accounts/abi/bind"
 	"nuclear/core/nuclear/common"
 	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/types"
 	"nuclear/core/nuclear/log"
 	"nuclear/core/nuclear/rpc"
 
@@ -42,6 +43,8 @@ type GovernanceAPI struct {
 	backend    Backend
 	uInfoCache *energi_common.CacheStorage
 	bInfoCache *energi_common.CacheStorage
+	sInfoCache *energi_common.CacheStorage
+	votes      *voteIndex
 }
 
 func NewGovernanceAPI(b Backend) *GovernanceAPI {
@@ -49,10 +52,20 @@ func NewGovernanceAPI(b Backend) *GovernanceAPI {
 		backend:    b,
 		uInfoCache: energi_common.NewCacheStorage(),
 		bInfoCache: energi_common.NewCacheStorage(),
+		sInfoCache: energi_common.NewCacheStorage(),
+		votes:      newVoteIndex(b.ChainDb()),
 	}
+
+	// Keep the persisted vote index up to date, otherwise it is started
+	// on the first use.
+	if _, next := r.votes.snapshot(); next > 0 {
+		r.votes.start(b)
+	}
+
 	b.OnSyncedHeadUpdates(func() {
-		r.UpgradeInfo()
-		r.BudgetInfo()
+		r.UpgradeInfo(nil)
+		r.BudgetInfo(nil)
+		r.SporkInfo(nil)
 	})
 	return r
 }
@@ -108,31 +121,22 @@ func (g *GovernanceAPI) VoteAccept(
 	mn_owner common.Address,
 	password *string,
 ) (txhash common.Hash, err error) {
-	contract, err := g.proposal(password, mn_owner, proposal)
-	if err != nil {
-		log.Error("Failed", "err", err)
-		return
-	}
-
-	if err = g.checkCanVote(contract, mn_owner); err != nil {
-		log.Error("Failed", "err", err)
-		return
-	}
-
-	tx, err := contract.VoteAccept()
-
-	if tx != nil {
-		txhash = tx.Hash()
-		log.Info("Note: please wait until the proposal TX gets into a block!", "tx", txhash.Hex())
-	}
-
-	return
+	return g.vote(proposal, mn_owner, password, true)
 }
 
 func (g *GovernanceAPI) VoteReject(
 	proposal common.Address,
 	mn_owner common.Address,
 	password *string,
+) (txhash common.Hash, err error) {
+	return g.vote(proposal, mn_owner, password, false)
+}
+
+func (g *GovernanceAPI) vote(
+	proposal common.Address,
+	mn_owner common.Address,
+	password *string,
+	accept bool,
 ) (txhash common.Hash, err error) {
 	contract, err := g.proposal(password, mn_owner, proposal)
 	if err != nil {
@@ -145,7 +149,12 @@ func (g *GovernanceAPI) VoteReject(
 		return
 	}
 
-	tx, err := contract.VoteReject()
+	var tx *types.Transaction
+	if accept {
+		tx, err = contract.VoteAccept()
+	} else {
+		tx, err = contract.VoteReject()
+	}
 
 	if tx != nil {
 		txhash = tx.Hash()
@@ -200,11 +209,9 @@ type ProposalInfo struct {
 	Balance      *hexutil.Big
 }
 
-func getBalance(backend Backend, address common.Address) (*hexutil.Big, error) {
-	curr_block := backend.CurrentBlock()
-
+func getBalance(backend Backend, num *big.Int, address common.Address) (*hexutil.Big, error) {
 	state, _, err := backend.StateAndHeaderByNumber(
-		nil, rpc.BlockNumber(curr_block.Number().Int64()))
+		nil, rpc.BlockNumber(num.Int64()))
 	if err != nil {
 		log.Error("Failed at state", "err", err)
 		return nil, err
@@ -213,7 +220,7 @@ func getBalance(backend Backend, address common.Address) (*hexutil.Big, error) {
 	return (*hexutil.Big)(state.GetBalance(address)), nil
 }
 
-func proposalInfo(backend Backend, address common.Address) (*ProposalInfo, error) {
+func proposalInfo(backend Backend, num *big.Int, address common.Address) (*ProposalInfo, error) {
 	if (address == common.Address{}) {
 		return nil, nil
 	}
@@ -225,10 +232,7 @@ func proposalInfo(backend Backend, address common.Address) (*ProposalInfo, error
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(backend, num)
 
 	proposer, err := proposal.FeePayer(call_opts)
 	if err != nil {
@@ -284,7 +288,7 @@ func proposalInfo(backend Backend, address common.Address) (*ProposalInfo, error
 		return nil, err
 	}
 
-	balance, err := getBalance(backend, address)
+	balance, err := getBalance(backend, num, address)
 	if err != nil {
 		log.Error("Failed at getBalance", "err", err)
 		return nil, err
@@ -324,10 +328,7 @@ func (g *GovernanceAPI) upgradeProposalInfo(num *big.Int, proxy common.Address)
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(g.backend, num)
 	proposals, err := proxy_obj.ListUpgradeProposals(call_opts)
 	if err != nil {
 		log.Error("Failed ListUpgradeProposals", "err", err)
@@ -336,7 +337,7 @@ func (g *GovernanceAPI) upgradeProposalInfo(num *big.Int, proxy common.Address)
 
 	ret := make([]UpgradeProposalInfo, 0, len(proposals))
 	for i, p := range proposals {
-		pInfo, err := proposalInfo(g.backend, p)
+		pInfo, err := proposalInfo(g.backend, num, p)
 		if err != nil {
 			log.Error("Failed at proposalInfo", "err", err)
 			continue
@@ -394,8 +395,14 @@ type UpgradeProposals struct {
 	MasternodeToken    []UpgradeProposalInfo
 }
 
-func (g *GovernanceAPI) UpgradeInfo() *UpgradeProposals {
-	data, err := g.uInfoCache.Get(g.backend, g.upgradeInfo)
+func (g *GovernanceAPI) UpgradeInfo(block *rpc.BlockNumberOrHash) *UpgradeProposals {
+	at, err := queryBlock(g.backend, block)
+	if err != nil {
+		log.Error("UpgradeInfo failed", "err", err)
+		return nil
+	}
+
+	data, err := g.uInfoCache.GetAt(g.backend, at, g.upgradeInfo)
 	if err != nil || data == nil {
 		log.Error("UpgradeInfo failed", "err", err)
 		return nil
@@ -564,8 +571,13 @@ type BudgetInfo struct {
 	Proposals []BudgetProposalInfo
 }
 
-func (g *GovernanceAPI) BudgetInfo() (*BudgetInfo, error) {
-	data, err := g.bInfoCache.Get(g.backend, g.budgetInfo)
+func (g *GovernanceAPI) BudgetInfo(block *rpc.BlockNumberOrHash) (*BudgetInfo, error) {
+	at, err := queryBlock(g.backend, block)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := g.bInfoCache.GetAt(g.backend, at, g.budgetInfo)
 	if err != nil || data == nil {
 		log.Error("BudgetInfo failed", "err", err)
 		return nil, err
@@ -575,10 +587,10 @@ func (g *GovernanceAPI) BudgetInfo() (*BudgetInfo, error) {
 }
 
 func (g *GovernanceAPI) budgetInfo(num *big.Int) (interface{}, error) {
-	return treasuryInfo(energi_params.Nuclear_Treasury, g.backend)
+	return treasuryInfo(energi_params.Nuclear_Treasury, g.backend, num)
 }
 
-func treasuryInfo(addr common.Address, backend Backend) (interface{}, error) {
+func treasuryInfo(addr common.Address, backend Backend, num *big.Int) (interface{}, error) {
 	treasury, err := energi_abi.NewITreasuryCaller(
 		addr, backend.(bind.ContractCaller))
 	if err != nil {
@@ -593,10 +605,7 @@ func treasuryInfo(addr common.Address, backend Backend) (interface{}, error) {
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(backend, num)
 
 	proposals, err := treasury.ListProposals(call_opts)
 	if err != nil {
@@ -613,7 +622,7 @@ func treasuryInfo(addr common.Address, backend Backend) (interface{}, error) {
 
 	ret := make([]BudgetProposalInfo, 0, len(proposals))
 	for i, p := range proposals {
-		pInfo, err := proposalInfo(backend, p)
+		pInfo, err := proposalInfo(backend, num, p)
 		if err != nil {
 			log.Debug("Failed at proposalInfo", "err", err)
 			continue
@@ -645,10 +654,10 @@ func treasuryInfo(addr common.Address, backend Backend) (interface{}, error) {
 		}
 		ret[i].ProposedAmount = (*hexutil.Big)(proposed_amount)
 		ret[i].PaidAmount = (*hexutil.Big)(paid_amount)
-		ret[i].RefUUID = uuid.UUID(common.LeftPadBytes(ref_uuid.Bytes(), 16)).String()
+		ret[i].RefUUID = refUUIDString(ref_uuid)
 	}
 
-	balance, err := getBalance(backend, impl)
+	balance, err := getBalance(backend, num, impl)
 	if err != nil {
 		log.Error("Failed at getBalance", "err", err)
 	}
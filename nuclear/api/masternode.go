@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"nuclear/core/nuclear/accounts/abi/bind"
 	"nuclear/core/nuclear/common"
@@ -27,6 +28,7 @@ import (
 	"nuclear/core/nuclear/crypto"
 	"nuclear/core/nuclear/log"
 	"nuclear/core/nuclear/p2p/enode"
+	"nuclear/core/nuclear/rpc"
 
 	energi_abi "nuclear/core/nuclear/energi/abi"
 	energi_common "nuclear/core/nuclear/energi/common"
@@ -42,17 +44,22 @@ type MasternodeAPI struct {
 	backend    Backend
 	nodesCache *energi_common.CacheStorage
 	statsCache *energi_common.CacheStorage
+	watcher    *MasternodeWatcher
 }
 
-func NewMasternodeAPI(b Backend) *MasternodeAPI {
+func NewMasternodeAPI(b Backend, notifiers ...MasternodeNotifier) *MasternodeAPI {
 	r := &MasternodeAPI{
 		backend:    b,
 		nodesCache: energi_common.NewCacheStorage(),
 		statsCache: energi_common.NewCacheStorage(),
+		watcher:    newMasternodeWatcher(b),
+	}
+	for _, n := range notifiers {
+		r.watcher.AddNotifier(n)
 	}
 	b.OnSyncedHeadUpdates(func() {
-		r.ListMasternodes()
-		r.Stats()
+		r.ListMasternodes(nil)
+		r.Stats(nil)
 	})
 	return r
 }
@@ -166,6 +173,7 @@ func (m *MasternodeAPI) DepositCollateral(
 
 	if tx != nil {
 		txhash = tx.Hash()
+		m.watcher.trackTx(dst, "depositCollateral", txhash, nil)
 		log.Info("Note: please wait until the collateral TX gets into a block!", "tx", txhash.Hex())
 	}
 
@@ -212,6 +220,7 @@ func (m *MasternodeAPI) WithdrawCollateral(
 
 	if tx != nil {
 		txhash = tx.Hash()
+		m.watcher.trackTx(dst, "withdrawCollateral", txhash, nil)
 		log.Info("Note: please wait until the collateral TX gets into a block!", "tx", txhash.Hex())
 	}
 
@@ -236,6 +245,7 @@ type MNInfo struct {
 	Masternode     common.Address
 	Owner          common.Address
 	Enode          string
+	ENR            string
 	Collateral     *hexutil.Big
 	AnnouncedBlock uint64
 	IsActive       bool
@@ -244,8 +254,13 @@ type MNInfo struct {
 	SWVersion      string
 }
 
-func (m *MasternodeAPI) ListMasternodes() (res []MNInfo, err error) {
-	data, err := m.nodesCache.Get(m.backend, m.listMasternodes)
+func (m *MasternodeAPI) ListMasternodes(block *rpc.BlockNumberOrHash) (res []MNInfo, err error) {
+	at, err := queryBlock(m.backend, block)
+	if err != nil {
+		return
+	}
+
+	data, err := m.nodesCache.GetAt(m.backend, at, m.listMasternodes)
 	if err != nil || data == nil {
 		log.Error("ListMasternodes failed", "err", err)
 		return
@@ -264,10 +279,7 @@ func (m *MasternodeAPI) listMasternodes(num *big.Int) (interface{}, error) {
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(m.backend, num)
 	prev_call_opts := &bind.CallOpts{
 		BlockNumber: new(big.Int).Sub(num, common.Big3),
 		GasLimit:    energi_params.UnlimitedGas,
@@ -313,7 +325,8 @@ func (m *MasternodeAPI) listMasternodes(num *big.Int) (interface{}, error) {
 		res = append(res, MNInfo{
 			Masternode:     mn,
 			Owner:          mninfo.Owner,
-			Enode:          m.enode(mninfo.Ipv4address, mninfo.Enode),
+			Enode:          m.enode(mn, mninfo.Ipv4address, mninfo.Enode),
+			ENR:            m.enr(mn, mninfo.Enode),
 			Collateral:     (*hexutil.Big)(mninfo.Collateral),
 			AnnouncedBlock: mninfo.AnnouncedBlock.Uint64(),
 			IsActive:       isActive,
@@ -327,7 +340,7 @@ func (m *MasternodeAPI) listMasternodes(num *big.Int) (interface{}, error) {
 }
 
 func (m *MasternodeAPI) MasternodeInfo(owner_or_mn common.Address) (res MNInfo, err error) {
-	Mns, err := m.ListMasternodes()
+	Mns, err := m.ListMasternodes(nil)
 	if err != nil {
 		log.Error("Failed at m.ListMasternodes", "err", err)
 		return
@@ -343,8 +356,13 @@ func (m *MasternodeAPI) MasternodeInfo(owner_or_mn common.Address) (res MNInfo,
 	return
 }
 
-func (m *MasternodeAPI) Stats() (res *MasternodeStats, err error) {
-	data, err := m.statsCache.Get(m.backend, m.stats)
+func (m *MasternodeAPI) Stats(block *rpc.BlockNumberOrHash) (res *MasternodeStats, err error) {
+	at, err := queryBlock(m.backend, block)
+	if err != nil {
+		return
+	}
+
+	data, err := m.statsCache.GetAt(m.backend, at, m.stats)
 
 	if err != nil || data == nil {
 		log.Error("Stats failed", "err", err)
@@ -363,10 +381,7 @@ func (m *MasternodeAPI) stats(num *big.Int) (interface{}, error) {
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(m.backend, num)
 	count, err := registry.Count(call_opts)
 	if err != nil {
 		log.Error("Failed", "err", err)
@@ -383,15 +398,25 @@ func (m *MasternodeAPI) stats(num *big.Int) (interface{}, error) {
 	return res, nil
 }
 
-func (m *MasternodeAPI) enode(ipv4address uint32, pubkey [2][32]byte) string {
+func (m *MasternodeAPI) enode(mn common.Address, ipv4address uint32, pubkey [2][32]byte) string {
 	cfg := m.backend.ChainConfig()
-	res := energi_common.MastenodeEnode(ipv4address, pubkey, cfg)
+	res := energi_common.MasternodeNodes(m.backend.ChainDb(), mn, ipv4address, pubkey, cfg)
+
+	if len(res) == 0 {
+		return ""
+	}
+
+	return res[0].String()
+}
+
+func (m *MasternodeAPI) enr(mn common.Address, pubkey [2][32]byte) string {
+	res := energi_common.MasternodeENR(m.backend.ChainDb(), mn, pubkey)
 
 	if res == nil {
 		return ""
 	}
 
-	return res.String()
+	return energi_common.MasternodeENRText(res)
 }
 
 func masternodeRegistry(
@@ -438,21 +463,20 @@ func (m *MasternodeAPI) Announce(
 	)
 
 	//---
-	res, err := enode.ParseV4(enode_url)
+	var res *enode.Node
+	is_enr := strings.HasPrefix(enode_url, energi_common.MasternodeENRPrefix)
+	if is_enr {
+		res, err = energi_common.ParseMasternodeENR(enode_url)
+	} else {
+		res, err = enode.ParseV4(enode_url)
+	}
 	if err != nil {
 		return
 	}
 
 	//---
-	ip := res.IP().To4()
-	if ip == nil {
-		err = errors.New("Invalid IPv4")
-		return
-	}
-
-	if ip[0] == byte(127) || ip[0] == byte(10) ||
-		(ip[0] == byte(192) && ip[1] == byte(168)) ||
-		(ip[0] == byte(172) && (ip[1]&0xF0) == byte(16)) {
+	addrs := energi_common.MasternodeAddrs(res)
+	if len(addrs) == 0 {
 		err = errors.New("Wrong enode IP")
 		return
 	}
@@ -464,18 +488,23 @@ func (m *MasternodeAPI) Announce(
 		return
 	}
 
-	ipv4address = uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+	// NOTE: the registry keeps only the first public IPv4 address. Other
+	//       addresses of the masternode are reached through the gossiped
+	//       record, which is the only option for IPv6-only masternodes.
+	for _, addr := range addrs {
+		if ip := addr.To4(); ip != nil {
+			ipv4address = uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+			break
+		}
+	}
 
-	//---
-	pk := crypto.CompressPubkey(res.Pubkey())
-	if len(pk) != 33 {
-		log.Error("Wrong public key length", "pklen", len(pk))
-		err = errors.New("Wrong public key")
-		return
+	if ipv4address == 0 {
+		log.Warn("Announcing without IPv4 address, it requires MasternodeRegistryV3",
+			"addrs", addrs)
 	}
 
-	copy(pubkey[0][:], pk[:32])
-	copy(pubkey[1][:], pk[32:33])
+	//---
+	pubkey = energi_common.MasternodePubkey(res.Pubkey())
 
 	//---
 	masternode := crypto.PubkeyToAddress(*res.Pubkey())
@@ -485,6 +514,18 @@ func (m *MasternodeAPI) Announce(
 
 	if tx != nil {
 		txhash = tx.Hash()
+
+		// The signed record is kept locally until the masternode gossips
+		// its own, but only once the announcement succeeds.
+		var on_success func()
+		if is_enr {
+			db := m.backend.ChainDb()
+			on_success = func() {
+				energi_common.StoreMasternodeENR(db, masternode, res)
+			}
+		}
+
+		m.watcher.trackTx(owner, "announce", txhash, on_success)
 		log.Info("Note: please wait until the TX gets into a block!", "tx", txhash.Hex())
 	}
 
@@ -510,6 +551,7 @@ func (m *MasternodeAPI) Denounce(
 
 	if tx != nil {
 		txhash = tx.Hash()
+		m.watcher.trackTx(owner, "denounce", txhash, nil)
 		log.Info("Note: please wait until the TX gets into a block!", "tx", txhash.Hex())
 	}
 
@@ -25,12 +25,14 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"sync"
 
 	"nuclear/core/nuclear/accounts"
 	"nuclear/core/nuclear/accounts/abi/bind"
 	"nuclear/core/nuclear/accounts/keystore"
 	"nuclear/core/nuclear/common"
 	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/types"
 	"nuclear/core/nuclear/crypto"
 	"nuclear/core/nuclear/log"
 	"nuclear/core/nuclear/rpc"
@@ -57,12 +59,16 @@ type MigrationAPI struct {
 
 	lastCoins   interface{}
 	lastBalance *big.Int
+
+	claimMtx sync.Mutex
+	claimTxs map[uint64]common.Hash
 }
 
 func NewMigrationAPI(b Backend) *MigrationAPI {
 	r := &MigrationAPI{
 		backend:    b,
 		coinsCache: energi_common.NewCacheStorage(),
+		claimTxs:   make(map[uint64]common.Hash),
 	}
 	b.OnSyncedHeadUpdates(func() {
 		r.listGen2Coins()
@@ -82,16 +88,25 @@ type Gen2Key struct {
 	Key      *ecdsa.PrivateKey
 }
 
-func (m *MigrationAPI) ListGen2Coins() (coins []Gen2Coin, err error) {
+func (m *MigrationAPI) ListGen2Coins(block *rpc.BlockNumberOrHash) (coins []Gen2Coin, err error) {
 	if m.backend.IsPublicService() {
 		return nil, errors.New("This API is disabled for security reasons")
 	}
 
-	return m.listGen2Coins()
+	at, err := queryBlock(m.backend, block)
+	if err != nil {
+		return
+	}
+
+	return m.listGen2CoinsAt(at)
 }
 
 func (m *MigrationAPI) listGen2Coins() (coins []Gen2Coin, err error) {
-	data, err := m.coinsCache.Get(m.backend, m.listGen2CoinsUncached)
+	return m.listGen2CoinsAt(nil)
+}
+
+func (m *MigrationAPI) listGen2CoinsAt(at *types.Block) (coins []Gen2Coin, err error) {
+	data, err := m.coinsCache.GetAt(m.backend, at, m.listGen2CoinsUncached)
 	if err != nil || data == nil {
 		log.Error("listGen2Coins failed", "err", err)
 		return
@@ -127,10 +142,7 @@ func (m *MigrationAPI) listGen2CoinsUncached(num *big.Int) (interface{}, error)
 		return nil, err
 	}
 
-	call_opts := &bind.CallOpts{
-		Pending:  true,
-		GasLimit: energi_params.UnlimitedGas,
-	}
+	call_opts := blockCallOpts(m.backend, num)
 	bigItems, err := mgrt_contract.ItemCount(call_opts)
 	if err != nil {
 		log.Error("Failed to get coin count", "err", err)
@@ -324,20 +336,22 @@ func (m *MigrationAPI) loadGen2Dump(file string) (keys []Gen2Key, err error) {
 		return nil, err
 	}
 
-	return m.parseGen2Dump(string(buf[:len])), nil
+	return ParseGen2Dump(string(buf[:len])), nil
 }
 
-func (m *MigrationAPI) parseGen2Dump(data string) (keys []Gen2Key) {
+// ParseGen2Dump extracts private keys from Gen2 "dumpwallet" output.
+// Plain lists of WIF keys, one per line, are accepted as well.
+func ParseGen2Dump(data string) (keys []Gen2Key) {
 	lines := strings.Split(data, "\n")
 	keys = make([]Gen2Key, 0, len(lines))
 
 	for i, l := range lines {
-		lp := strings.Split(l, " ")
-		if len(lp) < 3 || lp[0] == "#" {
+		lp := strings.Fields(l)
+		if len(lp) == 0 || strings.HasPrefix(lp[0], "#") {
 			continue
 		}
 
-		key, err := m.parseGen2Key(lp[0])
+		key, err := ParseGen2Key(lp[0])
 		if err != nil {
 			log.Error("Failed to parse key", "err", err, "line", i)
 			continue
@@ -349,7 +363,8 @@ func (m *MigrationAPI) parseGen2Dump(data string) (keys []Gen2Key) {
 	return
 }
 
-func (m *MigrationAPI) parseGen2Key(tkey string) (*Gen2Key, error) {
+// ParseGen2Key decodes a Gen2 private key in WIF format.
+func ParseGen2Key(tkey string) (*Gen2Key, error) {
 	if len(tkey) != base54PrivateKeyLen {
 		return nil, errors.New("Invalid private key length")
 	}
@@ -383,7 +398,7 @@ func (m *MigrationAPI) ClaimGen2CoinsDirect(
 	dst common.Address,
 	tkey string,
 ) (txhash common.Hash, err error) {
-	key, err := m.parseGen2Key(tkey)
+	key, err := ParseGen2Key(tkey)
 	if err != nil {
 		log.Error("Failed to parse key", "err", err)
 		return
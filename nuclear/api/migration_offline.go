@@ -0,0 +1,360 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"errors"
+	"math/big"
+
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/log"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+// Per-coin claim statuses
+const (
+	Gen2ClaimUnsigned  = "unsigned"
+	Gen2ClaimReady     = "ready"
+	Gen2ClaimInvalid   = "invalid"
+	Gen2ClaimSubmitted = "submitted"
+	Gen2ClaimPending   = "pending"
+	Gen2ClaimFailed    = "failed"
+	Gen2ClaimDropped   = "dropped"
+	Gen2ClaimClaimed   = "claimed"
+)
+
+// Gen2ClaimBundle is a set of Gen2 coins to be claimed to a single destination.
+//
+// The bundle is prepared by an online node, signed offline with Gen2 keys
+// and then submitted back to any online node.
+type Gen2ClaimBundle struct {
+	ChainID     *hexutil.Big
+	Destination common.Address
+	HashToSign  common.Hash
+	Claims      []Gen2Claim
+}
+
+type Gen2Claim struct {
+	ItemID    uint64
+	RawOwner  common.Address
+	Owner     string
+	Amount    *hexutil.Big
+	Signature hexutil.Bytes
+}
+
+type Gen2ClaimStatus struct {
+	ItemID uint64
+	Owner  string
+	Amount *hexutil.Big
+	Status string
+	TxHash *common.Hash
+	Error  string
+}
+
+// PrepareGen2Claim creates an unsigned claim bundle of all non-empty
+// coins of the specified Gen2 owners.
+func (m *MigrationAPI) PrepareGen2Claim(
+	owners []string,
+	dst common.Address,
+) (*Gen2ClaimBundle, error) {
+	coins, err := m.SearchGen2Coins(owners, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(coins) == 0 {
+		return nil, errors.New("No coins found")
+	}
+
+	mgrt_contract, err := energi_abi.NewGen2MigrationCaller(
+		energi_params.Nuclear_MigrationContract, m.backend.(bind.ContractCaller))
+	if err != nil {
+		log.Error("Failed to create contract face", "err", err)
+		return nil, err
+	}
+
+	call_opts := &bind.CallOpts{
+		Pending:  true,
+		GasLimit: energi_params.UnlimitedGas,
+	}
+
+	hts, err := mgrt_contract.HashToSign(call_opts, dst)
+	if err != nil {
+		log.Error("Failed to get hash to sign", "err", err)
+		return nil, err
+	}
+
+	bundle := &Gen2ClaimBundle{
+		ChainID:     (*hexutil.Big)(m.backend.ChainConfig().ChainID),
+		Destination: dst,
+		HashToSign:  hts,
+		Claims:      make([]Gen2Claim, len(coins)),
+	}
+
+	for i, c := range coins {
+		bundle.Claims[i] = Gen2Claim{
+			ItemID:   c.ItemID,
+			RawOwner: c.RawOwner,
+			Owner:    c.Owner,
+			Amount:   c.Amount,
+		}
+	}
+
+	return bundle, nil
+}
+
+// Gen2HashToSign calculates the claim hash the same way as
+// Gen2Migration.hashToSign() does.
+func Gen2HashToSign(dst common.Address, chain_id *big.Int) common.Hash {
+	return crypto.Keccak256Hash(
+		dst.Bytes(),
+		[]byte("||Nuclear Gen 2 migration claim||"),
+		common.LeftPadBytes(chain_id.Bytes(), 32),
+	)
+}
+
+// SignGen2Claim signs claims of the bundle which have a matching key.
+// It does not require any chain access and is meant for offline use.
+//
+// NOTE: the hash is always calculated locally from the destination and the
+// chain ID. The bundle hash is only cross-checked.
+func SignGen2Claim(bundle *Gen2ClaimBundle, keys []Gen2Key) (signed int, err error) {
+	if bundle.ChainID == nil {
+		return 0, errors.New("Missing chain ID")
+	}
+
+	hts := Gen2HashToSign(bundle.Destination, bundle.ChainID.ToInt())
+	if bundle.HashToSign != hts {
+		return 0, errors.New("Hash to sign mismatch")
+	}
+
+	owner2key := make(map[common.Address]*Gen2Key, len(keys))
+	for i, k := range keys {
+		owner2key[k.RawOwner] = &keys[i]
+	}
+
+	for i := range bundle.Claims {
+		c := &bundle.Claims[i]
+
+		key, ok := owner2key[c.RawOwner]
+		if !ok {
+			continue
+		}
+
+		sig, err := crypto.Sign(hts[:], key.Key)
+		if err != nil {
+			return signed, err
+		}
+
+		c.Signature = sig
+		signed++
+	}
+
+	return signed, nil
+}
+
+// SubmitGen2Claim sends signed claims of the bundle as zero-fee transactions.
+//
+// NOTE: an ephemeral sender key is used as zero-fee claims require neither
+// balance nor the destination account to be available on this node.
+func (m *MigrationAPI) SubmitGen2Claim(
+	bundle *Gen2ClaimBundle,
+) (statuses []Gen2ClaimStatus, err error) {
+	chain_id := m.backend.ChainConfig().ChainID
+	if bundle.ChainID == nil || bundle.ChainID.ToInt().Cmp(chain_id) != 0 {
+		return nil, errors.New("Chain ID mismatch")
+	}
+
+	mgrt_contract_obj, err := energi_abi.NewGen2Migration(
+		energi_params.Nuclear_MigrationContract, m.backend.(bind.ContractBackend))
+	if err != nil {
+		log.Error("Failed to create contract face", "err", err)
+		return nil, err
+	}
+
+	mgrt_contract := energi_abi.Gen2MigrationSession{
+		Contract: mgrt_contract_obj,
+		CallOpts: bind.CallOpts{
+			Pending:  true,
+			GasLimit: energi_params.UnlimitedGas,
+		},
+	}
+
+	hts, err := mgrt_contract.HashToSign(bundle.Destination)
+	if err != nil {
+		log.Error("Failed to get hash to sign", "err", err)
+		return nil, err
+	}
+
+	if hts != bundle.HashToSign {
+		return nil, errors.New("Hash to sign mismatch")
+	}
+
+	sender_key, err := crypto.GenerateKey()
+	if err != nil {
+		log.Error("Failed to generate sender key", "err", err)
+		return nil, err
+	}
+
+	sender := crypto.PubkeyToAddress(sender_key.PublicKey)
+	mgrt_contract.TransactOpts = bind.TransactOpts{
+		From: sender,
+		Signer: func(
+			signer types.Signer,
+			addr common.Address,
+			tx *types.Transaction,
+		) (*types.Transaction, error) {
+			return types.SignTx(tx, types.NewEIP155Signer(chain_id), sender_key)
+		},
+		Value:    common.Big0,
+		GasPrice: common.Big0,
+		GasLimit: migrationGas,
+	}
+
+	statuses = make([]Gen2ClaimStatus, len(bundle.Claims))
+
+	for i, c := range bundle.Claims {
+		st := &statuses[i]
+		st.ItemID = c.ItemID
+		st.Owner = c.Owner
+		st.Amount = c.Amount
+
+		if len(c.Signature) == 0 {
+			st.Status = Gen2ClaimUnsigned
+			continue
+		}
+
+		item := new(big.Int).SetUint64(c.ItemID)
+		v, r, s, err := splitGen2Signature(c.Signature)
+		if err != nil {
+			st.Status = Gen2ClaimInvalid
+			st.Error = err.Error()
+			continue
+		}
+
+		amt, err := mgrt_contract.VerifyClaim(item, bundle.Destination, v, r, s)
+		if err != nil {
+			st.Status = Gen2ClaimInvalid
+			st.Error = err.Error()
+			continue
+		}
+
+		if amt.Cmp(common.Big0) == 0 {
+			st.Status = Gen2ClaimClaimed
+			continue
+		}
+
+		tx, err := mgrt_contract.Claim(item, bundle.Destination, v, r, s)
+		if err != nil {
+			log.Error("Failed to send claim", "item", c.ItemID, "err", err)
+			st.Status = Gen2ClaimFailed
+			st.Error = err.Error()
+			continue
+		}
+
+		txhash := tx.Hash()
+		st.Status = Gen2ClaimSubmitted
+		st.TxHash = &txhash
+
+		m.claimMtx.Lock()
+		m.claimTxs[c.ItemID] = txhash
+		m.claimMtx.Unlock()
+
+		log.Info("Sent migration transaction", "tx", txhash.Hex(), "coins", c.Owner)
+	}
+
+	return statuses, nil
+}
+
+// CheckGen2Claim reports the current per-coin status of the bundle.
+func (m *MigrationAPI) CheckGen2Claim(
+	bundle *Gen2ClaimBundle,
+) (statuses []Gen2ClaimStatus, err error) {
+	mgrt_contract, err := energi_abi.NewGen2MigrationCaller(
+		energi_params.Nuclear_MigrationContract, m.backend.(bind.ContractCaller))
+	if err != nil {
+		log.Error("Failed to create contract face", "err", err)
+		return nil, err
+	}
+
+	call_opts := &bind.CallOpts{
+		Pending:  true,
+		GasLimit: energi_params.UnlimitedGas,
+	}
+
+	statuses = make([]Gen2ClaimStatus, len(bundle.Claims))
+
+	for i, c := range bundle.Claims {
+		st := &statuses[i]
+		st.ItemID = c.ItemID
+		st.Owner = c.Owner
+		st.Amount = c.Amount
+
+		coin, err := mgrt_contract.Coins(call_opts, new(big.Int).SetUint64(c.ItemID))
+		if err != nil {
+			log.Error("Failed to get coin info", "err", err)
+			return nil, err
+		}
+
+		m.claimMtx.Lock()
+		txhash, tracked := m.claimTxs[c.ItemID]
+		m.claimMtx.Unlock()
+
+		if tracked {
+			st.TxHash = &txhash
+		}
+
+		switch {
+		case coin.Amount.Cmp(common.Big0) == 0:
+			st.Status = Gen2ClaimClaimed
+		case tracked && m.backend.GetPoolTransaction(txhash) != nil:
+			st.Status = Gen2ClaimPending
+		case tracked:
+			if tx, _, _, _ := rawdb.ReadTransaction(m.backend.ChainDb(), txhash); tx != nil {
+				st.Status = Gen2ClaimFailed
+			} else {
+				st.Status = Gen2ClaimDropped
+			}
+		case len(c.Signature) == 0:
+			st.Status = Gen2ClaimUnsigned
+		default:
+			st.Status = Gen2ClaimReady
+		}
+	}
+
+	return statuses, nil
+}
+
+func splitGen2Signature(sig []byte) (v uint8, r, s [32]byte, err error) {
+	if len(sig) != 65 {
+		err = errors.New("Wrong signature size")
+		return
+	}
+
+	copy(r[:], sig[:32])
+	copy(s[:], sig[32:64])
+	v = uint8(sig[64])
+
+	return
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/crypto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignGen2Claim(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	assert.Empty(t, err)
+
+	owner := common.HexToAddress("0x1234")
+	bundle := &Gen2ClaimBundle{
+		Destination: common.HexToAddress("0x5678"),
+		Claims: []Gen2Claim{
+			{ItemID: 1, RawOwner: owner},
+			{ItemID: 2, RawOwner: common.HexToAddress("0x4321")},
+		},
+	}
+	keys := []Gen2Key{{RawOwner: owner, Key: key}}
+
+	_, err = SignGen2Claim(bundle, keys)
+	assert.Equal(t, "Missing chain ID", err.Error())
+
+	bundle.ChainID = (*hexutil.Big)(big.NewInt(49797))
+	bundle.HashToSign = common.HexToHash("0xabcdef")
+	_, err = SignGen2Claim(bundle, keys)
+	assert.Equal(t, "Hash to sign mismatch", err.Error())
+	assert.Empty(t, bundle.Claims[0].Signature)
+
+	bundle.HashToSign = crypto.Keccak256Hash(
+		common.HexToAddress("0x5678").Bytes(),
+		[]byte("||Nuclear Gen 2 migration claim||"),
+		common.LeftPadBytes(big.NewInt(49797).Bytes(), 32),
+	)
+	assert.Equal(t, bundle.HashToSign, Gen2HashToSign(bundle.Destination, big.NewInt(49797)))
+	signed, err := SignGen2Claim(bundle, keys)
+	assert.Empty(t, err)
+	assert.Equal(t, 1, signed)
+	assert.Empty(t, bundle.Claims[1].Signature)
+
+	pubkey, err := crypto.SigToPub(bundle.HashToSign[:], bundle.Claims[0].Signature)
+	assert.Empty(t, err)
+	assert.Equal(t, key.PublicKey, *pubkey)
+
+	v, r, s, err := splitGen2Signature(bundle.Claims[0].Signature)
+	assert.Empty(t, err)
+	assert.Equal(t, bundle.Claims[0].Signature[64], v)
+	assert.Equal(t, []byte(bundle.Claims[0].Signature[:32]), r[:])
+	assert.Equal(t, []byte(bundle.Claims[0].Signature[32:64]), s[:])
+
+	_, _, _, err = splitGen2Signature(bundle.Claims[0].Signature[:64])
+	assert.Equal(t, "Wrong signature size", err.Error())
+}
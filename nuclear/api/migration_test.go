@@ -42,8 +42,7 @@ func TestGen2Parse(t *testing.T) {
 	t.Parallel()
 	log.Root().SetHandler(log.StdoutHandler)
 
-	m := NewMigrationAPI(nil)
-	res := m.parseGen2Dump(testWalletDump)
+	res := ParseGen2Dump(testWalletDump)
 	assert.Equal(t, 2, len(res))
 	assert.Equal(t,
 		"0xC94729d0212C2D1074d858EB6c9ee44Fb19D76e6",
@@ -0,0 +1,642 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/event"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rpc"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+// Masternode lifecycle event kinds
+const (
+	MNEventTxIncluded  = "tx-included"
+	MNEventTxFailed    = "tx-failed"
+	MNEventTxDropped   = "tx-dropped"
+	MNEventAnnounced   = "announced"
+	MNEventDenounced   = "denounced"
+	MNEventCollateral  = "collateral"
+	MNEventActivated   = "activated"
+	MNEventDeactivated = "deactivated"
+	MNEventInvalidated = "invalidated"
+)
+
+const (
+	// mnWatchDropBlocks is the number of blocks a tracked transaction may
+	// be missing from both the pool and the chain before considered dropped.
+	mnWatchDropBlocks uint64 = 10
+	mnWatchEventLimit int    = 100
+	mnWatchOwnerLimit int    = 100
+	mnWebhookLimit    int    = 8
+	mnWebhookTimeout         = 10 * time.Second
+)
+
+var mnInvalidationID common.Hash
+
+func init() {
+	mnreg_abi, err := abi.JSON(strings.NewReader(energi_abi.IMasternodeRegistryV2ABI))
+	if err != nil {
+		panic(err)
+	}
+
+	mnInvalidationID = mnreg_abi.Events["Invalidation"].Id()
+}
+
+type MasternodeEvent struct {
+	Kind       string
+	Owner      common.Address
+	Masternode common.Address
+	Block      uint64
+	TxHash     *common.Hash `json:",omitempty"`
+	Action     string       `json:",omitempty"`
+	Collateral *hexutil.Big `json:",omitempty"`
+	Balance    *hexutil.Big `json:",omitempty"`
+	IsActive   bool
+}
+
+// MasternodeNotifier is a pluggable sink of masternode lifecycle events.
+type MasternodeNotifier interface {
+	Notify(ev *MasternodeEvent)
+}
+
+// mnOwnerState is the last known registry state of a watched owner.
+type mnOwnerState struct {
+	Masternode common.Address
+	Announced  bool
+	IsActive   bool
+	Collateral *big.Int
+	Balance    *big.Int
+}
+
+type mnWatchTx struct {
+	owner     common.Address
+	action    string
+	sentAt    uint64
+	onSuccess func()
+}
+
+// MasternodeWatcher follows owner transactions to inclusion and tracks
+// the resulting masternode registry state.
+type MasternodeWatcher struct {
+	backend Backend
+
+	mtx       sync.Mutex
+	owners    map[common.Address]*mnOwnerState
+	txs       map[common.Hash]*mnWatchTx
+	notifiers []MasternodeNotifier
+	webhooks  map[string]*WebhookNotifier
+	events    []MasternodeEvent
+	stopCh    chan struct{}
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+}
+
+func newMasternodeWatcher(b Backend) *MasternodeWatcher {
+	return &MasternodeWatcher{
+		backend:  b,
+		owners:   make(map[common.Address]*mnOwnerState),
+		txs:      make(map[common.Hash]*mnWatchTx),
+		webhooks: make(map[string]*WebhookNotifier),
+	}
+}
+
+// AddNotifier registers an additional event sink.
+func (w *MasternodeWatcher) AddNotifier(n MasternodeNotifier) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	w.notifiers = append(w.notifiers, n)
+}
+
+// SubscribeEvents subscribes to all masternode lifecycle events.
+func (w *MasternodeWatcher) SubscribeEvents(ch chan<- *MasternodeEvent) event.Subscription {
+	return w.scope.Track(w.feed.Subscribe(ch))
+}
+
+func (w *MasternodeWatcher) addWebhook(url string) error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if _, ok := w.webhooks[url]; ok {
+		return errors.New("Webhook already exists")
+	}
+
+	if len(w.webhooks) >= mnWebhookLimit {
+		return errors.New("Too many webhooks")
+	}
+
+	w.webhooks[url] = NewWebhookNotifier(url)
+	return nil
+}
+
+func (w *MasternodeWatcher) removeWebhook(url string) bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if _, ok := w.webhooks[url]; !ok {
+		return false
+	}
+
+	delete(w.webhooks, url)
+	return true
+}
+
+func (w *MasternodeWatcher) listWebhooks() []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	res := make([]string, 0, len(w.webhooks))
+	for url := range w.webhooks {
+		res = append(res, url)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func (w *MasternodeWatcher) watch(owner common.Address) error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if _, ok := w.owners[owner]; !ok {
+		if len(w.owners) >= mnWatchOwnerLimit {
+			return errors.New("Too many watched owners")
+		}
+
+		w.owners[owner] = nil
+	}
+
+	w.startLocked()
+	return nil
+}
+
+func (w *MasternodeWatcher) unwatch(owner common.Address) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	delete(w.owners, owner)
+
+	for h, tx := range w.txs {
+		if tx.owner == owner {
+			delete(w.txs, h)
+		}
+	}
+
+	if len(w.owners) == 0 && w.stopCh != nil {
+		close(w.stopCh)
+		w.stopCh = nil
+	}
+}
+
+// trackTx starts following the transaction of the owner. The optional
+// callback is called once the transaction succeeds.
+func (w *MasternodeWatcher) trackTx(
+	owner common.Address,
+	action string,
+	txhash common.Hash,
+	onSuccess func(),
+) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if _, ok := w.owners[owner]; !ok {
+		w.owners[owner] = nil
+	}
+
+	w.txs[txhash] = &mnWatchTx{
+		owner:     owner,
+		action:    action,
+		sentAt:    w.backend.CurrentBlock().NumberU64(),
+		onSuccess: onSuccess,
+	}
+
+	w.startLocked()
+}
+
+func (w *MasternodeWatcher) recentEvents(owner *common.Address) []MasternodeEvent {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	res := make([]MasternodeEvent, 0, len(w.events))
+	for _, ev := range w.events {
+		if owner == nil || ev.Owner == *owner {
+			res = append(res, ev)
+		}
+	}
+
+	return res
+}
+
+func (w *MasternodeWatcher) startLocked() {
+	if w.stopCh != nil {
+		return
+	}
+
+	w.stopCh = make(chan struct{})
+	go w.loop(w.stopCh)
+}
+
+func (w *MasternodeWatcher) loop(stopCh chan struct{}) {
+	headCh := make(chan core.ChainHeadEvent, 8)
+	headSub := w.backend.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	// Initial state to have a baseline
+	w.onHead(w.backend.CurrentBlock().Header())
+
+	for {
+		select {
+		case ev := <-headCh:
+			w.onHead(ev.Block.Header())
+		case <-stopCh:
+			return
+		case <-headSub.Err():
+			return
+		}
+	}
+}
+
+func (w *MasternodeWatcher) onHead(header *types.Header) {
+	w.mtx.Lock()
+	owners := make([]common.Address, 0, len(w.owners))
+	for o := range w.owners {
+		owners = append(owners, o)
+	}
+	w.mtx.Unlock()
+
+	w.checkTxs(header)
+
+	for _, owner := range owners {
+		state, err := w.ownerState(header, owner)
+		if err != nil {
+			log.Debug("Failed to get masternode state", "owner", owner, "err", err)
+			continue
+		}
+
+		w.mtx.Lock()
+		prev, watched := w.owners[owner]
+		if watched {
+			w.owners[owner] = state
+		}
+		w.mtx.Unlock()
+
+		if !watched {
+			continue
+		}
+
+		for _, kind := range diffMasternodeState(prev, state) {
+			w.emit(&MasternodeEvent{
+				Kind:       kind,
+				Owner:      owner,
+				Masternode: state.Masternode,
+				Block:      header.Number.Uint64(),
+				Collateral: (*hexutil.Big)(state.Collateral),
+				Balance:    (*hexutil.Big)(state.Balance),
+				IsActive:   state.IsActive,
+			})
+		}
+
+		if state.Announced && w.isInvalidated(header, state.Masternode) {
+			w.emit(&MasternodeEvent{
+				Kind:       MNEventInvalidated,
+				Owner:      owner,
+				Masternode: state.Masternode,
+				Block:      header.Number.Uint64(),
+				Collateral: (*hexutil.Big)(state.Collateral),
+				Balance:    (*hexutil.Big)(state.Balance),
+				IsActive:   state.IsActive,
+			})
+		}
+	}
+}
+
+// checkTxs reports inclusion status of tracked transactions.
+func (w *MasternodeWatcher) checkTxs(header *types.Header) {
+	w.mtx.Lock()
+	txs := make(map[common.Hash]mnWatchTx, len(w.txs))
+	for h, tx := range w.txs {
+		txs[h] = *tx
+	}
+	w.mtx.Unlock()
+
+	num := header.Number.Uint64()
+
+	for txhash, tx := range txs {
+		txhash := txhash
+		ev := &MasternodeEvent{
+			Owner:  tx.owner,
+			Block:  num,
+			TxHash: &txhash,
+			Action: tx.action,
+		}
+
+		receipt, _, block_num, _ := rawdb.ReadReceipt(w.backend.ChainDb(), txhash)
+
+		switch {
+		case receipt != nil && receipt.Status == types.ReceiptStatusSuccessful:
+			ev.Kind = MNEventTxIncluded
+			ev.Block = block_num
+
+			if tx.onSuccess != nil {
+				tx.onSuccess()
+			}
+		case receipt != nil:
+			ev.Kind = MNEventTxFailed
+			ev.Block = block_num
+		case w.backend.GetPoolTransaction(txhash) != nil:
+			continue
+		case num < tx.sentAt+mnWatchDropBlocks:
+			continue
+		default:
+			ev.Kind = MNEventTxDropped
+		}
+
+		w.mtx.Lock()
+		delete(w.txs, txhash)
+		w.mtx.Unlock()
+
+		w.emit(ev)
+	}
+}
+
+func (w *MasternodeWatcher) ownerState(
+	header *types.Header,
+	owner common.Address,
+) (*mnOwnerState, error) {
+	call_opts := &bind.CallOpts{
+		BlockNumber: header.Number,
+		GasLimit:    energi_params.UnlimitedGas,
+	}
+
+	registry, err := energi_abi.NewIMasternodeRegistryV2Caller(
+		energi_params.Nuclear_MasternodeRegistry, w.backend.(bind.ContractCaller))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := energi_abi.NewIMasternodeTokenCaller(
+		energi_params.Nuclear_MasternodeToken, w.backend.(bind.ContractCaller))
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := token.BalanceOf(call_opts, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &mnOwnerState{
+		Collateral: new(big.Int),
+		Balance:    balance,
+	}
+
+	owner_registry, err := energi_abi.NewIMasternodeRegistryV2Caller(
+		energi_params.Nuclear_MasternodeRegistry,
+		energi_common.RevertCaller{ContractCaller: w.backend.(bind.ContractCaller)})
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: the call reverts for owners without announced masternode
+	info, err := owner_registry.OwnerInfo(call_opts, owner)
+	if err == energi_common.ErrReverted {
+		return res, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	res.Masternode = info.Masternode
+	res.Announced = true
+	res.Collateral = info.Collateral
+
+	res.IsActive, err = registry.IsActive(call_opts, info.Masternode)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (w *MasternodeWatcher) isInvalidated(header *types.Header, mn common.Address) bool {
+	if !types.BloomLookup(header.Bloom, mnInvalidationID) ||
+		!types.BloomLookup(header.Bloom, mn.Hash()) {
+		return false
+	}
+
+	receipts, err := w.backend.GetReceipts(context.Background(), header.Hash())
+	if err != nil {
+		log.Error("Failed to get receipts", "block", header.Hash(), "err", err)
+		return false
+	}
+
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			if len(l.Topics) > 1 &&
+				l.Topics[0] == mnInvalidationID &&
+				l.Topics[1] == mn.Hash() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (w *MasternodeWatcher) emit(ev *MasternodeEvent) {
+	log.Info("Masternode event", "kind", ev.Kind, "owner", ev.Owner, "block", ev.Block)
+
+	w.mtx.Lock()
+	w.events = append(w.events, *ev)
+	if len(w.events) > mnWatchEventLimit {
+		w.events = w.events[len(w.events)-mnWatchEventLimit:]
+	}
+	notifiers := make([]MasternodeNotifier, 0, len(w.notifiers)+len(w.webhooks))
+	notifiers = append(notifiers, w.notifiers...)
+	for _, n := range w.webhooks {
+		notifiers = append(notifiers, n)
+	}
+	w.mtx.Unlock()
+
+	for _, n := range notifiers {
+		n.Notify(ev)
+	}
+
+	w.feed.Send(ev)
+}
+
+// diffMasternodeState returns event kinds of the state transition.
+// Nothing is reported for the initial state.
+func diffMasternodeState(prev, curr *mnOwnerState) (kinds []string) {
+	if prev == nil || curr == nil {
+		return
+	}
+
+	if !prev.Announced && curr.Announced {
+		kinds = append(kinds, MNEventAnnounced)
+	} else if prev.Announced && !curr.Announced {
+		kinds = append(kinds, MNEventDenounced)
+	}
+
+	if prev.Balance.Cmp(curr.Balance) != 0 || prev.Collateral.Cmp(curr.Collateral) != 0 {
+		kinds = append(kinds, MNEventCollateral)
+	}
+
+	if !prev.IsActive && curr.IsActive {
+		kinds = append(kinds, MNEventActivated)
+	} else if prev.IsActive && !curr.IsActive {
+		kinds = append(kinds, MNEventDeactivated)
+	}
+
+	return
+}
+
+//=============================================================================
+
+// WebhookNotifier posts masternode events as JSON to the configured URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: mnWebhookTimeout},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ev *MasternodeEvent) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Error("Failed to encode masternode event", "err", err)
+		return
+	}
+
+	go func() {
+		resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			log.Warn("Failed to send masternode event", "url", n.url, "err", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode/100 != 2 {
+			log.Warn("Masternode event is rejected", "url", n.url, "status", resp.Status)
+		}
+	}()
+}
+
+//=============================================================================
+
+// MasternodeEvents returns recent lifecycle events, optionally of a single owner.
+func (m *MasternodeAPI) MasternodeEvents(owner *common.Address) []MasternodeEvent {
+	return m.watcher.recentEvents(owner)
+}
+
+// Lifecycle is a subscription to masternode lifecycle events.
+func (m *MasternodeAPI) Lifecycle(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *MasternodeEvent, 16)
+		sub := m.watcher.SubscribeEvents(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+//=============================================================================
+
+// MasternodeAdminAPI controls masternode lifecycle tracking. It makes the
+// node poll the chain and do outgoing requests, so it is not public.
+type MasternodeAdminAPI struct {
+	watcher *MasternodeWatcher
+}
+
+func NewMasternodeAdminAPI(m *MasternodeAPI) *MasternodeAdminAPI {
+	return &MasternodeAdminAPI{m.watcher}
+}
+
+// WatchMasternode starts tracking lifecycle of the owner's masternode.
+func (a *MasternodeAdminAPI) WatchMasternode(owner common.Address) error {
+	return a.watcher.watch(owner)
+}
+
+// UnwatchMasternode stops tracking of the owner.
+func (a *MasternodeAdminAPI) UnwatchMasternode(owner common.Address) {
+	a.watcher.unwatch(owner)
+}
+
+// AddWebhook sends all further lifecycle events to the URL.
+func (a *MasternodeAdminAPI) AddWebhook(url string) error {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return errors.New("Invalid webhook URL")
+	}
+
+	return a.watcher.addWebhook(url)
+}
+
+// RemoveWebhook stops sending events to the URL.
+func (a *MasternodeAdminAPI) RemoveWebhook(url string) bool {
+	return a.watcher.removeWebhook(url)
+}
+
+// Webhooks lists URLs of the registered webhooks.
+func (a *MasternodeAdminAPI) Webhooks() []string {
+	return a.watcher.listWebhooks()
+}
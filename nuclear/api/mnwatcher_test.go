@@ -0,0 +1,170 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ethereum "nuclear/core/nuclear"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/crypto"
+
+	"github.com/stretchr/testify/assert"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func TestDiffMasternodeState(t *testing.T) {
+	t.Parallel()
+
+	empty := &mnOwnerState{
+		Collateral: big.NewInt(0),
+		Balance:    big.NewInt(0),
+	}
+	deposited := &mnOwnerState{
+		Collateral: big.NewInt(0),
+		Balance:    big.NewInt(1000),
+	}
+	announced := &mnOwnerState{
+		Masternode: common.HexToAddress("0x1234"),
+		Announced:  true,
+		IsActive:   true,
+		Collateral: big.NewInt(1000),
+		Balance:    big.NewInt(1000),
+	}
+	inactive := &mnOwnerState{
+		Masternode: common.HexToAddress("0x1234"),
+		Announced:  true,
+		Collateral: big.NewInt(1000),
+		Balance:    big.NewInt(1000),
+	}
+
+	assert.Empty(t, diffMasternodeState(nil, empty))
+	assert.Empty(t, diffMasternodeState(empty, empty))
+	assert.Equal(t, []string{MNEventCollateral}, diffMasternodeState(empty, deposited))
+	assert.Equal(t, []string{MNEventAnnounced, MNEventCollateral, MNEventActivated},
+		diffMasternodeState(deposited, announced))
+	assert.Equal(t, []string{MNEventDeactivated}, diffMasternodeState(announced, inactive))
+	assert.Equal(t, []string{MNEventDenounced, MNEventCollateral},
+		diffMasternodeState(inactive, deposited))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan MasternodeEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev MasternodeEvent
+		assert.Empty(t, json.NewDecoder(r.Body).Decode(&ev))
+		received <- ev
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(srv.URL)
+	n.Notify(&MasternodeEvent{
+		Kind:  MNEventDeactivated,
+		Owner: common.HexToAddress("0x1234"),
+		Block: 10,
+	})
+
+	select {
+	case ev := <-received:
+		assert.Equal(t, MNEventDeactivated, ev.Kind)
+		assert.Equal(t, common.HexToAddress("0x1234"), ev.Owner)
+		assert.Equal(t, uint64(10), ev.Block)
+	case <-time.After(5 * time.Second):
+		t.Error("Webhook timeout")
+	}
+}
+
+func TestMasternodeWatcherLimits(t *testing.T) {
+	t.Parallel()
+
+	w := newMasternodeWatcher(nil)
+	api := &MasternodeAdminAPI{w}
+
+	assert.Equal(t, "Invalid webhook URL", api.AddWebhook("ftp://localhost").Error())
+
+	for i := 0; i < mnWebhookLimit; i++ {
+		assert.Empty(t, api.AddWebhook(fmt.Sprintf("http://localhost:%d/", 8000+i)))
+	}
+	assert.Equal(t, "Webhook already exists", api.AddWebhook("http://localhost:8000/").Error())
+	assert.Equal(t, "Too many webhooks", api.AddWebhook("http://localhost:9000/").Error())
+	assert.Len(t, api.Webhooks(), mnWebhookLimit)
+
+	assert.True(t, api.RemoveWebhook("http://localhost:8000/"))
+	assert.False(t, api.RemoveWebhook("http://localhost:8000/"))
+	assert.Empty(t, api.AddWebhook("http://localhost:9000/"))
+	assert.Equal(t, "http://localhost:9000/", api.Webhooks()[mnWebhookLimit-1])
+
+	for i := 0; i < mnWatchOwnerLimit; i++ {
+		w.owners[common.BigToAddress(big.NewInt(int64(i+1)))] = nil
+	}
+	assert.Equal(t, "Too many watched owners",
+		api.WatchMasternode(common.HexToAddress("0x1234567")).Error())
+}
+
+type ownerStateBackend struct {
+	Backend
+	registryOutput []byte
+	registryErr    error
+}
+
+func (b *ownerStateBackend) CodeAt(
+	ctx context.Context, contract common.Address, number *big.Int,
+) ([]byte, error) {
+	return []byte{0}, nil
+}
+
+func (b *ownerStateBackend) CallContract(
+	ctx context.Context, msg ethereum.CallMsg, number *big.Int,
+) ([]byte, error) {
+	if *msg.To == energi_params.Nuclear_MasternodeRegistry {
+		return b.registryOutput, b.registryErr
+	}
+	return common.LeftPadBytes(big.NewInt(1000).Bytes(), 32), nil
+}
+
+func TestMasternodeWatcherOwnerState(t *testing.T) {
+	t.Parallel()
+
+	header := &types.Header{Number: big.NewInt(10)}
+	owner := common.HexToAddress("0x1234567")
+
+	// The registry reverts for owners without announced masternode
+	reason := append(crypto.Keccak256([]byte("Error(string)"))[:4], make([]byte, 96)...)
+	b := &ownerStateBackend{registryOutput: reason}
+	state, err := newMasternodeWatcher(b).ownerState(header, owner)
+	assert.Empty(t, err)
+	assert.False(t, state.Announced)
+	assert.Equal(t, big.NewInt(1000), state.Balance)
+
+	// Other failures must not look like a denounced masternode
+	b = &ownerStateBackend{registryErr: errors.New("missing trie node")}
+	state, err = newMasternodeWatcher(b).ownerState(header, owner)
+	assert.Error(t, err)
+	assert.Nil(t, state)
+}
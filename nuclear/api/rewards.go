@@ -0,0 +1,88 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"errors"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/rpc"
+)
+
+type RewardsAPI struct {
+	backend Backend
+}
+
+func NewRewardsAPI(b Backend) *RewardsAPI {
+	return &RewardsAPI{b}
+}
+
+type BlockRewardItem struct {
+	Recipient common.Address `json:"recipient"`
+	Category  string         `json:"category"`
+	Amount    *hexutil.Big   `json:"amount"`
+}
+
+type BlockRewardsInfo struct {
+	Number  uint64            `json:"number"`
+	Hash    common.Hash       `json:"hash"`
+	Total   *hexutil.Big      `json:"total"`
+	Rewards []BlockRewardItem `json:"rewards"`
+}
+
+// NewBlockRewardItems converts reward breakdown into RPC representation.
+func NewBlockRewardItems(rewards types.BlockRewards) []BlockRewardItem {
+	res := make([]BlockRewardItem, len(rewards))
+	for i, r := range rewards {
+		res[i] = BlockRewardItem{
+			Recipient: r.Recipient,
+			Category:  r.Category,
+			Amount:    (*hexutil.Big)(r.Amount),
+		}
+	}
+	return res
+}
+
+// GetBlockRewards returns itemised consensus reward of the block.
+//
+// NOTE: the breakdown is recorded only for blocks processed by this node,
+// but not for fast synced ones.
+func (r *RewardsAPI) GetBlockRewards(blockNr rpc.BlockNumber) (*BlockRewardsInfo, error) {
+	header, err := r.backend.HeaderByNumber(context.Background(), blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("Unknown block")
+	}
+
+	hash := header.Hash()
+	rewards := r.backend.BlockChain().GetBlockRewards(hash, header.Number.Uint64())
+	if rewards == nil {
+		return nil, errors.New("Block rewards are not available")
+	}
+
+	return &BlockRewardsInfo{
+		Number:  header.Number.Uint64(),
+		Hash:    hash,
+		Total:   (*hexutil.Big)(rewards.Total()),
+		Rewards: NewBlockRewardItems(rewards),
+	}, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"math/big"
+
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rpc"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_consensus "nuclear/core/nuclear/energi/consensus"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+//=============================================================================
+// Spork API
+//=============================================================================
+
+type SporkValues struct {
+	CallGas uint64
+	XferGas uint64
+}
+
+type SporkInfo struct {
+	Impl      common.Address
+	OnChain   SporkValues
+	Active    SporkValues
+	Override  bool
+	Proposals []UpgradeProposalInfo
+}
+
+func (g *GovernanceAPI) SporkInfo(block *rpc.BlockNumberOrHash) (*SporkInfo, error) {
+	at, err := queryBlock(g.backend, block)
+	if err != nil {
+		log.Error("SporkInfo failed", "err", err)
+		return nil, err
+	}
+
+	data, err := g.sInfoCache.GetAt(g.backend, at, g.sporkInfo)
+	if err != nil || data == nil {
+		log.Error("SporkInfo failed", "err", err)
+		return nil, err
+	}
+
+	return data.(*SporkInfo), nil
+}
+
+func (g *GovernanceAPI) sporkInfo(num *big.Int) (interface{}, error) {
+	call_opts := blockCallOpts(g.backend, num)
+
+	proxy_obj, err := energi_abi.NewIGovernedProxyCaller(
+		energi_params.Nuclear_SporkRegistry, g.backend.(bind.ContractCaller))
+	if err != nil {
+		log.Error("Failed NewIGovernedProxyCaller", "err", err)
+		return nil, err
+	}
+
+	impl, err := proxy_obj.Impl(call_opts)
+	if err != nil {
+		log.Error("Failed Impl", "err", err)
+		return nil, err
+	}
+
+	registry, err := energi_abi.NewISporkRegistryCaller(
+		energi_params.Nuclear_SporkRegistry, g.backend.(bind.ContractCaller))
+	if err != nil {
+		log.Error("Failed NewISporkRegistryCaller", "err", err)
+		return nil, err
+	}
+
+	limits, err := registry.ConsensusGasLimits(call_opts)
+	if err != nil {
+		log.Error("Failed ConsensusGasLimits", "err", err)
+		return nil, err
+	}
+
+	ret := &SporkInfo{
+		Impl: impl,
+		OnChain: SporkValues{
+			CallGas: limits.CallGas.Uint64(),
+			XferGas: limits.XferGas.Uint64(),
+		},
+	}
+
+	// The local overrides take precedence in the consensus engine
+	var overrides *energi_consensus.SporkOverrides
+	if engine, ok := g.backend.BlockChain().Engine().(*energi_consensus.Nuclear); ok {
+		overrides = engine.SporkOverrides()
+	}
+	ret.Override = overrides != nil
+	ret.Active.CallGas, ret.Active.XferGas = overrides.Apply(
+		ret.OnChain.CallGas, ret.OnChain.XferGas)
+
+	ret.Proposals, err = g.upgradeProposalInfo(num, energi_params.Nuclear_SporkRegistry)
+	if err != nil {
+		log.Error("SporkRegistry info fetch failed", "err", err)
+	}
+
+	return ret, nil
+}
+
+func (g *GovernanceAPI) SporkPropose(
+	new_impl common.Address,
+	period uint64,
+	fee *hexutil.Big,
+	payer common.Address,
+	password *string,
+) (txhash common.Hash, err error) {
+	return g.UpgradePropose(
+		energi_params.Nuclear_SporkRegistry, new_impl, period, fee, payer, password)
+}
+
+func (g *GovernanceAPI) SporkPerform(
+	proposal common.Address,
+	payer common.Address,
+	password *string,
+) (txhash common.Hash, err error) {
+	return g.UpgradePerform(
+		energi_params.Nuclear_SporkRegistry, proposal, payer, password)
+}
+
+func (g *GovernanceAPI) SporkCollect(
+	proposal common.Address,
+	payer common.Address,
+	password *string,
+) (txhash common.Hash, err error) {
+	return g.UpgradeCollect(
+		energi_params.Nuclear_SporkRegistry, proposal, payer, password)
+}
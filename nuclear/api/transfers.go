@@ -0,0 +1,144 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"errors"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/types"
+)
+
+const (
+	internalTransfersMaxLimit = 1000
+)
+
+type InternalTransfersAPI struct {
+	backend Backend
+}
+
+func NewInternalTransfersAPI(b Backend) *InternalTransfersAPI {
+	return &InternalTransfersAPI{b}
+}
+
+type InternalTransferInfo struct {
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+	Depth       uint64         `json:"depth"`
+}
+
+type InternalTransfersPage struct {
+	Total     uint64                 `json:"total"`
+	Offset    uint64                 `json:"offset"`
+	Transfers []InternalTransferInfo `json:"transfers"`
+}
+
+func newInternalTransferInfo(
+	number uint64,
+	hash common.Hash,
+	t *types.InternalTransfer,
+) InternalTransferInfo {
+	return InternalTransferInfo{
+		BlockNumber: number,
+		BlockHash:   hash,
+		TxHash:      t.TxHash,
+		From:        t.From,
+		To:          t.To,
+		Value:       (*hexutil.Big)(t.Value),
+		Depth:       t.Depth,
+	}
+}
+
+// GetInternalTransfers returns a page of internal transfers from or to
+// the address in chain order.
+//
+// NOTE: entries of blocks which are not canonical anymore are skipped,
+// so a page may have fewer items than the limit.
+func (a *InternalTransfersAPI) GetInternalTransfers(
+	address common.Address,
+	offset uint64,
+	limit uint64,
+) (*InternalTransfersPage, error) {
+	if limit == 0 || limit > internalTransfersMaxLimit {
+		limit = internalTransfersMaxLimit
+	}
+
+	db := a.backend.ChainDb()
+	total := rawdb.ReadInternalTransferCount(db, address)
+
+	res := &InternalTransfersPage{
+		Total:     total,
+		Offset:    offset,
+		Transfers: []InternalTransferInfo{},
+	}
+
+	var (
+		block_hash common.Hash
+		transfers  types.InternalTransfers
+	)
+
+	for seq := offset; seq < total && seq < offset+limit; seq++ {
+		entry := rawdb.ReadInternalTransferEntry(db, address, seq)
+		if entry == nil {
+			return nil, errors.New("Missing internal transfer entry")
+		}
+
+		if rawdb.ReadCanonicalHash(db, entry.BlockNumber) != entry.BlockHash {
+			continue
+		}
+
+		if entry.BlockHash != block_hash {
+			block_hash = entry.BlockHash
+			transfers = a.backend.BlockChain().GetInternalTransfers(block_hash, entry.BlockNumber)
+		}
+
+		if entry.Index >= uint64(len(transfers)) {
+			return nil, errors.New("Invalid internal transfer entry")
+		}
+
+		res.Transfers = append(res.Transfers, newInternalTransferInfo(
+			entry.BlockNumber, block_hash, transfers[entry.Index]))
+	}
+
+	return res, nil
+}
+
+// GetTransactionInternalTransfers returns all internal transfers of the transaction.
+func (a *InternalTransfersAPI) GetTransactionInternalTransfers(
+	txhash common.Hash,
+) ([]InternalTransferInfo, error) {
+	block_hash, number, _ := rawdb.ReadTxLookupEntry(a.backend.ChainDb(), txhash)
+	if (block_hash == common.Hash{}) {
+		return nil, errors.New("Unknown transaction")
+	}
+
+	res := []InternalTransferInfo{}
+
+	for _, t := range a.backend.BlockChain().GetInternalTransfers(block_hash, number) {
+		if t.TxHash == txhash {
+			res = append(res, newInternalTransferInfo(number, block_hash, t))
+		}
+	}
+
+	return res, nil
+}
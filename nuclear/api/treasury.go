@@ -0,0 +1,345 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/pborman/uuid"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rpc"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+const (
+	// treasuryHistoryMaxRange limits the block range of a single history
+	// query on public service nodes.
+	treasuryHistoryMaxRange uint64 = 100000
+)
+
+var (
+	treasuryAbi          abi.ABI
+	treasuryPayoutID     common.Hash
+	treasuryContributeID common.Hash
+)
+
+func init() {
+	var err error
+
+	treasuryAbi, err = abi.JSON(strings.NewReader(energi_abi.ITreasuryABI))
+	if err != nil {
+		panic(err)
+	}
+
+	treasuryPayoutID = treasuryAbi.Events["Payout"].Id()
+	treasuryContributeID = treasuryAbi.Events["Contribution"].Id()
+}
+
+type TreasuryPayout struct {
+	Block    uint64
+	TxHash   common.Hash
+	Proposal common.Address
+	RefUUID  string
+	Amount   *hexutil.Big
+}
+
+type TreasuryContribution struct {
+	Block  uint64
+	TxHash common.Hash
+	From   common.Address
+	Amount *hexutil.Big
+}
+
+type TreasurySuperblock struct {
+	Number  uint64
+	Time    uint64
+	Inflow  *hexutil.Big
+	Paid    *hexutil.Big
+	Balance *hexutil.Big
+}
+
+type TreasuryHistory struct {
+	From              uint64
+	To                uint64
+	StartBalance      *hexutil.Big
+	EndBalance        *hexutil.Big
+	TotalInflow       *hexutil.Big
+	TotalPaid         *hexutil.Big
+	TotalContribution *hexutil.Big
+	Superblocks       []TreasurySuperblock
+	Payouts           []TreasuryPayout
+	Contributions     []TreasuryContribution
+}
+
+// treasuryChain provides the chain data used for the Treasury history.
+type treasuryChain interface {
+	superblockCycle(num uint64) (uint64, error)
+	treasuryHeader(ctx context.Context, num uint64) (*types.Header, error)
+	treasuryBalanceAt(ctx context.Context, num uint64) (*big.Int, error)
+	treasuryEvents(ctx context.Context, header *types.Header) ([]TreasuryPayout, []TreasuryContribution, error)
+}
+
+// TreasuryHistory returns superblocks, payouts and contributions of the Treasury
+// in the specified inclusive block range.
+// NOTE: historical states are required, i.e. it works on archive nodes only.
+func (g *GovernanceAPI) TreasuryHistory(from, to uint64) (*TreasuryHistory, error) {
+	curr := g.backend.CurrentBlock().NumberU64()
+	if to > curr {
+		to = curr
+	}
+
+	if from == 0 || from > to {
+		return nil, errors.New("Invalid block range")
+	}
+
+	if g.backend.IsPublicService() && (to-from) > treasuryHistoryMaxRange {
+		return nil, errors.New("Too large block range")
+	}
+
+	return treasuryHistory(context.Background(), g, from, to)
+}
+
+func treasuryHistory(
+	ctx context.Context,
+	chain treasuryChain,
+	from, to uint64,
+) (*TreasuryHistory, error) {
+	cycle, err := chain.superblockCycle(to)
+	if err != nil {
+		log.Error("Failed to get superblock cycle", "err", err)
+		return nil, err
+	}
+
+	start_balance, err := chain.treasuryBalanceAt(ctx, from-1)
+	if err != nil {
+		log.Error("Failed to get Treasury balance", "block", from-1, "err", err)
+		return nil, err
+	}
+
+	res := &TreasuryHistory{
+		From:          from,
+		To:            to,
+		StartBalance:  (*hexutil.Big)(start_balance),
+		Superblocks:   []TreasurySuperblock{},
+		Payouts:       []TreasuryPayout{},
+		Contributions: []TreasuryContribution{},
+	}
+
+	total_inflow := new(big.Int)
+	total_paid := new(big.Int)
+	total_contrib := new(big.Int)
+	end_balance := start_balance
+
+	for n := from; n <= to; n++ {
+		header, err := chain.treasuryHeader(ctx, n)
+		if err != nil || header == nil {
+			log.Error("Failed to get header", "number", n, "err", err)
+			return nil, errors.New("Failed to get header")
+		}
+
+		paid := new(big.Int)
+		contrib := new(big.Int)
+
+		if types.BloomLookup(header.Bloom, treasuryPayoutID) ||
+			types.BloomLookup(header.Bloom, treasuryContributeID) {
+			payouts, contributions, err := chain.treasuryEvents(ctx, header)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range payouts {
+				paid.Add(paid, p.Amount.ToInt())
+			}
+			for _, c := range contributions {
+				contrib.Add(contrib, c.Amount.ToInt())
+			}
+
+			res.Payouts = append(res.Payouts, payouts...)
+			res.Contributions = append(res.Contributions, contributions...)
+		}
+
+		total_paid.Add(total_paid, paid)
+		total_contrib.Add(total_contrib, contrib)
+
+		end_balance = new(big.Int).Sub(end_balance, paid)
+		end_balance.Add(end_balance, contrib)
+
+		// The inflow is derived from the balance delta at superblocks
+		// and at the end of the range.
+		if n%cycle != 0 && n != to {
+			continue
+		}
+
+		balance, err := chain.treasuryBalanceAt(ctx, n)
+		if err != nil {
+			log.Error("Failed to get Treasury balance", "block", n, "err", err)
+			return nil, err
+		}
+
+		inflow := new(big.Int).Sub(balance, end_balance)
+		total_inflow.Add(total_inflow, inflow)
+		end_balance = balance
+
+		if n%cycle != 0 {
+			continue
+		}
+
+		res.Superblocks = append(res.Superblocks, TreasurySuperblock{
+			Number:  n,
+			Time:    header.Time,
+			Inflow:  (*hexutil.Big)(inflow),
+			Paid:    (*hexutil.Big)(paid),
+			Balance: (*hexutil.Big)(balance),
+		})
+	}
+
+	res.EndBalance = (*hexutil.Big)(end_balance)
+	res.TotalInflow = (*hexutil.Big)(total_inflow)
+	res.TotalPaid = (*hexutil.Big)(total_paid)
+	res.TotalContribution = (*hexutil.Big)(total_contrib)
+
+	return res, nil
+}
+
+func (g *GovernanceAPI) superblockCycle(num uint64) (uint64, error) {
+	treasury, err := energi_abi.NewTreasuryV1Caller(
+		energi_params.Nuclear_Treasury, g.backend.(bind.ContractCaller))
+	if err != nil {
+		return 0, err
+	}
+
+	call_opts := &bind.CallOpts{
+		BlockNumber: new(big.Int).SetUint64(num),
+		GasLimit:    energi_params.UnlimitedGas,
+	}
+
+	cycle, err := treasury.SuperblockCycle(call_opts)
+	if err != nil {
+		return 0, err
+	}
+
+	if cycle.Sign() <= 0 {
+		return 0, errors.New("Invalid superblock cycle")
+	}
+
+	return cycle.Uint64(), nil
+}
+
+func (g *GovernanceAPI) treasuryHeader(ctx context.Context, num uint64) (*types.Header, error) {
+	return g.backend.HeaderByNumber(ctx, rpc.BlockNumber(num))
+}
+
+// treasuryBalanceAt returns Treasury implementation balance as of the specified block.
+func (g *GovernanceAPI) treasuryBalanceAt(ctx context.Context, num uint64) (*big.Int, error) {
+	state, _, err := g.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(num))
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil {
+		return nil, errors.New("Missing state")
+	}
+
+	impl := common.BytesToAddress(
+		state.GetState(energi_params.Nuclear_Treasury, energi_params.Storage_ProxyImpl).Bytes())
+
+	return state.GetBalance(impl), nil
+}
+
+func (g *GovernanceAPI) treasuryEvents(
+	ctx context.Context,
+	header *types.Header,
+) (payouts []TreasuryPayout, contributions []TreasuryContribution, err error) {
+	state, _, err := g.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+	if err != nil || state == nil {
+		log.Error("Failed to get state", "number", header.Number, "err", err)
+		return nil, nil, err
+	}
+
+	// NOTE: events are emitted by the implementation, not the proxy
+	impl := common.BytesToAddress(
+		state.GetState(energi_params.Nuclear_Treasury, energi_params.Storage_ProxyImpl).Bytes())
+
+	receipts, err := g.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		log.Error("Failed to get receipts", "block", header.Hash(), "err", err)
+		return nil, nil, err
+	}
+
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			if l.Address != impl || len(l.Topics) == 0 {
+				continue
+			}
+
+			switch l.Topics[0] {
+			case treasuryPayoutID:
+				ev := new(energi_abi.ITreasuryPayout)
+				if err = treasuryAbi.Unpack(ev, "Payout", l.Data); err != nil {
+					log.Error("Failed to unpack Payout", "err", err)
+					return nil, nil, err
+				}
+				if len(l.Topics) > 1 {
+					ev.RefUuid = l.Topics[1].Big()
+				}
+
+				payouts = append(payouts, TreasuryPayout{
+					Block:    header.Number.Uint64(),
+					TxHash:   receipt.TxHash,
+					Proposal: ev.Proposal,
+					RefUUID:  refUUIDString(ev.RefUuid),
+					Amount:   (*hexutil.Big)(ev.Amount),
+				})
+
+			case treasuryContributeID:
+				ev := new(energi_abi.ITreasuryContribution)
+				if err = treasuryAbi.Unpack(ev, "Contribution", l.Data); err != nil {
+					log.Error("Failed to unpack Contribution", "err", err)
+					return nil, nil, err
+				}
+
+				contributions = append(contributions, TreasuryContribution{
+					Block:  header.Number.Uint64(),
+					TxHash: receipt.TxHash,
+					From:   ev.From,
+					Amount: (*hexutil.Big)(ev.Amount),
+				})
+			}
+		}
+	}
+
+	return payouts, contributions, nil
+}
+
+func refUUIDString(ref_uuid *big.Int) string {
+	if ref_uuid == nil {
+		return ""
+	}
+
+	return uuid.UUID(common.LeftPadBytes(ref_uuid.Bytes(), 16)).String()
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTreasuryChain gets 1 inflow per block, a payout of 30 at block 20 and
+// a contribution of 5 at block 22. The superblock cycle is 10.
+type testTreasuryChain struct{}
+
+func (testTreasuryChain) superblockCycle(num uint64) (uint64, error) {
+	return 10, nil
+}
+
+func (testTreasuryChain) treasuryHeader(ctx context.Context, num uint64) (*types.Header, error) {
+	header := &types.Header{Number: new(big.Int).SetUint64(num), Time: num * 60}
+
+	switch num {
+	case 20:
+		header.Bloom.Add(treasuryPayoutID.Big())
+	case 22:
+		header.Bloom.Add(treasuryContributeID.Big())
+	}
+
+	return header, nil
+}
+
+func (testTreasuryChain) treasuryBalanceAt(ctx context.Context, num uint64) (*big.Int, error) {
+	balance := int64(100 + num)
+	if num >= 20 {
+		balance -= 30
+	}
+	if num >= 22 {
+		balance += 5
+	}
+	return big.NewInt(balance), nil
+}
+
+func (testTreasuryChain) treasuryEvents(
+	ctx context.Context,
+	header *types.Header,
+) ([]TreasuryPayout, []TreasuryContribution, error) {
+	switch header.Number.Uint64() {
+	case 20:
+		return []TreasuryPayout{{Block: 20, Amount: (*hexutil.Big)(big.NewInt(30))}}, nil, nil
+	case 22:
+		return nil, []TreasuryContribution{{Block: 22, Amount: (*hexutil.Big)(big.NewInt(5))}}, nil
+	}
+	return nil, nil, nil
+}
+
+func TestTreasuryHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	chain := testTreasuryChain{}
+
+	// Not a superblock at the end
+	res, err := treasuryHistory(ctx, chain, 5, 23)
+	assert.Empty(t, err)
+	assert.Equal(t, big.NewInt(104), res.StartBalance.ToInt())
+	assert.Equal(t, big.NewInt(98), res.EndBalance.ToInt())
+	assert.Equal(t, big.NewInt(19), res.TotalInflow.ToInt())
+	assert.Equal(t, big.NewInt(30), res.TotalPaid.ToInt())
+	assert.Equal(t, big.NewInt(5), res.TotalContribution.ToInt())
+	assert.Equal(t, 1, len(res.Payouts))
+	assert.Equal(t, 1, len(res.Contributions))
+	assert.Equal(t, 2, len(res.Superblocks))
+	assert.Equal(t, uint64(10), res.Superblocks[0].Number)
+	assert.Equal(t, big.NewInt(6), res.Superblocks[0].Inflow.ToInt())
+	assert.Equal(t, big.NewInt(110), res.Superblocks[0].Balance.ToInt())
+	assert.Equal(t, uint64(20), res.Superblocks[1].Number)
+	assert.Equal(t, big.NewInt(10), res.Superblocks[1].Inflow.ToInt())
+	assert.Equal(t, big.NewInt(30), res.Superblocks[1].Paid.ToInt())
+	assert.Equal(t, big.NewInt(90), res.Superblocks[1].Balance.ToInt())
+
+	// No superblock at all
+	res, err = treasuryHistory(ctx, chain, 21, 25)
+	assert.Empty(t, err)
+	assert.Equal(t, big.NewInt(90), res.StartBalance.ToInt())
+	assert.Equal(t, big.NewInt(100), res.EndBalance.ToInt())
+	assert.Equal(t, big.NewInt(5), res.TotalInflow.ToInt())
+	assert.Equal(t, 0, len(res.Superblocks))
+
+	// Superblock at the end
+	res, err = treasuryHistory(ctx, chain, 11, 20)
+	assert.Empty(t, err)
+	assert.Equal(t, big.NewInt(90), res.EndBalance.ToInt())
+	assert.Equal(t, big.NewInt(10), res.TotalInflow.ToInt())
+	assert.Equal(t, 1, len(res.Superblocks))
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/rpc"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+// UpgradeVerify checks the implementation of the upgrade proposal against
+// the solc output of the contract in the artifacts directory of the node,
+// e.g. build/contracts/energi. It should be done before voting for the
+// proposal.
+func (a *GovernanceAdminAPI) UpgradeVerify(
+	proxy common.Address,
+	proposal common.Address,
+	contract string,
+	artifacts string,
+) (*energi_common.UpgradeVerification, error) {
+	contracts, err := energi_common.LoadArtifacts(artifacts)
+	if err != nil {
+		log.Error("Failed to load artifacts", "err", err)
+		return nil, err
+	}
+
+	backend := a.gov.backend
+
+	state, header, err := backend.StateAndHeaderByNumber(
+		context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		log.Error("Failed at state", "err", err)
+		return nil, err
+	}
+
+	proxy_obj, err := energi_abi.NewIGovernedProxyCaller(
+		proxy, backend.(bind.ContractCaller))
+	if err != nil {
+		log.Error("Failed NewIGovernedProxyCaller", "err", err)
+		return nil, err
+	}
+
+	call_opts := &bind.CallOpts{
+		BlockNumber: header.Number,
+		GasLimit:    energi_params.UnlimitedGas,
+	}
+
+	current, err := proxy_obj.Impl(call_opts)
+	if err != nil {
+		log.Error("Failed Impl", "err", err)
+		return nil, err
+	}
+
+	proposed, err := proxy_obj.UpgradeProposalImpl(call_opts, proposal)
+	if err != nil {
+		log.Error("Failed UpgradeProposalImpl", "err", err)
+		return nil, err
+	}
+
+	ret := energi_common.VerifyUpgrade(
+		contracts, contract, state.GetCode(current), state.GetCode(proposed))
+	ret.Proxy = proxy
+	ret.Proposal = proposal
+	ret.Current.Address = current
+	ret.Proposed.Address = proposed
+
+	return ret, nil
+}
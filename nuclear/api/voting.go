@@ -0,0 +1,904 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/event"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/params"
+	"nuclear/core/nuclear/rlp"
+	"nuclear/core/nuclear/rpc"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+const (
+	// voteIndexBatch is the number of blocks indexed at once, the progress
+	// is saved and the index is readable in between.
+	voteIndexBatch uint64 = 1000
+
+	voteIndexChanSize = 10
+	policyChanSize    = 10
+)
+
+var (
+	voteAcceptID []byte
+	voteRejectID []byte
+
+	upgradeProposalID common.Hash
+	upgradedID        common.Hash
+	budgetProposalID  common.Hash
+)
+
+func init() {
+	proposal_abi, err := abi.JSON(strings.NewReader(energi_abi.IProposalABI))
+	if err != nil {
+		panic(err)
+	}
+
+	voteAcceptID = proposal_abi.Methods["voteAccept"].Id()
+	voteRejectID = proposal_abi.Methods["voteReject"].Id()
+
+	proxy_abi, err := abi.JSON(strings.NewReader(energi_abi.IGovernedProxyABI))
+	if err != nil {
+		panic(err)
+	}
+
+	upgradeProposalID = proxy_abi.Events["UpgradeProposal"].Id()
+	upgradedID = proxy_abi.Events["Upgraded"].Id()
+
+	treasury_abi, err := abi.JSON(strings.NewReader(energi_abi.ITreasuryABI))
+	if err != nil {
+		panic(err)
+	}
+
+	budgetProposalID = treasury_abi.Events["BudgetProposal"].Id()
+}
+
+//=============================================================================
+// Vote history
+//=============================================================================
+
+type ProposalVote struct {
+	Proposal common.Address
+	Owner    common.Address
+	Accepted bool
+	Block    uint64
+	TxHash   common.Hash
+}
+
+type OwnerVotes struct {
+	Owner common.Address
+	Votes []ProposalVote
+}
+
+// VoteHistory lists the votes found in the blocks before Next. The votes are
+// partial while the index catches up with the Current block.
+type VoteHistory struct {
+	Next    uint64
+	Current uint64
+	Owners  []OwnerVotes
+}
+
+// ListVotes returns votes of the specified masternode owners across all
+// upgrade and budget proposals, including the finished ones. All voters are
+// returned, if no owner is given.
+//
+// NOTE: the index is built in background since the first call.
+func (g *GovernanceAPI) ListVotes(owners []common.Address) (*VoteHistory, error) {
+	if g.backend.IsPublicService() && (len(owners) == 0 || len(owners) > ownerSafetyLimit) {
+		return nil, errors.New("Owners are required, but not too many")
+	}
+
+	g.votes.start(g.backend)
+	votes, next := g.votes.snapshot()
+
+	return &VoteHistory{
+		Next:    next,
+		Current: g.backend.CurrentBlock().NumberU64(),
+		Owners:  filterOwnerVotes(votes, owners),
+	}, nil
+}
+
+func filterOwnerVotes(votes []ProposalVote, owners []common.Address) []OwnerVotes {
+	owners_map := make(map[common.Address]int, len(owners))
+	res := make([]OwnerVotes, 0, len(owners))
+
+	for _, o := range owners {
+		if _, ok := owners_map[o]; !ok {
+			owners_map[o] = len(res)
+			res = append(res, OwnerVotes{Owner: o, Votes: []ProposalVote{}})
+		}
+	}
+
+	for _, v := range votes {
+		idx, ok := owners_map[v.Owner]
+		if !ok {
+			if len(owners) > 0 {
+				continue
+			}
+
+			idx = len(res)
+			owners_map[v.Owner] = idx
+			res = append(res, OwnerVotes{Owner: v.Owner, Votes: []ProposalVote{}})
+		}
+
+		res[idx].Votes = append(res[idx].Votes, v)
+	}
+
+	return res
+}
+
+// voteChain is the part of Backend used by voteIndex.
+type voteChain interface {
+	ChainConfig() *params.ChainConfig
+	CurrentBlock() *types.Block
+	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
+	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
+	GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error)
+	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// voteIndexHead is the persisted progress of the index.
+type voteIndexHead struct {
+	Next uint64      // the first block not indexed yet
+	Hash common.Hash // the last indexed block
+	Last uint64      // the last block record number plus one, zero for none
+}
+
+// voteIndexBlock is the persisted record of a block with votes or new
+// proposals. The records are linked from the last one backwards.
+type voteIndexBlock struct {
+	Prev       uint64 // the previous block record number plus one, zero for none
+	Votes      []ProposalVote
+	Proposals  []common.Address
+	Treasuries []common.Address
+}
+
+// voteIndex keeps the successful votes on the governance proposals of the
+// canonical chain. It is built in background on the chain head events and
+// persisted, so only the blocks replaced by a reorganization are scanned
+// again.
+//
+// NOTE: the proposals are recognized by the events of the governed proxies
+// and the Treasury, as the proposal contracts are destroyed when finished.
+type voteIndex struct {
+	db ethdb.Database
+
+	mtx        sync.Mutex
+	started    bool
+	head       voteIndexHead
+	votes      []ProposalVote
+	proposals  map[common.Address]uint64
+	treasuries map[common.Address]uint64
+}
+
+func newVoteIndex(db ethdb.Database) *voteIndex {
+	vi := &voteIndex{db: db}
+	vi.load()
+	return vi
+}
+
+// reset clears the index, the Treasury of genesis is always known.
+func (vi *voteIndex) reset() {
+	vi.head = voteIndexHead{}
+	vi.votes = []ProposalVote{}
+	vi.proposals = make(map[common.Address]uint64)
+	vi.treasuries = map[common.Address]uint64{
+		energi_params.Nuclear_GovernedProxies[energi_params.Nuclear_Treasury]: 0,
+	}
+}
+
+// load restores the persisted index, a broken one gets rebuilt.
+func (vi *voteIndex) load() {
+	vi.reset()
+
+	data := rawdb.ReadVoteIndexHead(vi.db)
+	if len(data) == 0 {
+		return
+	}
+
+	var head voteIndexHead
+	if err := rlp.DecodeBytes(data, &head); err != nil {
+		log.Error("Failed to decode vote index head", "err", err)
+		return
+	}
+
+	var (
+		numbers []uint64
+		records []*voteIndexBlock
+	)
+
+	for last := head.Last; last > 0; {
+		rec := vi.readBlock(last - 1)
+		if rec == nil {
+			log.Warn("Rebuilding broken vote index")
+			return
+		}
+
+		numbers = append(numbers, last-1)
+		records = append(records, rec)
+		last = rec.Prev
+	}
+
+	for i := len(records) - 1; i >= 0; i-- {
+		vi.apply(numbers[i], records[i])
+	}
+
+	vi.head = head
+}
+
+func (vi *voteIndex) readBlock(number uint64) *voteIndexBlock {
+	data := rawdb.ReadVoteIndexBlock(vi.db, number)
+	if len(data) == 0 {
+		return nil
+	}
+
+	rec := new(voteIndexBlock)
+	if err := rlp.DecodeBytes(data, rec); err != nil {
+		log.Error("Failed to decode vote index block", "number", number, "err", err)
+		return nil
+	}
+
+	return rec
+}
+
+func (vi *voteIndex) writeBlock(db ethdb.Putter, number uint64, rec *voteIndexBlock) {
+	data, err := rlp.EncodeToBytes(rec)
+	if err != nil {
+		log.Crit("Failed to encode vote index block", "err", err)
+	}
+
+	rawdb.WriteVoteIndexBlock(db, number, data)
+}
+
+func (vi *voteIndex) writeHead(db ethdb.Putter) {
+	data, err := rlp.EncodeToBytes(&vi.head)
+	if err != nil {
+		log.Crit("Failed to encode vote index head", "err", err)
+	}
+
+	rawdb.WriteVoteIndexHead(db, data)
+}
+
+// apply adds the block record to the in-memory index.
+func (vi *voteIndex) apply(number uint64, rec *voteIndexBlock) {
+	vi.votes = append(vi.votes, rec.Votes...)
+
+	for _, p := range rec.Proposals {
+		vi.proposals[p] = number
+	}
+
+	for _, t := range rec.Treasuries {
+		vi.treasuries[t] = number
+	}
+}
+
+// snapshot returns all the votes and the first block not indexed yet.
+// The result must not be modified.
+func (vi *voteIndex) snapshot() ([]ProposalVote, uint64) {
+	vi.mtx.Lock()
+	defer vi.mtx.Unlock()
+
+	return vi.votes[:len(vi.votes):len(vi.votes)], vi.head.Next
+}
+
+// start launches the background indexing, unless it is already running.
+func (vi *voteIndex) start(chain voteChain) {
+	vi.mtx.Lock()
+	defer vi.mtx.Unlock()
+
+	if vi.started {
+		return
+	}
+
+	vi.started = true
+	go vi.loop(chain)
+}
+
+func (vi *voteIndex) loop(chain voteChain) {
+	chainHeadCh := make(chan core.ChainHeadEvent, voteIndexChanSize)
+	headSub := chain.SubscribeChainHeadEvent(chainHeadCh)
+	defer headSub.Unsubscribe()
+
+	for {
+		done, err := vi.update(chain, voteIndexBatch)
+		if err != nil {
+			log.Error("Failed to update vote index", "err", err)
+			done = true
+		}
+
+		if !done {
+			// Do not block the chain while catching up
+			select {
+			case <-chainHeadCh:
+			case <-headSub.Err():
+				return
+			default:
+			}
+			continue
+		}
+
+		select {
+		case <-chainHeadCh:
+		case <-headSub.Err():
+			return
+		}
+	}
+}
+
+// update indexes up to limit blocks towards the current one and reports
+// if the index has caught up.
+func (vi *voteIndex) update(chain voteChain, limit uint64) (done bool, err error) {
+	vi.mtx.Lock()
+	defer vi.mtx.Unlock()
+
+	ctx := context.Background()
+	batch := vi.db.NewBatch()
+
+	defer func() {
+		vi.writeHead(batch)
+		if werr := batch.Write(); werr != nil {
+			log.Crit("Failed to store vote index", "err", werr)
+		}
+	}()
+
+	if err = vi.rollback(ctx, chain, batch); err != nil {
+		return false, err
+	}
+
+	head := chain.CurrentBlock().NumberU64()
+	end := vi.head.Next + limit
+
+	for ; vi.head.Next <= head; vi.head.Next++ {
+		if vi.head.Next >= end {
+			return false, nil
+		}
+
+		number := vi.head.Next
+
+		block, err := chain.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil || block == nil {
+			log.Error("Failed to get block", "number", number, "err", err)
+			return false, errors.New("Failed to get block")
+		}
+
+		// Reorganized meanwhile, it is handled on the next update
+		if number > 0 && block.ParentHash() != vi.head.Hash {
+			return false, nil
+		}
+
+		rec, err := vi.blockRecord(ctx, chain, block)
+		if err != nil {
+			return false, err
+		}
+
+		if rec != nil {
+			rec.Prev = vi.head.Last
+			vi.writeBlock(batch, number, rec)
+			vi.apply(number, rec)
+			vi.head.Last = number + 1
+		}
+
+		vi.head.Hash = block.Hash()
+	}
+
+	return true, nil
+}
+
+// rollback drops the indexed blocks which are not canonical anymore.
+func (vi *voteIndex) rollback(ctx context.Context, chain voteChain, batch ethdb.Batch) error {
+	if vi.head.Next == 0 {
+		return nil
+	}
+
+	number := vi.head.Next - 1
+	hash := vi.head.Hash
+
+	// Find the common ancestor with the canonical chain
+	for {
+		header, err := chain.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return err
+		}
+
+		if header != nil && header.Hash() == hash {
+			break
+		}
+
+		block, err := chain.GetBlock(ctx, hash)
+		if number == 0 || err != nil || block == nil {
+			log.Warn("Rebuilding vote index after a reorganization", "number", number)
+			vi.dropRecords(batch, 0)
+			vi.reset()
+			return nil
+		}
+
+		hash = block.ParentHash()
+		number--
+	}
+
+	next := number + 1
+	if next == vi.head.Next {
+		return nil
+	}
+
+	if !vi.dropRecords(batch, next) {
+		log.Warn("Rebuilding broken vote index")
+		vi.reset()
+		return nil
+	}
+
+	keep := len(vi.votes)
+	for keep > 0 && vi.votes[keep-1].Block >= next {
+		keep--
+	}
+
+	// Copy to avoid modifying results returned before
+	vi.votes = append([]ProposalVote{}, vi.votes[:keep]...)
+
+	for p, n := range vi.proposals {
+		if n >= next {
+			delete(vi.proposals, p)
+		}
+	}
+
+	for t, n := range vi.treasuries {
+		if n >= next {
+			delete(vi.treasuries, t)
+		}
+	}
+
+	vi.head.Next = next
+	vi.head.Hash = hash
+	return nil
+}
+
+// dropRecords deletes the block records starting from the specified number.
+func (vi *voteIndex) dropRecords(batch ethdb.Batch, next uint64) bool {
+	for vi.head.Last > next {
+		number := vi.head.Last - 1
+
+		rec := vi.readBlock(number)
+		rawdb.DeleteVoteIndexBlock(batch, number)
+
+		if rec == nil {
+			return false
+		}
+
+		vi.head.Last = rec.Prev
+	}
+
+	return true
+}
+
+// blockRecord returns the successful votes on the known proposals and
+// the new proposals of the block, nil if there are none.
+func (vi *voteIndex) blockRecord(
+	ctx context.Context,
+	chain voteChain,
+	block *types.Block,
+) (*voteIndexBlock, error) {
+	bloom := block.Bloom()
+	has_events := types.BloomLookup(bloom, upgradeProposalID) ||
+		types.BloomLookup(bloom, upgradedID) ||
+		types.BloomLookup(bloom, budgetProposalID)
+
+	txs := block.Transactions()
+	has_votes := false
+
+	for _, tx := range txs {
+		if _, ok := voteCall(tx); ok {
+			has_votes = true
+			break
+		}
+	}
+
+	if !has_events && !has_votes {
+		return nil, nil
+	}
+
+	receipts, err := chain.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		log.Error("Failed to get receipts", "block", block.Hash(), "err", err)
+		return nil, err
+	}
+
+	var (
+		rec    = new(voteIndexBlock)
+		signer = types.MakeSigner(chain.ChainConfig(), block.Number())
+		number = block.NumberU64()
+	)
+
+	for i, tx := range txs {
+		if i >= len(receipts) {
+			break
+		}
+
+		receipt := receipts[i]
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+
+		if accepted, ok := voteCall(tx); ok {
+			// Any contract can have the voting methods
+			if _, ok := vi.proposals[*tx.To()]; !ok {
+				continue
+			}
+
+			owner, err := types.Sender(signer, tx)
+			if err != nil {
+				log.Debug("Failed to recover vote sender", "tx", tx.Hash(), "err", err)
+				continue
+			}
+
+			rec.Votes = append(rec.Votes, ProposalVote{
+				Proposal: *tx.To(),
+				Owner:    owner,
+				Accepted: accepted,
+				Block:    number,
+				TxHash:   tx.Hash(),
+			})
+			continue
+		}
+
+		if has_events {
+			vi.proposalEvents(rec, number, receipt.Logs)
+		}
+	}
+
+	if len(rec.Votes) == 0 && len(rec.Proposals) == 0 && len(rec.Treasuries) == 0 {
+		return nil, nil
+	}
+
+	return rec, nil
+}
+
+// proposalEvents adds the proposals created by the governed proxies and
+// the Treasury. The new Treasury implementations are tracked as the budget
+// proposal events are emitted by them.
+func (vi *voteIndex) proposalEvents(rec *voteIndexBlock, number uint64, logs []*types.Log) {
+	for _, l := range logs {
+		if len(l.Topics) == 0 {
+			continue
+		}
+
+		var ok bool
+
+		switch l.Topics[0] {
+		case upgradeProposalID:
+			_, ok = energi_params.Nuclear_GovernedProxies[l.Address]
+		case budgetProposalID:
+			_, ok = vi.treasuries[l.Address]
+		case upgradedID:
+			if l.Address == energi_params.Nuclear_Treasury && len(l.Topics) > 1 {
+				impl := common.BytesToAddress(l.Topics[1].Bytes())
+				rec.Treasuries = append(rec.Treasuries, impl)
+				vi.treasuries[impl] = number
+			}
+			continue
+		}
+
+		if !ok || len(l.Data) < common.HashLength {
+			continue
+		}
+
+		// The proposal is the first non-indexed field of both events
+		proposal := common.BytesToAddress(l.Data[:common.HashLength])
+		rec.Proposals = append(rec.Proposals, proposal)
+		vi.proposals[proposal] = number
+	}
+}
+
+// voteCall checks if the transaction calls one of the voting methods.
+func voteCall(tx *types.Transaction) (accepted bool, ok bool) {
+	if tx.To() == nil || tx.IsConsensus() {
+		return false, false
+	}
+
+	data := tx.Data()
+	accepted = bytes.Equal(data, voteAcceptID)
+
+	return accepted, accepted || bytes.Equal(data, voteRejectID)
+}
+
+//=============================================================================
+// Delegated voting
+//=============================================================================
+
+type VoteResult struct {
+	Owner  common.Address
+	TxHash common.Hash
+	Error  string
+}
+
+// VoteAcceptMany casts approval votes for each of the specified owners.
+func (g *GovernanceAPI) VoteAcceptMany(
+	proposal common.Address,
+	mn_owners []common.Address,
+	password *string,
+) []VoteResult {
+	return g.voteMany(proposal, mn_owners, password, true)
+}
+
+// VoteRejectMany casts rejection votes for each of the specified owners.
+func (g *GovernanceAPI) VoteRejectMany(
+	proposal common.Address,
+	mn_owners []common.Address,
+	password *string,
+) []VoteResult {
+	return g.voteMany(proposal, mn_owners, password, false)
+}
+
+func (g *GovernanceAPI) voteMany(
+	proposal common.Address,
+	mn_owners []common.Address,
+	password *string,
+	accept bool,
+) []VoteResult {
+	res := make([]VoteResult, 0, len(mn_owners))
+
+	for _, owner := range mn_owners {
+		txhash, err := g.vote(proposal, owner, password, accept)
+
+		r := VoteResult{
+			Owner:  owner,
+			TxHash: txhash,
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+
+		res = append(res, r)
+	}
+
+	return res
+}
+
+//=============================================================================
+// Voting policy
+//=============================================================================
+
+// VotingPolicy describes automatic voting decisions for locally unlocked
+// masternode owner accounts.
+type VotingPolicy struct {
+	Owners []common.Address `json:"owners"`
+	Budget *struct {
+		AcceptBelow *hexutil.Big `json:"acceptBelow"`
+		RejectAbove *hexutil.Big `json:"rejectAbove"`
+	} `json:"budget"`
+	Upgrade *struct {
+		Accept []common.Address `json:"accept"`
+		Reject []common.Address `json:"reject"`
+	} `json:"upgrade"`
+}
+
+func loadVotingPolicy(file string) (*VotingPolicy, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	policy := &VotingPolicy{}
+	if err = dec.Decode(policy); err != nil {
+		return nil, err
+	}
+
+	if len(policy.Owners) == 0 {
+		return nil, errors.New("No owners in voting policy")
+	}
+
+	return policy, nil
+}
+
+// budgetDecision returns whether to vote and the vote direction for
+// a budget proposal of the specified amount.
+func (p *VotingPolicy) budgetDecision(amount *big.Int) (vote bool, accept bool) {
+	if p.Budget == nil || amount == nil {
+		return false, false
+	}
+
+	if b := p.Budget.AcceptBelow; b != nil && amount.Cmp(b.ToInt()) < 0 {
+		return true, true
+	}
+
+	if r := p.Budget.RejectAbove; r != nil && amount.Cmp(r.ToInt()) > 0 {
+		return true, false
+	}
+
+	return false, false
+}
+
+// upgradeDecision returns whether to vote and the vote direction for
+// an upgrade proposal of the specified implementation.
+func (p *VotingPolicy) upgradeDecision(impl common.Address) (vote bool, accept bool) {
+	if p.Upgrade == nil {
+		return false, false
+	}
+
+	for _, a := range p.Upgrade.Accept {
+		if a == impl {
+			return true, true
+		}
+	}
+
+	for _, r := range p.Upgrade.Reject {
+		if r == impl {
+			return true, false
+		}
+	}
+
+	return false, false
+}
+
+type GovernanceAdminAPI struct {
+	gov *GovernanceAPI
+
+	mtx    sync.Mutex
+	policy *VotingPolicy
+	voted  map[common.Address]map[common.Address]bool
+	stopCh chan struct{}
+}
+
+func NewGovernanceAdminAPI(gov *GovernanceAPI) *GovernanceAdminAPI {
+	return &GovernanceAdminAPI{gov: gov}
+}
+
+// VotingPolicyLoad enables the voting policy from the specified file. The policy
+// is applied to open proposals immediately and on each new block.
+// NOTE: owner accounts must be unlocked.
+func (a *GovernanceAdminAPI) VotingPolicyLoad(file string) (*VotingPolicy, error) {
+	policy, err := loadVotingPolicy(file)
+	if err != nil {
+		log.Error("Failed to load voting policy", "err", err)
+		return nil, err
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.policy = policy
+	a.voted = make(map[common.Address]map[common.Address]bool)
+
+	if a.stopCh == nil {
+		a.stopCh = make(chan struct{})
+		go a.loop(a.stopCh)
+	}
+
+	log.Info("Voting policy is loaded", "file", file, "owners", len(policy.Owners))
+	return policy, nil
+}
+
+// VotingPolicyClear disables automatic voting.
+func (a *GovernanceAdminAPI) VotingPolicyClear() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.stopCh != nil {
+		close(a.stopCh)
+		a.stopCh = nil
+	}
+
+	a.policy = nil
+}
+
+func (a *GovernanceAdminAPI) loop(stopCh chan struct{}) {
+	chainHeadCh := make(chan core.ChainHeadEvent, policyChanSize)
+	headSub := a.gov.backend.SubscribeChainHeadEvent(chainHeadCh)
+	defer headSub.Unsubscribe()
+
+	a.applyPolicy()
+
+	for {
+		select {
+		case <-chainHeadCh:
+			a.applyPolicy()
+		case <-stopCh:
+			return
+		case <-headSub.Err():
+			return
+		}
+	}
+}
+
+func (a *GovernanceAdminAPI) applyPolicy() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if a.policy == nil {
+		return
+	}
+
+	if uinfo := a.gov.UpgradeInfo(nil); uinfo != nil {
+		for _, list := range [][]UpgradeProposalInfo{
+			uinfo.Treasury,
+			uinfo.MasternodeRegistry,
+			uinfo.StakerReward,
+			uinfo.BackboneReward,
+			uinfo.SporkRegistry,
+			uinfo.CheckpointRegistry,
+			uinfo.BlacklistRegistry,
+			uinfo.MasternodeToken,
+		} {
+			for _, p := range list {
+				if vote, accept := a.policy.upgradeDecision(p.Impl); vote {
+					a.policyVote(&p.ProposalInfo, accept)
+				}
+			}
+		}
+	}
+
+	if binfo, err := a.gov.BudgetInfo(nil); err == nil && binfo != nil {
+		for _, p := range binfo.Proposals {
+			if vote, accept := a.policy.budgetDecision(p.ProposedAmount.ToInt()); vote {
+				a.policyVote(&p.ProposalInfo, accept)
+			}
+		}
+	}
+}
+
+func (a *GovernanceAdminAPI) policyVote(p *ProposalInfo, accept bool) {
+	if p.Finished {
+		return
+	}
+
+	voted, ok := a.voted[p.Proposal]
+	if !ok {
+		voted = make(map[common.Address]bool)
+		a.voted[p.Proposal] = voted
+	}
+
+	for _, owner := range a.policy.Owners {
+		if voted[owner] {
+			continue
+		}
+
+		txhash, err := a.gov.vote(p.Proposal, owner, nil, accept)
+		if err != nil {
+			log.Debug("Policy vote skipped", "proposal", p.Proposal, "owner", owner, "err", err)
+			continue
+		}
+
+		log.Info("Policy vote", "proposal", p.Proposal, "owner", owner,
+			"accept", accept, "tx", txhash.Hex())
+
+		// Avoid duplicates while the vote TX is pending
+		voted[owner] = true
+	}
+}
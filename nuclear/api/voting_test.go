@@ -0,0 +1,388 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/event"
+	"nuclear/core/nuclear/params"
+	"nuclear/core/nuclear/rpc"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testVotingPolicy = `{
+	"owners": ["0x0000000000000000000000000000000000001234"],
+	"budget": {
+		"acceptBelow": "0x64",
+		"rejectAbove": "0x3e8"
+	},
+	"upgrade": {
+		"accept": ["0x0000000000000000000000000000000000000abc"],
+		"reject": ["0x0000000000000000000000000000000000000def"]
+	}
+}`
+)
+
+func TestVotingPolicy(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "voting_policy")
+	assert.Empty(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(testVotingPolicy)
+	assert.Empty(t, err)
+	f.Close()
+
+	policy, err := loadVotingPolicy(f.Name())
+	assert.Empty(t, err)
+	assert.Equal(t, []common.Address{common.HexToAddress("0x1234")}, policy.Owners)
+
+	vote, accept := policy.budgetDecision(big.NewInt(99))
+	assert.True(t, vote)
+	assert.True(t, accept)
+
+	vote, _ = policy.budgetDecision(big.NewInt(100))
+	assert.False(t, vote)
+
+	vote, _ = policy.budgetDecision(big.NewInt(1000))
+	assert.False(t, vote)
+
+	vote, accept = policy.budgetDecision(big.NewInt(1001))
+	assert.True(t, vote)
+	assert.False(t, accept)
+
+	vote, accept = policy.upgradeDecision(common.HexToAddress("0xabc"))
+	assert.True(t, vote)
+	assert.True(t, accept)
+
+	vote, accept = policy.upgradeDecision(common.HexToAddress("0xdef"))
+	assert.True(t, vote)
+	assert.False(t, accept)
+
+	vote, _ = policy.upgradeDecision(common.HexToAddress("0x123"))
+	assert.False(t, vote)
+}
+
+func TestFilterOwnerVotes(t *testing.T) {
+	t.Parallel()
+
+	owner1 := common.HexToAddress("0x1")
+	owner2 := common.HexToAddress("0x2")
+	owner3 := common.HexToAddress("0x3")
+
+	votes := []ProposalVote{
+		{Proposal: common.HexToAddress("0x10"), Owner: owner1, Accepted: true},
+		{Proposal: common.HexToAddress("0x10"), Owner: owner2, Accepted: false},
+		{Proposal: common.HexToAddress("0x11"), Owner: owner1, Accepted: false},
+	}
+
+	all := filterOwnerVotes(votes, nil)
+	assert.Equal(t, 2, len(all))
+	assert.Equal(t, owner1, all[0].Owner)
+	assert.Equal(t, 2, len(all[0].Votes))
+	assert.Equal(t, owner2, all[1].Owner)
+	assert.Equal(t, 1, len(all[1].Votes))
+
+	some := filterOwnerVotes(votes, []common.Address{owner3, owner1})
+	assert.Equal(t, 2, len(some))
+	assert.Equal(t, owner3, some[0].Owner)
+	assert.Equal(t, 0, len(some[0].Votes))
+	assert.Equal(t, owner1, some[1].Owner)
+	assert.Equal(t, 2, len(some[1].Votes))
+}
+
+type testVoteChain struct {
+	blocks   []*types.Block
+	known    map[common.Hash]*types.Block
+	receipts map[common.Hash]types.Receipts
+	logs     map[common.Hash][]*types.Log
+	feed     event.Feed
+}
+
+func newTestVoteChain() *testVoteChain {
+	return &testVoteChain{
+		known:    make(map[common.Hash]*types.Block),
+		receipts: make(map[common.Hash]types.Receipts),
+		logs:     make(map[common.Hash][]*types.Log),
+	}
+}
+
+func (c *testVoteChain) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (c *testVoteChain) CurrentBlock() *types.Block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *testVoteChain) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if int(number) >= len(c.blocks) {
+		return nil, nil
+	}
+	return c.blocks[number].Header(), nil
+}
+
+func (c *testVoteChain) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if int(number) >= len(c.blocks) {
+		return nil, nil
+	}
+	return c.blocks[number], nil
+}
+
+func (c *testVoteChain) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return c.known[hash], nil
+}
+
+func (c *testVoteChain) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return c.receipts[hash], nil
+}
+
+func (c *testVoteChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// event attaches a log to the receipt of the transaction.
+func (c *testVoteChain) event(
+	tx *types.Transaction,
+	addr common.Address,
+	topics []common.Hash,
+	proposal common.Address,
+) *types.Transaction {
+	c.logs[tx.Hash()] = append(c.logs[tx.Hash()], &types.Log{
+		Address: addr,
+		Topics:  topics,
+		Data:    common.LeftPadBytes(proposal.Bytes(), 32),
+	})
+	return tx
+}
+
+// add appends a block of the transactions, the ones with odd nonce fail.
+func (c *testVoteChain) add(fork byte, txs ...*types.Transaction) {
+	header := &types.Header{
+		Number: big.NewInt(int64(len(c.blocks))),
+		Extra:  []byte{fork},
+	}
+	if len(c.blocks) > 0 {
+		header.ParentHash = c.CurrentBlock().Hash()
+	}
+
+	receipts := types.Receipts{}
+	for _, tx := range txs {
+		receipt := types.NewReceipt(nil, tx.Nonce()%2 == 1, 0)
+		receipt.Logs = c.logs[tx.Hash()]
+		receipts = append(receipts, receipt)
+	}
+
+	block := types.NewBlock(header, txs, nil, receipts)
+	c.blocks = append(c.blocks, block)
+	c.known[block.Hash()] = block
+	c.receipts[block.Hash()] = receipts
+}
+
+func TestVoteIndex(t *testing.T) {
+	t.Parallel()
+
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	owner1 := crypto.PubkeyToAddress(key1.PublicKey)
+	owner2 := crypto.PubkeyToAddress(key2.PublicKey)
+	proposal1 := common.HexToAddress("0x10")
+	proposal2 := common.HexToAddress("0x11")
+	fake := common.HexToAddress("0x12")
+	proposal3 := common.HexToAddress("0x13")
+	treasury2 := common.HexToAddress("0x20")
+
+	chain := newTestVoteChain()
+	signer := types.MakeSigner(chain.ChainConfig(), big.NewInt(1))
+
+	tx := func(key_nonce int, to common.Address, data []byte) *types.Transaction {
+		key := key1
+		if key_nonce >= 10 {
+			key = key2
+		}
+		tx, err := types.SignTx(
+			types.NewTransaction(uint64(key_nonce%10), to, common.Big0, 100000, common.Big1, data),
+			signer, key)
+		assert.Empty(t, err)
+		return tx
+	}
+
+	chain.add(0)
+	chain.add(0,
+		chain.event(tx(0, energi_params.Nuclear_Treasury, nil),
+			energi_params.Nuclear_Treasury,
+			[]common.Hash{upgradeProposalID, common.HexToHash("0xabc")},
+			proposal1),
+		chain.event(tx(2, energi_params.Nuclear_Treasury, nil),
+			energi_params.Nuclear_TreasuryV1,
+			[]common.Hash{budgetProposalID, common.HexToHash("0x1")},
+			proposal2),
+		// not a governance contract
+		chain.event(tx(4, fake, nil),
+			fake,
+			[]common.Hash{budgetProposalID, common.HexToHash("0x2")},
+			fake),
+		tx(6, proposal1, voteAcceptID),
+		// failed
+		tx(11, proposal1, voteRejectID),
+		// not a vote
+		tx(12, proposal2, []byte{0x01}),
+		// not a proposal
+		tx(14, fake, voteAcceptID),
+	)
+	chain.add(0, tx(16, proposal2, voteRejectID))
+
+	db := ethdb.NewMemDatabase()
+	index := newVoteIndex(db)
+	done, err := index.update(chain, voteIndexBatch)
+	assert.Empty(t, err)
+	assert.True(t, done)
+
+	votes, next := index.snapshot()
+	assert.Equal(t, uint64(3), next)
+	assert.Equal(t, []ProposalVote{
+		{Proposal: proposal1, Owner: owner1, Accepted: true, Block: 1, TxHash: chain.blocks[1].Transactions()[3].Hash()},
+		{Proposal: proposal2, Owner: owner2, Accepted: false, Block: 2, TxHash: chain.blocks[2].Transactions()[0].Hash()},
+	}, votes)
+
+	// Persisted
+	restored, restored_next := newVoteIndex(db).snapshot()
+	assert.Equal(t, votes, restored)
+	assert.Equal(t, next, restored_next)
+
+	// Incremental in batches, including the Treasury upgrade
+	chain.add(0, chain.event(tx(8, energi_params.Nuclear_Treasury, nil),
+		energi_params.Nuclear_Treasury,
+		[]common.Hash{upgradedID, treasury2.Hash()},
+		common.HexToAddress("0x30")))
+	chain.add(0,
+		chain.event(tx(18, energi_params.Nuclear_Treasury, nil),
+			treasury2,
+			[]common.Hash{budgetProposalID, common.HexToHash("0x3")},
+			proposal3),
+		tx(20, proposal3, voteAcceptID))
+
+	done, err = index.update(chain, 1)
+	assert.Empty(t, err)
+	assert.False(t, done)
+
+	done, err = index.update(chain, voteIndexBatch)
+	assert.Empty(t, err)
+	assert.True(t, done)
+
+	more, _ := index.snapshot()
+	assert.Equal(t, 3, len(more))
+	assert.Equal(t, proposal3, more[2].Proposal)
+	assert.Equal(t, uint64(4), more[2].Block)
+	assert.Equal(t, 2, len(votes))
+
+	// Reorganization
+	chain.blocks = chain.blocks[:2]
+	chain.add(1)
+	chain.add(1)
+	chain.add(1, tx(18, proposal1, voteAcceptID))
+	_, err = index.update(chain, voteIndexBatch)
+	assert.Empty(t, err)
+
+	reorg, next := index.snapshot()
+	assert.Equal(t, uint64(5), next)
+	assert.Equal(t, 2, len(reorg))
+	assert.Equal(t, owner1, reorg[0].Owner)
+	assert.Equal(t, owner2, reorg[1].Owner)
+	assert.Equal(t, uint64(4), reorg[1].Block)
+	assert.Equal(t, 3, len(more))
+	assert.Equal(t, uint64(4), more[2].Block)
+
+	restored, _ = newVoteIndex(db).snapshot()
+	assert.Equal(t, reorg, restored)
+
+	// Unknown previous chain
+	stale := newVoteIndex(db)
+	chain.blocks = chain.blocks[:1]
+	chain.known = map[common.Hash]*types.Block{chain.blocks[0].Hash(): chain.blocks[0]}
+	chain.add(2, tx(20, proposal1, voteAcceptID))
+	_, err = stale.update(chain, voteIndexBatch)
+	assert.Empty(t, err)
+
+	rebuilt, next := stale.snapshot()
+	assert.Equal(t, uint64(2), next)
+	assert.Empty(t, rebuilt)
+}
+
+func TestVoteIndexLoop(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	proposal := common.HexToAddress("0x10")
+
+	chain := newTestVoteChain()
+	signer := types.MakeSigner(chain.ChainConfig(), big.NewInt(1))
+
+	tx := func(nonce uint64, to common.Address, data []byte) *types.Transaction {
+		tx, err := types.SignTx(
+			types.NewTransaction(nonce, to, common.Big0, 100000, common.Big1, data),
+			signer, key)
+		assert.Empty(t, err)
+		return tx
+	}
+
+	chain.add(0)
+	chain.add(0, chain.event(tx(0, energi_params.Nuclear_Treasury, nil),
+		energi_params.Nuclear_Treasury,
+		[]common.Hash{upgradeProposalID, common.HexToHash("0xabc")},
+		proposal))
+
+	index := newVoteIndex(ethdb.NewMemDatabase())
+	index.start(chain)
+	index.start(chain)
+
+	wait := func(next uint64) []ProposalVote {
+		for i := 0; i < 100; i++ {
+			if votes, n := index.snapshot(); n == next {
+				return votes
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("vote index has not reached %d", next)
+		return nil
+	}
+
+	assert.Empty(t, wait(2))
+
+	chain.add(0, tx(2, proposal, voteAcceptID))
+	chain.feed.Send(core.ChainHeadEvent{Block: chain.CurrentBlock()})
+
+	votes := wait(3)
+	assert.Equal(t, 1, len(votes))
+	assert.Equal(t, proposal, votes[0].Proposal)
+}
@@ -0,0 +1,310 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"nuclear/core/nuclear/common"
+)
+
+const (
+	artifactCreationExt = ".bin"
+	artifactRuntimeExt  = ".bin-runtime"
+	artifactStorageExt  = ".storage.json"
+)
+
+// StorageEntry is a state variable of the solc storage layout output.
+type StorageEntry struct {
+	Label  string `json:"label"`
+	Offset uint64 `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+// StorageType is a type description of the solc storage layout output.
+type StorageType struct {
+	Label         string `json:"label"`
+	NumberOfBytes string `json:"numberOfBytes"`
+}
+
+// StorageLayout is the solc "storageLayout" output of a contract.
+type StorageLayout struct {
+	Storage []StorageEntry         `json:"storage"`
+	Types   map[string]StorageType `json:"types"`
+}
+
+// CompatibleWith checks that the layout keeps all the state variables of
+// the current layout in place. New variables may only be appended.
+func (l *StorageLayout) CompatibleWith(current *StorageLayout) error {
+	for i, old := range current.Storage {
+		if i >= len(l.Storage) {
+			return fmt.Errorf("state variable %s is removed", old.Label)
+		}
+
+		curr := l.Storage[i]
+		if curr.Slot != old.Slot || curr.Offset != old.Offset {
+			return fmt.Errorf("state variable %s is moved from slot %s:%d to %s:%d",
+				old.Label, old.Slot, old.Offset, curr.Slot, curr.Offset)
+		}
+
+		old_type, curr_type := current.Types[old.Type], l.Types[curr.Type]
+		if curr_type != old_type {
+			return fmt.Errorf("state variable %s changes type from %s to %s",
+				old.Label, old_type.Label, curr_type.Label)
+		}
+	}
+
+	return nil
+}
+
+// ContractArtifact is the solc output of a single contract.
+type ContractArtifact struct {
+	Name     string
+	Creation []byte
+	Runtime  []byte
+	Layout   *StorageLayout
+}
+
+// ContractArtifacts are the compiled contracts by name.
+type ContractArtifacts map[string]*ContractArtifact
+
+// LoadArtifacts reads the solc output directory as produced by the
+// energi-contracts make target: <Name>.bin, <Name>.bin-runtime and the
+// <Name>.storage.json storage layout.
+func LoadArtifacts(dir string) (ContractArtifacts, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(ContractArtifacts)
+	get := func(name string) *ContractArtifact {
+		if a, ok := ret[name]; ok {
+			return a
+		}
+		a := &ContractArtifact{Name: name}
+		ret[name] = a
+		return a
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		file := f.Name()
+		path := filepath.Join(dir, file)
+
+		switch {
+		case strings.HasSuffix(file, artifactRuntimeExt):
+			code, err := readArtifactCode(path)
+			if err != nil {
+				return nil, err
+			}
+			get(strings.TrimSuffix(file, artifactRuntimeExt)).Runtime = code
+		case strings.HasSuffix(file, artifactCreationExt):
+			code, err := readArtifactCode(path)
+			if err != nil {
+				return nil, err
+			}
+			get(strings.TrimSuffix(file, artifactCreationExt)).Creation = code
+		case strings.HasSuffix(file, artifactStorageExt):
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			layout := &StorageLayout{}
+			if err := json.Unmarshal(data, layout); err != nil {
+				return nil, fmt.Errorf("invalid storage layout %s: %v", path, err)
+			}
+			get(strings.TrimSuffix(file, artifactStorageExt)).Layout = layout
+		}
+	}
+
+	// Interfaces and abstract contracts have no code
+	for name, a := range ret {
+		if len(a.Creation) == 0 && len(a.Runtime) == 0 {
+			delete(ret, name)
+		}
+	}
+
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no contract artifacts in %s", dir)
+	}
+
+	return ret, nil
+}
+
+func readArtifactCode(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hex_code := strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+	code, err := hex.DecodeString(hex_code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode %s: %v", path, err)
+	}
+
+	return code, nil
+}
+
+// SplitMetadata separates the CBOR encoded metadata which solc appends to
+// the runtime code. The metadata includes the source hash and so it differs
+// for any change of the sources, including comments and file paths.
+func SplitMetadata(code []byte) ([]byte, []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+
+	size := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - size
+
+	// Metadata is a CBOR map, i.e. of major type 5
+	if size == 0 || start < 0 || code[start]>>5 != 5 {
+		return code, nil
+	}
+
+	return code[:start], code[start:]
+}
+
+// CodeMatch is the artifact which matches the deployed code.
+type CodeMatch struct {
+	Address         common.Address
+	Contract        string `json:",omitempty"`
+	Matched         bool
+	MetadataMatched bool
+}
+
+// Match compares the deployed runtime code with the artifact of the named
+// contract only. The metadata is compared separately.
+func (artifacts ContractArtifacts) Match(name string, code []byte) (ret CodeMatch, artifact *ContractArtifact) {
+	artifact, ok := artifacts[name]
+	if !ok || len(code) == 0 {
+		return ret, nil
+	}
+
+	stripped, metadata := SplitMetadata(code)
+
+	if len(artifact.Runtime) > 0 {
+		compiled, compiled_metadata := SplitMetadata(artifact.Runtime)
+		ret.Matched = bytes.Equal(compiled, stripped)
+		ret.MetadataMatched = ret.Matched && bytes.Equal(compiled_metadata, metadata)
+	} else {
+		// The creation code is the init code followed by the runtime code
+		compiled, compiled_metadata := SplitMetadata(artifact.Creation)
+		ret.Matched = bytes.HasSuffix(compiled, stripped)
+		ret.MetadataMatched = ret.Matched && bytes.Equal(compiled_metadata, metadata)
+	}
+
+	if !ret.Matched {
+		return ret, nil
+	}
+
+	ret.Contract = name
+	return ret, artifact
+}
+
+// Find looks for the artifact of the deployed runtime code among all the
+// contracts. Exact matches including the metadata are preferred.
+func (artifacts ContractArtifacts) Find(code []byte) (ret CodeMatch, artifact *ContractArtifact) {
+	names := make([]string, 0, len(artifacts))
+	for name := range artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		match, a := artifacts.Match(name, code)
+		if !match.Matched || (ret.Matched && !match.MetadataMatched) {
+			continue
+		}
+
+		ret, artifact = match, a
+
+		if match.MetadataMatched {
+			break
+		}
+	}
+
+	return
+}
+
+// UpgradeVerification is the summary of the upgrade proposal checks.
+type UpgradeVerification struct {
+	Proxy             common.Address
+	Proposal          common.Address
+	Current           CodeMatch
+	Proposed          CodeMatch
+	StorageChecked    bool
+	StorageCompatible bool
+	Passed            bool
+	Notes             []string
+}
+
+// VerifyUpgrade checks the runtime code of the current and the proposed
+// implementation against the artifacts. The proposed implementation must
+// match the artifact of the contract behind the proxy and keep the storage
+// layout of the current one. The current implementation may match any
+// artifact, it only provides the layout.
+func VerifyUpgrade(artifacts ContractArtifacts, contract string, current, proposed []byte) *UpgradeVerification {
+	ret := &UpgradeVerification{}
+
+	var current_artifact, proposed_artifact *ContractArtifact
+	ret.Current, current_artifact = artifacts.Find(current)
+	ret.Proposed, proposed_artifact = artifacts.Match(contract, proposed)
+
+	if _, ok := artifacts[contract]; !ok {
+		ret.Notes = append(ret.Notes, fmt.Sprintf("no artifact of contract %s", contract))
+	} else if !ret.Proposed.Matched {
+		ret.Notes = append(ret.Notes, fmt.Sprintf("proposed implementation does not match %s", contract))
+	} else if !ret.Proposed.MetadataMatched {
+		ret.Notes = append(ret.Notes, "proposed implementation metadata differs, sources may be modified")
+	}
+
+	if !ret.Current.Matched {
+		ret.Notes = append(ret.Notes, "current implementation does not match any artifact")
+	}
+
+	switch {
+	case current_artifact != nil && current_artifact.Layout == nil:
+		ret.Notes = append(ret.Notes, fmt.Sprintf("no storage layout of %s", current_artifact.Name))
+	case proposed_artifact != nil && proposed_artifact.Layout == nil:
+		ret.Notes = append(ret.Notes, fmt.Sprintf("no storage layout of %s", proposed_artifact.Name))
+	case current_artifact != nil && proposed_artifact != nil:
+		ret.StorageChecked = true
+
+		if err := proposed_artifact.Layout.CompatibleWith(current_artifact.Layout); err != nil {
+			ret.Notes = append(ret.Notes, err.Error())
+		} else {
+			ret.StorageCompatible = true
+		}
+	}
+
+	ret.Passed = ret.Proposed.Matched && ret.StorageChecked && ret.StorageCompatible
+
+	return ret
+}
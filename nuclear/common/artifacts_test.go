@@ -0,0 +1,188 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testInitCode = "6080604052348015600f57600080fd5b50"
+	// PUSH32 0, POP, STOP
+	testRuntimeV1 = "7f" + "0000000000000000000000000000000000000000000000000000000000000000" + "5000"
+	// PUSH1 1, POP, STOP
+	testRuntimeV2 = "60015000"
+	testMetaV1    = "a1010a0003"
+	testMetaV2    = "a1010b0003"
+	testNonZero   = "1111111111111111111111111111111111111111111111111111111111111111"
+
+	testLayoutV1 = `{"storage": [
+		{"label": "proxy", "offset": 0, "slot": "0", "type": "t_address"}
+	], "types": {"t_address": {"label": "address", "numberOfBytes": "20"}}}`
+	testLayoutV2 = `{"storage": [
+		{"label": "proxy", "offset": 0, "slot": "0", "type": "t_address"},
+		{"label": "count", "offset": 0, "slot": "1", "type": "t_uint256"}
+	], "types": {
+		"t_address": {"label": "address", "numberOfBytes": "20"},
+		"t_uint256": {"label": "uint256", "numberOfBytes": "32"}
+	}}`
+	testLayoutBad = `{"storage": [
+		{"label": "count", "offset": 0, "slot": "0", "type": "t_uint256"}
+	], "types": {"t_uint256": {"label": "uint256", "numberOfBytes": "32"}}}`
+)
+
+func mustHex(s string) []byte {
+	ret, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+func writeArtifacts(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "artifacts")
+	assert.Empty(t, err)
+
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+		assert.Empty(t, err)
+	}
+
+	return dir
+}
+
+func TestSplitMetadata(t *testing.T) {
+	t.Parallel()
+
+	code, meta := SplitMetadata(mustHex(testRuntimeV2 + testMetaV1))
+	assert.Equal(t, mustHex(testRuntimeV2), code)
+	assert.Equal(t, mustHex(testMetaV1), meta)
+
+	code, meta = SplitMetadata(mustHex(testRuntimeV2))
+	assert.Equal(t, mustHex(testRuntimeV2), code)
+	assert.Empty(t, meta)
+}
+
+func TestVerifyUpgrade(t *testing.T) {
+	t.Parallel()
+
+	dir := writeArtifacts(t, map[string]string{
+		"ContractV1.bin-runtime":  testRuntimeV1 + testMetaV1 + "\n",
+		"ContractV1.bin":          testInitCode + testRuntimeV1 + testMetaV1,
+		"ContractV1.storage.json": testLayoutV1,
+		"ContractV2.bin":          "0x" + testInitCode + testRuntimeV2 + testMetaV1,
+		"ContractV2.storage.json": testLayoutV2,
+		"ContractV3.bin-runtime":  testRuntimeV2 + testMetaV2,
+		"ContractV3.storage.json": testLayoutBad,
+		"ContractV4.bin-runtime":  testRuntimeV2 + testMetaV2,
+		"IContract.bin":           "",
+		"IContract.abi":           "[]",
+	})
+	defer os.RemoveAll(dir)
+
+	artifacts, err := LoadArtifacts(dir)
+	assert.Empty(t, err)
+	assert.Len(t, artifacts, 4)
+
+	deployed_v1 := mustHex(testRuntimeV1 + testMetaV1)
+
+	match, _ := artifacts.Match("ContractV1", deployed_v1)
+	assert.True(t, match.Matched)
+	assert.True(t, match.MetadataMatched)
+	assert.Equal(t, "ContractV1", match.Contract)
+
+	// No placeholders for the immutable values
+	match, _ = artifacts.Match("ContractV1", mustHex(
+		strings.Replace(testRuntimeV1, strings.Repeat("0", 64), testNonZero, 1)+testMetaV1))
+	assert.False(t, match.Matched)
+
+	// Only the named contract is compared
+	match, _ = artifacts.Match("ContractV1", mustHex(testRuntimeV2+testMetaV1))
+	assert.False(t, match.Matched)
+	match, _ = artifacts.Match("Unknown", deployed_v1)
+	assert.False(t, match.Matched)
+
+	// The creation code ends with the runtime code
+	match, _ = artifacts.Match("ContractV2", mustHex(testRuntimeV2+testMetaV1))
+	assert.True(t, match.Matched)
+	assert.True(t, match.MetadataMatched)
+	match, _ = artifacts.Match("ContractV2", mustHex("6001"+testMetaV1))
+	assert.False(t, match.Matched)
+
+	// Only the metadata differs
+	match, _ = artifacts.Match("ContractV1", mustHex(testRuntimeV1+"a1010c0003"))
+	assert.True(t, match.Matched)
+	assert.False(t, match.MetadataMatched)
+
+	// Exact match is preferred
+	match, _ = artifacts.Find(mustHex(testRuntimeV2 + testMetaV1))
+	assert.Equal(t, "ContractV2", match.Contract)
+	assert.True(t, match.MetadataMatched)
+
+	match, _ = artifacts.Find(mustHex("60025000" + testMetaV1))
+	assert.False(t, match.Matched)
+
+	// Compatible upgrade
+	res := VerifyUpgrade(artifacts, "ContractV2", deployed_v1, mustHex(testRuntimeV2+testMetaV1))
+	assert.True(t, res.Passed)
+	assert.True(t, res.StorageChecked)
+	assert.True(t, res.StorageCompatible)
+	assert.Equal(t, "ContractV1", res.Current.Contract)
+	assert.Equal(t, "ContractV2", res.Proposed.Contract)
+
+	// Code of another contract
+	res = VerifyUpgrade(artifacts, "ContractV1", deployed_v1, mustHex(testRuntimeV2+testMetaV1))
+	assert.False(t, res.Passed)
+	assert.False(t, res.Proposed.Matched)
+
+	res = VerifyUpgrade(artifacts, "Unknown", deployed_v1, mustHex(testRuntimeV2+testMetaV1))
+	assert.False(t, res.Passed)
+	assert.Contains(t, res.Notes, "no artifact of contract Unknown")
+
+	// Broken storage layout
+	res = VerifyUpgrade(artifacts, "ContractV3", deployed_v1, mustHex(testRuntimeV2+testMetaV2))
+	assert.False(t, res.Passed)
+	assert.True(t, res.StorageChecked)
+	assert.False(t, res.StorageCompatible)
+	assert.Contains(t, res.Notes, "state variable proxy changes type from address to uint256")
+
+	// Missing storage layout
+	res = VerifyUpgrade(artifacts, "ContractV4", deployed_v1, mustHex(testRuntimeV2+testMetaV2))
+	assert.False(t, res.Passed)
+	assert.True(t, res.Proposed.Matched)
+	assert.False(t, res.StorageChecked)
+	assert.Contains(t, res.Notes, "no storage layout of ContractV4")
+
+	// Unknown code
+	res = VerifyUpgrade(artifacts, "ContractV2", deployed_v1, mustHex("60025000"))
+	assert.False(t, res.Passed)
+	assert.False(t, res.StorageChecked)
+
+	// Unknown current code has no storage layout to check against
+	res = VerifyUpgrade(artifacts, "ContractV2", nil, mustHex(testRuntimeV2+testMetaV1))
+	assert.False(t, res.Passed)
+	assert.True(t, res.Proposed.Matched)
+	assert.False(t, res.StorageChecked)
+	assert.Contains(t, res.Notes, "current implementation does not match any artifact")
+}
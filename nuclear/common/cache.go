@@ -25,8 +25,13 @@ import (
 
 	eth_common "nuclear/core/nuclear/common"
 	eth_types "nuclear/core/nuclear/core/types"
+
+	lru "github.com/hashicorp/golang-lru"
 )
 
+// cacheHistoryLimit is the number of per block entries kept by a CacheStorage.
+const cacheHistoryLimit = 32
+
 // ErrInvalidData is returned if the CacheQuery function returns a null result
 var ErrInvalidData = errors.New("Invalid data returned by the CacheQuery func")
 
@@ -40,11 +45,13 @@ type cacheState struct {
 }
 
 // CacheStorage is a storage that is held by the client that wants to cache specific
-// data.
+// data. The entry of the current block is kept separately from the bounded
+// history of entries keyed by block hash.
 type CacheStorage struct {
 	mtx      sync.RWMutex
 	state    unsafe.Pointer
 	updating int32
+	history  *lru.Cache
 }
 
 // CacheChain defines the method(s) needed by the cache implementation to access
@@ -57,6 +64,7 @@ type CacheChain interface {
 // NewCacheStorage creates a new CacheStorage instance.
 func NewCacheStorage() *CacheStorage {
 	c := new(CacheStorage)
+	c.history, _ = lru.New(cacheHistoryLimit)
 	state := &cacheState{}
 	atomic.StorePointer(&c.state, unsafe.Pointer(state))
 	return c
@@ -87,6 +95,10 @@ func (c *CacheStorage) Get(chain CacheChain, source CacheQuery) (interface{}, er
 
 			state = &cacheState{blockhash, entry}
 			atomic.StorePointer(&c.state, unsafe.Pointer(state))
+
+			if entry != nil {
+				c.history.Add(blockhash, entry)
+			}
 		}
 
 		if state.entry == nil {
@@ -122,3 +134,39 @@ func (c *CacheStorage) Get(chain CacheChain, source CacheQuery) (interface{}, er
 
 	return state.entry, nil
 }
+
+// GetAt returns the data entry at the specific block. The current block is
+// handled by Get, while the other ones are kept in the bounded history.
+// The block must be on the canonical chain as the source queries by number.
+func (c *CacheStorage) GetAt(chain CacheChain, block *eth_types.Block, source CacheQuery) (interface{}, error) {
+	if block == nil || block.Hash() == chain.CurrentBlock().Hash() {
+		return c.Get(chain, source)
+	}
+
+	blockhash := block.Hash()
+
+	if entry, ok := c.history.Get(blockhash); ok {
+		return entry, nil
+	}
+
+	// NOTE: sources are not expected to run concurrently
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if entry, ok := c.history.Get(blockhash); ok {
+		return entry, nil
+	}
+
+	entry, err := source(block.Number())
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, ErrInvalidData
+	}
+
+	c.history.Add(blockhash, entry)
+
+	return entry, nil
+}
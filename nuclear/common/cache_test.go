@@ -36,6 +36,10 @@ func (f *fakeChain) CurrentBlock() *types.Block {
 	}, nil, nil, nil)
 }
 
+func (f *fakeChain) IsPublicService() bool {
+	return true
+}
+
 // TestDataCache tests the cache's setter and getter methods.
 func TestDataCache(t *testing.T) {
 	chain := new(fakeChain)
@@ -148,4 +152,71 @@ func TestDataCache(t *testing.T) {
 		}
 	})
 
+	t.Run("Test_historical_data", func(t *testing.T) {
+		newData = "current"
+		if _, err := cacheInstance.Get(chain, cacheQueryfunc); err != nil {
+			t.Fatalf("expected no error but found %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		head := chain.CurrentBlock()
+		data, err := cacheInstance.GetAt(chain, head, cacheQueryfunc)
+		if err != nil {
+			t.Fatalf("expected no error but found %v", err)
+		}
+		if !reflect.DeepEqual(data, newData) {
+			t.Fatalf("expected the current data for the head block")
+		}
+
+		calls := 0
+		historicalQueryfunc := func(num *big.Int) (interface{}, error) {
+			calls++
+			return num.Uint64(), nil
+		}
+
+		blocks := make([]*types.Block, cacheHistoryLimit+1)
+		for i := range blocks {
+			blocks[i] = types.NewBlock(&types.Header{
+				Number: big.NewInt(int64(i + 1)),
+			}, nil, nil, nil)
+		}
+
+		for i, block := range blocks {
+			data, err := cacheInstance.GetAt(chain, block, historicalQueryfunc)
+			if err != nil {
+				t.Fatalf("expected no error but found %v", err)
+			}
+			if data != uint64(i+1) {
+				t.Fatalf("expected the data of block %d but found %v", i+1, data)
+			}
+		}
+		if calls != len(blocks) {
+			t.Fatalf("expected %d queries but found %d", len(blocks), calls)
+		}
+
+		// The latest entries are cached
+		if _, err := cacheInstance.GetAt(chain, blocks[len(blocks)-1], historicalQueryfunc); err != nil {
+			t.Fatalf("expected no error but found %v", err)
+		}
+		if calls != len(blocks) {
+			t.Fatalf("expected a cached entry")
+		}
+
+		// The history is bounded
+		if _, err := cacheInstance.GetAt(chain, blocks[0], historicalQueryfunc); err != nil {
+			t.Fatalf("expected no error but found %v", err)
+		}
+		if calls != len(blocks)+1 {
+			t.Fatalf("expected an evicted entry")
+		}
+
+		// Invalid data is not cached
+		if _, err := cacheInstance.GetAt(chain, types.NewBlock(&types.Header{
+			Number: big.NewInt(1000),
+		}, nil, nil, nil), func(num *big.Int) (interface{}, error) {
+			return nil, nil
+		}); err != ErrInvalidData {
+			t.Fatalf("expected error (%v) but found (%v)", ErrInvalidData, err)
+		}
+	})
 }
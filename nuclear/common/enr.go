@@ -0,0 +1,292 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/rawdb"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/p2p/enode"
+	"nuclear/core/nuclear/p2p/enr"
+	"nuclear/core/nuclear/params"
+	"nuclear/core/nuclear/rlp"
+)
+
+const (
+	// MasternodeENRPrefix is the prefix of the text form of node records.
+	MasternodeENRPrefix = "enr:"
+
+	// maxMasternodeIPs limits the number of extra addresses in a record.
+	maxMasternodeIPs = 8
+)
+
+// loopbackMasternodes is set if the masternodes may use loopback addresses.
+var loopbackMasternodes int32
+
+// AllowLoopbackMasternodes makes the loopback addresses of the masternode
+// records valid. It is meant only for developer networks on a single host.
+func AllowLoopbackMasternodes(allow bool) {
+	var value int32
+	if allow {
+		value = 1
+	}
+	atomic.StoreInt32(&loopbackMasternodes, value)
+}
+
+// MasternodeIPs is the ENR entry with additional IPv4 and IPv6 addresses
+// the masternode is reachable at. All of them share the record ports.
+type MasternodeIPs []net.IP
+
+func (v MasternodeIPs) ENRKey() string { return "mnips" }
+
+// EncodeRLP implements rlp.Encoder.
+func (v MasternodeIPs) EncodeRLP(w io.Writer) error {
+	list := make([][]byte, len(v))
+	for i, ip := range v {
+		if ip4 := ip.To4(); ip4 != nil {
+			list[i] = ip4
+		} else {
+			list[i] = ip.To16()
+		}
+	}
+	return rlp.Encode(w, list)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (v *MasternodeIPs) DecodeRLP(s *rlp.Stream) error {
+	var list [][]byte
+	if err := s.Decode(&list); err != nil {
+		return err
+	}
+	if len(list) > maxMasternodeIPs {
+		return fmt.Errorf("too many masternode addresses: %d", len(list))
+	}
+	res := make(MasternodeIPs, len(list))
+	for i, ip := range list {
+		if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+			return fmt.Errorf("invalid IP address, want 4 or 16 bytes: %v", ip)
+		}
+		res[i] = net.IP(ip)
+	}
+	*v = res
+	return nil
+}
+
+// MasternodePubkey returns the public key in the compressed form used by
+// the masternode registry.
+func MasternodePubkey(pk *ecdsa.PublicKey) (res [2][32]byte) {
+	buf := crypto.CompressPubkey(pk)
+	copy(res[0][:], buf[:32])
+	copy(res[1][:], buf[32:33])
+	return
+}
+
+// IsPublicIP checks if the address can be announced for a masternode.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return !(ip4[0] == byte(10) ||
+			(ip4[0] == byte(192) && ip4[1] == byte(168)) ||
+			(ip4[0] == byte(172) && (ip4[1]&0xF0) == byte(16)))
+	}
+
+	// Unique local addresses, fc00::/7
+	return (ip[0] & 0xFE) != 0xFC
+}
+
+// DecodeMasternodeENR decodes a RLP encoded record and verifies its signature.
+func DecodeMasternodeENR(data []byte) (*enode.Node, error) {
+	var r enr.Record
+	if err := rlp.DecodeBytes(data, &r); err != nil {
+		return nil, err
+	}
+	return enode.New(enode.ValidSchemes, &r)
+}
+
+// EncodeMasternodeENR returns the RLP encoding of the node record.
+func EncodeMasternodeENR(n *enode.Node) ([]byte, error) {
+	return rlp.EncodeToBytes(n.Record())
+}
+
+// ParseMasternodeENR parses the "enr:" text form of a signed node record.
+func ParseMasternodeENR(text string) (*enode.Node, error) {
+	if !strings.HasPrefix(text, MasternodeENRPrefix) {
+		return nil, errors.New("missing 'enr:' prefix")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(text[len(MasternodeENRPrefix):])
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeMasternodeENR(data)
+}
+
+// MasternodeENRText returns the "enr:" text form of the node record.
+func MasternodeENRText(n *enode.Node) string {
+	data, err := EncodeMasternodeENR(n)
+	if err != nil {
+		log.Error("Failed to encode ENR", "err", err)
+		return ""
+	}
+
+	return MasternodeENRPrefix + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// IsMasternodeENR checks that the record is signed by the registered
+// masternode key.
+func IsMasternodeENR(n *enode.Node, pubkey [2][32]byte) bool {
+	if n == nil || n.Pubkey() == nil {
+		return false
+	}
+
+	return MasternodePubkey(n.Pubkey()) == pubkey
+}
+
+// MasternodeAddrs returns all public addresses of the record: the primary
+// "ip" entry goes first followed by the extra masternode addresses. Loopback
+// addresses are included only if allowed by AllowLoopbackMasternodes.
+func MasternodeAddrs(n *enode.Node) (res []net.IP) {
+	loopback := atomic.LoadInt32(&loopbackMasternodes) != 0
+
+	add := func(ip net.IP) {
+		if !IsPublicIP(ip) && !(loopback && ip != nil && ip.IsLoopback()) {
+			return
+		}
+		for _, v := range res {
+			if v.Equal(ip) {
+				return
+			}
+		}
+		res = append(res, ip)
+	}
+
+	add(n.IP())
+
+	var extra MasternodeIPs
+	if err := n.Load(&extra); err == nil {
+		for _, ip := range extra {
+			add(ip)
+		}
+	}
+
+	return
+}
+
+// MasternodeDialNodes returns a dial candidate for every address of the record.
+func MasternodeDialNodes(n *enode.Node) []*enode.Node {
+	addrs := MasternodeAddrs(n)
+	res := make([]*enode.Node, 0, len(addrs))
+
+	for _, ip := range addrs {
+		if ip.Equal(n.IP()) {
+			res = append(res, n)
+			continue
+		}
+		res = append(res, enode.NewV4(n.Pubkey(), ip, n.TCP(), n.UDP()))
+	}
+
+	return res
+}
+
+// MasternodeENR returns the stored record of the masternode, if it is still
+// signed by the registered key.
+func MasternodeENR(
+	db rawdb.DatabaseReader,
+	mn common.Address,
+	pubkey [2][32]byte,
+) *enode.Node {
+	data := rawdb.ReadMasternodeENR(db, mn)
+	if len(data) == 0 {
+		return nil
+	}
+
+	n, err := DecodeMasternodeENR(data)
+	if err != nil {
+		log.Debug("Invalid stored masternode ENR", "mn", mn, "err", err)
+		return nil
+	}
+
+	if !IsMasternodeENR(n, pubkey) {
+		return nil
+	}
+
+	return n
+}
+
+// MasternodeNodes returns dial candidates of the masternode. The stored
+// record takes precedence over the registry IPv4 address which is used as
+// the last resort.
+func MasternodeNodes(
+	db rawdb.DatabaseReader,
+	mn common.Address,
+	ipv4address uint32,
+	pubkey [2][32]byte,
+	cfg *params.ChainConfig,
+) (res []*enode.Node) {
+	if n := MasternodeENR(db, mn, pubkey); n != nil {
+		res = MasternodeDialNodes(n)
+	}
+
+	if ipv4address != 0 {
+		if n := MastenodeEnode(ipv4address, pubkey, cfg); n != nil {
+			for _, v := range res {
+				if v.IP().Equal(n.IP()) {
+					return
+				}
+			}
+			res = append(res, n)
+		}
+	}
+
+	return
+}
+
+// StoreMasternodeENR saves the record unless a newer one is already known.
+func StoreMasternodeENR(
+	db ethdb.Database,
+	mn common.Address,
+	n *enode.Node,
+) bool {
+	if old := MasternodeENR(db, mn, MasternodePubkey(n.Pubkey())); old != nil && old.Seq() >= n.Seq() {
+		return false
+	}
+
+	data, err := EncodeMasternodeENR(n)
+	if err != nil {
+		log.Error("Failed to encode ENR", "err", err)
+		return false
+	}
+
+	rawdb.WriteMasternodeENR(db, mn, data)
+	return true
+}
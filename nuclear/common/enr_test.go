@@ -0,0 +1,154 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"testing"
+
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/p2p/enode"
+	"nuclear/core/nuclear/p2p/enr"
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signedMasternodeENR(
+	t *testing.T,
+	key *ecdsa.PrivateKey,
+	seq uint64,
+	ip net.IP,
+	extra ...net.IP,
+) *enode.Node {
+	var r enr.Record
+	r.SetSeq(seq)
+	r.Set(enr.IP(ip))
+	r.Set(enr.TCP(49797))
+	r.Set(enr.UDP(49797))
+	if len(extra) > 0 {
+		r.Set(MasternodeIPs(extra))
+	}
+	assert.NoError(t, enode.SignV4(&r, key))
+
+	n, err := enode.New(enode.ValidSchemes, &r)
+	assert.NoError(t, err)
+	return n
+}
+
+func TestMasternodeENR(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	pubkey := MasternodePubkey(&key.PublicKey)
+	mn := crypto.PubkeyToAddress(key.PublicKey)
+
+	ip6 := net.ParseIP("2001:db8::1")
+	n := signedMasternodeENR(t, key, 1, net.ParseIP("1.2.3.4"),
+		ip6, net.ParseIP("10.0.0.1"), net.ParseIP("1.2.3.4"))
+
+	// Text round trip
+	text := MasternodeENRText(n)
+	assert.Contains(t, text, MasternodeENRPrefix)
+	parsed, err := ParseMasternodeENR(text)
+	assert.NoError(t, err)
+	assert.Equal(t, n.ID(), parsed.ID())
+	assert.Equal(t, n.Seq(), parsed.Seq())
+
+	_, err = ParseMasternodeENR(text[len(MasternodeENRPrefix):])
+	assert.Error(t, err)
+	_, err = ParseMasternodeENR(text[:len(text)-4] + "AAAA")
+	assert.Error(t, err)
+
+	// Addresses: private and duplicate ones are skipped
+	addrs := MasternodeAddrs(parsed)
+	assert.Len(t, addrs, 2)
+	assert.True(t, addrs[0].Equal(net.ParseIP("1.2.3.4")))
+	assert.True(t, addrs[1].Equal(ip6))
+
+	dial := MasternodeDialNodes(parsed)
+	assert.Len(t, dial, 2)
+	for _, d := range dial {
+		assert.Equal(t, n.ID(), d.ID())
+		assert.Equal(t, 49797, d.TCP())
+	}
+	assert.True(t, dial[1].IP().Equal(ip6))
+
+	// Registry key match
+	assert.True(t, IsMasternodeENR(parsed, pubkey))
+	assert.False(t, IsMasternodeENR(parsed, MasternodePubkey(&other.PublicKey)))
+
+	// Persistence keeps the newest record
+	db := ethdb.NewMemDatabase()
+	assert.Nil(t, MasternodeENR(db, mn, pubkey))
+	assert.True(t, StoreMasternodeENR(db, mn, n))
+	assert.False(t, StoreMasternodeENR(db, mn, n))
+
+	newer := signedMasternodeENR(t, key, 2, ip6)
+	assert.True(t, StoreMasternodeENR(db, mn, newer))
+	assert.Equal(t, uint64(2), MasternodeENR(db, mn, pubkey).Seq())
+	assert.Nil(t, MasternodeENR(db, mn, MasternodePubkey(&other.PublicKey)))
+
+	// The stored record goes before the registry address
+	cfg := params.NuclearMainnetChainConfig
+	nodes := MasternodeNodes(db, mn, 0x05060708, pubkey, cfg)
+	assert.Len(t, nodes, 2)
+	assert.True(t, nodes[0].IP().Equal(ip6))
+	assert.True(t, nodes[1].IP().Equal(net.ParseIP("5.6.7.8")))
+
+	nodes = MasternodeNodes(db, mn, 0, pubkey, cfg)
+	assert.Len(t, nodes, 1)
+}
+
+// Not parallel as the setting is global
+func TestLoopbackMasternodes(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	n := signedMasternodeENR(t, key, 1, net.ParseIP("127.0.0.1"), net.ParseIP("10.0.0.1"))
+
+	assert.Empty(t, MasternodeAddrs(n))
+
+	AllowLoopbackMasternodes(true)
+	defer AllowLoopbackMasternodes(false)
+
+	dial := MasternodeDialNodes(n)
+	assert.Len(t, dial, 1)
+	assert.True(t, dial[0].IP().Equal(net.ParseIP("127.0.0.1")))
+	assert.Equal(t, 49797, dial[0].TCP())
+}
+
+func TestIsPublicIP(t *testing.T) {
+	t.Parallel()
+
+	for ip, exp := range map[string]bool{
+		"1.2.3.4":     true,
+		"127.0.0.1":   false,
+		"10.1.2.3":    false,
+		"192.168.1.1": false,
+		"172.16.0.1":  false,
+		"172.32.0.1":  true,
+		"0.0.0.0":     false,
+		"2001:db8::1": true,
+		"::1":         false,
+		"fe80::1":     false,
+		"fd00::1":     false,
+	} {
+		assert.Equal(t, exp, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
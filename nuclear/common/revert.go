@@ -0,0 +1,54 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+
+	ethereum "nuclear/core/nuclear"
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/crypto"
+)
+
+var (
+	// ErrReverted is returned for the calls reverted by the contract.
+	ErrReverted = errors.New("execution reverted")
+
+	// revertSelector prefixes the reason of the reverted calls.
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+)
+
+// RevertCaller distinguishes the reverted calls from the other failures.
+// The reverted calls have empty output or the ABI encoded reason, which is
+// never a valid return value as it is not aligned to words.
+type RevertCaller struct {
+	bind.ContractCaller
+}
+
+func (rc RevertCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	output, err := rc.ContractCaller.CallContract(ctx, msg, number)
+	if err != nil {
+		return nil, err
+	}
+	if len(output) == 0 || (len(output)%32 == 4 && bytes.HasPrefix(output, revertSelector)) {
+		return nil, ErrReverted
+	}
+	return output, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	ethereum "nuclear/core/nuclear"
+	"nuclear/core/nuclear/accounts/abi/bind"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCaller struct {
+	bind.ContractCaller
+	output []byte
+	err    error
+}
+
+func (fc *fakeCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	return fc.output, fc.err
+}
+
+func TestRevertCaller(t *testing.T) {
+	t.Parallel()
+
+	reason := append(append([]byte{}, revertSelector...), make([]byte, 96)...)
+	failure := errors.New("missing trie node")
+
+	for _, tc := range []struct {
+		output []byte
+		err    error
+		expErr error
+	}{
+		{make([]byte, 32), nil, nil},
+		{make([]byte, 64), nil, nil},
+		{nil, nil, ErrReverted},
+		{reason, nil, ErrReverted},
+		{nil, failure, failure},
+	} {
+		rc := RevertCaller{&fakeCaller{output: tc.output, err: tc.err}}
+		output, err := rc.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+		assert.Equal(t, tc.expErr, err)
+		if tc.expErr == nil {
+			assert.Equal(t, tc.output, output)
+		} else {
+			assert.Nil(t, output)
+		}
+	}
+}
@@ -47,8 +47,13 @@ type StakingStatusInfo struct {
 }
 
 type StakingAccount struct {
-	Account common.Address
-	Weight  uint64
+	Account   common.Address
+	Weight    uint64
+	NonceCap  uint64
+	MinWeight uint64
+	FromHour  uint8
+	ToHour    uint8
+	Staking   bool
 }
 
 func (a *EngineAPI) StakingStatus() *StakingStatusInfo {
@@ -81,10 +86,16 @@ func (a *EngineAPI) StakingStatus() *StakingStatusInfo {
 			log.Warn("PoS weight lookup failed", "err", err)
 			continue
 		}
+		policy := engine.StakingPolicy(acct)
 		res.TotalWeight += weight
 		res.Accounts = append(res.Accounts, StakingAccount{
-			Account: acct,
-			Weight:  weight,
+			Account:   acct,
+			Weight:    weight,
+			NonceCap:  policy.nonceCap(res.NonceCap),
+			MinWeight: policy.MinWeight,
+			FromHour:  policy.FromHour,
+			ToHour:    policy.ToHour,
+			Staking:   res.Miner && weight > 0 && policy.allowed(weight, engine.now()),
 		})
 	}
 
@@ -102,3 +113,15 @@ func (a *EngineAPI) SetNonceCap(nonce *uint64) (oldNonce uint64) {
 	a.engine.SetMinerNonceCap(*nonce)
 	return
 }
+
+// SetStakingPolicy limits staking of a single local account. Only the
+// policies of the miner config survive a restart.
+func (a *EngineAPI) SetStakingPolicy(policy AccountStakingPolicy) error {
+	return a.engine.SetStakingPolicy(policy)
+}
+
+// RemoveStakingPolicy makes the account use the global nonce cap again
+// until restart.
+func (a *EngineAPI) RemoveStakingPolicy(account common.Address) bool {
+	return a.engine.RemoveStakingPolicy(account)
+}
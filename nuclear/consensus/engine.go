@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -90,6 +91,13 @@ type Nuclear struct {
 	knownStakes  KnownStakes
 	nextKSPurge  uint64
 	txhashMap    *lru.Cache
+
+	blockRewards *lru.Cache
+
+	policies  map[common.Address]AccountStakingPolicy
+	policyMtx sync.RWMutex
+
+	sporkOverrides *SporkOverrides
 }
 
 func New(config *params.NuclearConfig, db ethdb.Database) *Nuclear {
@@ -135,6 +143,12 @@ func New(config *params.NuclearConfig, db ethdb.Database) *Nuclear {
 		return nil
 	}
 
+	block_rewards, err := lru.New(blockRewardsCacheSize)
+	if err != nil {
+		panic(err)
+		return nil
+	}
+
 	return &Nuclear{
 		config:       config,
 		db:           db,
@@ -153,6 +167,8 @@ func New(config *params.NuclearConfig, db ethdb.Database) *Nuclear {
 		nextKSPurge:  0,
 		txhashMap:    txhashMap,
 
+		blockRewards: block_rewards,
+
 		accountsFn:  func() []common.Address { return nil },
 		peerCountFn: func() int { return 0 },
 		isMiningFn:  func() bool { return false },
@@ -165,16 +181,28 @@ func (e *Nuclear) createEVM(
 	header *types.Header,
 	statedb *state.StateDB,
 ) *vm.EVM {
-	vmc := &vm.Config{}
+	return e.createEVMWithConfig(msg, chain, header, statedb, e.vmConfig(chain))
+}
 
+func (e *Nuclear) vmConfig(chain ChainReader) vm.Config {
 	if bc, ok := chain.(*core.BlockChain); ok {
-		vmc = bc.GetVMConfig()
+		return *bc.GetVMConfig()
 	}
 
+	return vm.Config{}
+}
+
+func (e *Nuclear) createEVMWithConfig(
+	msg types.Message,
+	chain ChainReader,
+	header *types.Header,
+	statedb *state.StateDB,
+	vmc vm.Config,
+) *vm.EVM {
 	// Only From() is used by fact
 	ctx := core.NewEVMContext(msg, header, chain.(core.ChainContext), &header.Coinbase)
 	ctx.GasLimit = e.xferGas
-	return vm.NewEVM(ctx, statedb, chain.Config(), *vmc)
+	return vm.NewEVM(ctx, statedb, chain.Config(), vmc)
 }
 
 // Author retrieves the Ethereum address of the account that minted the given
@@ -501,7 +529,7 @@ func (e *Nuclear) Finalize(
 		}
 	}
 
-	block, receipts, err := e.finalize(chain, header, state, txs, receipts)
+	block, receipts, err := e.finalize(chain, header, state, txs, receipts, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -521,15 +549,21 @@ func (e *Nuclear) Finalize(
 	return block, receipts, err
 }
 
+// finalize assembles the block. The reward breakdown of a pending block is
+// hypothetical, so it is kept only for the blocks to be imported or sealed.
 func (e *Nuclear) finalize(
 	chain ChainReader, header *types.Header, state *state.StateDB,
-	txs []*types.Transaction, receipts []*types.Receipt,
+	txs []*types.Transaction, receipts []*types.Receipt, pending bool,
 ) (*types.Block, []*types.Receipt, error) {
 	var err error
+	var rewards types.BlockRewards
 
 	// Do not finalize too early in mining
 	if (header.Coinbase != common.Address{}) {
-		txs, receipts, err = e.govFinalize(chain, header, state, txs, receipts)
+		txs, receipts, rewards, err = e.govFinalize(chain, header, state, txs, receipts)
+	}
+	if err == nil && rewards != nil && !pending {
+		e.blockRewards.Add(header.Root, rewards)
 	}
 
 	header.UncleHash = uncleHash
@@ -543,10 +577,12 @@ func (e *Nuclear) govFinalize(
 	state *state.StateDB,
 	txs types.Transactions,
 	receipts types.Receipts,
-) (types.Transactions, types.Receipts, error) {
+) (types.Transactions, types.Receipts, types.BlockRewards, error) {
+	var rewards types.BlockRewards
+
 	err := e.processConsensusGasLimits(chain, header, state)
 	if err == nil {
-		txs, receipts, err = e.processBlockRewards(chain, header, state, txs, receipts)
+		txs, receipts, rewards, err = e.processBlockRewards(chain, header, state, txs, receipts)
 	}
 	if err == nil {
 		err = e.processMasternodes(chain, header, state)
@@ -561,7 +597,48 @@ func (e *Nuclear) govFinalize(
 		err = e.finalizeMigration(chain, header, state, txs)
 	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
-	return txs, receipts, err
+	return txs, receipts, rewards, err
+}
+
+// FinalizePending assembles the block including the consensus transactions
+// for the coinbase set in the header. Unlike Finalize, the consensus
+// transactions are not expected among the given ones. It is meant for the
+// pending block only, as the actual coinbase is chosen at sealing.
+func (e *Nuclear) FinalizePending(
+	chain ChainReader, header *types.Header, state *state.StateDB,
+	txs []*types.Transaction, receipts []*types.Receipt,
+) (*types.Block, []*types.Receipt, error) {
+	return e.finalize(chain, header, state, txs, receipts, true)
+}
+
+// PreviewFinalize runs the finalization of a hypothetical block on top of
+// the parent with the given coinbase. The state must be the one of the parent
+// and it gets modified as if no regular transaction is included. The rewards
+// are not kept for BlockRewards, as the block is never imported.
+func (e *Nuclear) PreviewFinalize(
+	chain ChainReader,
+	parent *types.Header,
+	coinbase common.Address,
+	statedb *state.StateDB,
+) (*types.Header, types.Transactions, types.Receipts, types.BlockRewards, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+	}
+
+	if err := e.Prepare(chain, header); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	header.Coinbase = coinbase
+
+	txs, receipts, rewards, err := e.govFinalize(chain, header, statedb, nil, nil)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return header, txs, receipts, rewards, nil
 }
 
 // Seal generates a new sealing request for the given input block and pushes
@@ -671,7 +748,7 @@ func (e *Nuclear) recreateBlock(
 	header.GasUsed = *usedGas
 	header.Bloom = types.Bloom{}
 
-	block, receipts, err := e.finalize(chain, header, blstate, txs, receipts)
+	block, receipts, err := e.finalize(chain, header, blstate, txs, receipts, false)
 	return eth_consensus.NewSealResult(block, blstate, receipts), err
 }
 
@@ -784,6 +861,11 @@ func (e *Nuclear) processConsensusGasLimits(
 	header *types.Header,
 	state *state.StateDB,
 ) error {
+	if e.sporkOverrides.complete() {
+		e.callGas, e.xferGas = e.sporkOverrides.Apply(e.callGas, e.xferGas)
+		return nil
+	}
+
 	callData, err := e.sporkAbi.Pack("consensusGasLimits")
 	if err != nil {
 		log.Error("Fail to prepare consensusGasLimits() call", "err", err)
@@ -822,8 +904,8 @@ func (e *Nuclear) processConsensusGasLimits(
 		return err
 	}
 
-	e.callGas = ret.CallGas.Uint64()
-	e.xferGas = ret.XferGas.Uint64()
+	e.callGas, e.xferGas = e.sporkOverrides.Apply(
+		ret.CallGas.Uint64(), ret.XferGas.Uint64())
 	log.Trace("Consensus Gas", "call", e.callGas, "xfer", e.xferGas)
 
 	return nil
@@ -0,0 +1,147 @@
+// Copyright 2019 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/consensus/ethash"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/core/vm"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// finalizeChain places a fake parent after the migration block, so that
+// the regular consensus transactions get finalized on top of it.
+type finalizeChain struct {
+	*core.BlockChain
+	parent *types.Header
+}
+
+func (fc *finalizeChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash == fc.parent.Hash() {
+		return fc.parent
+	}
+	return fc.BlockChain.GetHeader(hash, number)
+}
+
+func (fc *finalizeChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return fc.GetHeader(hash, 0)
+}
+
+type finalizeTest struct {
+	chain   *finalizeChain
+	parent  *types.Header
+	engine  *Nuclear
+	statedb *state.StateDB
+}
+
+func newFinalizeTest(t *testing.T) *finalizeTest {
+	var (
+		testdb = ethdb.NewMemDatabase()
+		gspec  = &core.Genesis{
+			Config: params.NuclearTestnetChainConfig,
+			Xfers:  core.DeployNuclearGovernance(params.NuclearTestnetChainConfig),
+		}
+		genesis = gspec.MustCommit(testdb)
+	)
+
+	chain, err := core.NewBlockChain(testdb, nil, params.NuclearTestnetChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+	assert.Empty(t, err)
+
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(testdb))
+	assert.Empty(t, err)
+
+	parent := types.CopyHeader(genesis.Header())
+	parent.ParentHash = genesis.Hash()
+	parent.Number = new(big.Int).Add(genesis.Number(), common.Big1)
+	parent.Time = genesis.Time() + 60
+
+	return &finalizeTest{
+		chain:   &finalizeChain{chain, parent},
+		parent:  parent,
+		engine:  New(new(params.NuclearConfig), testdb),
+		statedb: statedb,
+	}
+}
+
+func TestPreviewFinalize(t *testing.T) {
+	t.Parallel()
+
+	ft := newFinalizeTest(t)
+	defer ft.chain.Stop()
+
+	coinbase := common.HexToAddress("0x0000000000000000000000000000000012345678")
+	header, txs, receipts, rewards, err := ft.engine.PreviewFinalize(
+		ft.chain, ft.parent, coinbase, ft.statedb)
+	if !assert.Empty(t, err) {
+		return
+	}
+
+	assert.Equal(t, coinbase, header.Coinbase)
+	assert.Equal(t, new(big.Int).Add(ft.parent.Number, common.Big1), header.Number)
+	assert.NotEmpty(t, txs)
+	assert.Equal(t, len(txs), len(receipts))
+	assert.NotNil(t, rewards)
+
+	for _, tx := range txs {
+		assert.True(t, tx.IsConsensus())
+	}
+
+	_, ok := ft.engine.BlockRewards(header.Root)
+	assert.False(t, ok, "preview rewards must not be cached")
+}
+
+func TestFinalizePending(t *testing.T) {
+	t.Parallel()
+
+	ft := newFinalizeTest(t)
+	defer ft.chain.Stop()
+
+	header := &types.Header{
+		ParentHash: ft.parent.Hash(),
+		Number:     new(big.Int).Add(ft.parent.Number, common.Big1),
+		GasLimit:   ft.parent.GasLimit,
+	}
+	if !assert.Empty(t, ft.engine.Prepare(ft.chain, header)) {
+		return
+	}
+	header.Coinbase = common.HexToAddress("0x0000000000000000000000000000000012345678")
+
+	block, receipts, err := ft.engine.FinalizePending(
+		ft.chain, header, ft.statedb, nil, nil)
+	if !assert.Empty(t, err) {
+		return
+	}
+
+	assert.NotEmpty(t, block.Transactions())
+	assert.Equal(t, len(block.Transactions()), len(receipts))
+
+	for _, tx := range block.Transactions() {
+		assert.True(t, tx.IsConsensus())
+	}
+
+	_, ok := ft.engine.BlockRewards(block.Root())
+	assert.False(t, ok, "pending rewards must not be cached")
+}
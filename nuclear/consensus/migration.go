@@ -142,25 +142,15 @@ func migrationTx(
 		return nil
 	}
 
-	owners, amounts, blacklist := createSnapshotParams(snapshot)
-	if owners == nil || amounts == nil || blacklist == nil {
+	callData := snapshotCallData(snapshot)
+	if callData == nil {
 		log.Error("Failed to create arguments")
 		return nil
 	}
 
-	migration_abi, err := abi.JSON(strings.NewReader(energi_abi.Gen2MigrationABI))
-	if err != nil {
-		panic(err)
-	}
-
-	callData, err := migration_abi.Pack("setSnapshot", owners, amounts, blacklist)
-	if err != nil {
-		panic(err)
-	}
-
-	gasLimit := gasPerMigrationEntry * uint64(len(owners))
+	gasLimit := gasPerMigrationEntry * uint64(len(snapshot.Txouts))
 	header.GasLimit = gasLimit
-	header.Extra, err = rlp.EncodeToBytes([]interface{}{
+	extra, err := rlp.EncodeToBytes([]interface{}{
 		uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch),
 		"nuclear",
 		snapshot.Hash,
@@ -168,6 +158,7 @@ func migrationTx(
 	if err != nil {
 		panic(err)
 	}
+	header.Extra = extra
 
 	res = types.NewTransaction(
 		uint64(0), // it should be the first transaction
@@ -243,6 +234,27 @@ func createSnapshotParams(ss *snapshot) (
 	return
 }
 
+// snapshotCallData returns setSnapshot() call data for the snapshot
+// or nil on error.
+func snapshotCallData(ss *snapshot) []byte {
+	owners, amounts, blacklist := createSnapshotParams(ss)
+	if owners == nil || amounts == nil || blacklist == nil {
+		return nil
+	}
+
+	migration_abi, err := abi.JSON(strings.NewReader(energi_abi.Gen2MigrationABI))
+	if err != nil {
+		panic(err)
+	}
+
+	callData, err := migration_abi.Pack("setSnapshot", owners, amounts, blacklist)
+	if err != nil {
+		panic(err)
+	}
+
+	return callData
+}
+
 func parseSnapshot(reader io.Reader) (*snapshot, error) {
 	dec := json.NewDecoder(reader)
 	dec.DisallowUnknownFields()
@@ -280,22 +292,12 @@ func ValidateMigration(
 		return false
 	}
 
-	owners, amounts, blacklist := createSnapshotParams(snapshot)
-	if owners == nil || amounts == nil || blacklist == nil {
+	callData := snapshotCallData(snapshot)
+	if callData == nil {
 		log.Error("Failed to create arguments")
 		return false
 	}
 
-	migration_abi, err := abi.JSON(strings.NewReader(energi_abi.Gen2MigrationABI))
-	if err != nil {
-		panic(err)
-	}
-
-	callData, err := migration_abi.Pack("setSnapshot", owners, amounts, blacklist)
-	if err != nil {
-		panic(err)
-	}
-
 	txs := block.Transactions()
 	if len(txs) != 2 {
 		log.Error("Invalid transaction count")
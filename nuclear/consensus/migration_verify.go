@@ -0,0 +1,394 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"nuclear/core/nuclear/accounts/abi"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/rlp"
+
+	"github.com/shengdoushi/base58"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+)
+
+// SnapshotReport summarizes a Gen2 snapshot file.
+// NOTE: all amounts are in Gen3 units.
+type SnapshotReport struct {
+	Hash               string
+	CallDataHash       *common.Hash
+	Entries            int
+	Owners             int
+	TotalAmount        *big.Int
+	Blacklist          []string
+	BlacklistedEntries int
+	BlacklistedAmount  *big.Int
+	Duplicates         map[string]int
+	Invalid            []string
+}
+
+// SnapshotMismatch is a per-owner difference, nil amount means missing owner.
+type SnapshotMismatch struct {
+	Owner     common.Address
+	Gen2Owner string
+	Expected  *big.Int
+	Actual    *big.Int
+}
+
+// SnapshotDiff is a difference of actual migration data against the expected one.
+type SnapshotDiff struct {
+	ExpectedTotal    *big.Int
+	ActualTotal      *big.Int
+	Mismatches       []SnapshotMismatch
+	BlacklistAdded   []common.Address
+	BlacklistRemoved []common.Address
+}
+
+func (d *SnapshotDiff) IsEmpty() bool {
+	return len(d.Mismatches) == 0 &&
+		len(d.BlacklistAdded) == 0 &&
+		len(d.BlacklistRemoved) == 0
+}
+
+type snapshotBalances struct {
+	amounts   map[common.Address]*big.Int
+	names     map[common.Address]string
+	blacklist map[common.Address]bool
+}
+
+// VerifySnapshot parses the snapshot file and reports its contents.
+func VerifySnapshot(file string) (*SnapshotReport, error) {
+	ss, err := loadSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshotReport(ss), nil
+}
+
+// DiffSnapshots compares other snapshot file against the base one.
+func DiffSnapshots(base_file, other_file string) (*SnapshotDiff, error) {
+	base, err := loadSnapshotBalances(base_file)
+	if err != nil {
+		return nil, err
+	}
+
+	other, err := loadSnapshotBalances(other_file)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffSnapshotBalances(base, other), nil
+}
+
+// DiffMigrationData compares setSnapshot() call data of the migration
+// transaction against the snapshot file.
+func DiffMigrationData(file string, callData []byte) (*SnapshotDiff, error) {
+	base, err := loadSnapshotBalances(file)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := callDataBalances(callData)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffSnapshotBalances(base, actual), nil
+}
+
+// MigrationExtraHash extracts snapshot hash from the migration block header extra.
+func MigrationExtraHash(extra []byte) (string, error) {
+	var info struct {
+		Version uint
+		Name    string
+		Hash    string
+	}
+
+	if err := rlp.DecodeBytes(extra, &info); err != nil {
+		return "", err
+	}
+
+	return info.Hash, nil
+}
+
+func loadSnapshot(file string) (*snapshot, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseSnapshot(f)
+}
+
+func loadSnapshotBalances(file string) (*snapshotBalances, error) {
+	ss, err := loadSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSnapshotBalances(ss)
+}
+
+// decodeGen2Owner converts base58 owner to raw one with checksum validation.
+func decodeGen2Owner(owner string) (common.Address, error) {
+	raw, err := base58.Decode(owner, base58.BitcoinAlphabet)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	if len(raw) != 25 {
+		return common.Address{}, errors.New("Invalid owner length")
+	}
+
+	hash := sha256.Sum256(raw[:21])
+	hash = sha256.Sum256(hash[:])
+	if !bytes.Equal(hash[:4], raw[21:]) {
+		return common.Address{}, errors.New("Invalid owner checksum")
+	}
+
+	return common.BytesToAddress(raw[1:21]), nil
+}
+
+func snapshotReport(ss *snapshot) *SnapshotReport {
+	// NOTE: Gen 2 precision is 8, but Gen 3 is 18
+	multiplier := big.NewInt(1e10)
+
+	res := &SnapshotReport{
+		Hash:              ss.Hash,
+		Entries:           len(ss.Txouts),
+		TotalAmount:       new(big.Int),
+		Blacklist:         ss.Blacklist,
+		BlacklistedAmount: new(big.Int),
+		Duplicates:        make(map[string]int),
+		Invalid:           []string{},
+	}
+
+	blacklist := make(map[string]bool, len(ss.Blacklist))
+	for _, o := range ss.Blacklist {
+		if _, err := decodeGen2Owner(o); err != nil {
+			res.Invalid = append(res.Invalid, o)
+		}
+		blacklist[o] = true
+	}
+
+	counts := make(map[string]int, len(ss.Txouts))
+	for _, item := range ss.Txouts {
+		if _, err := decodeGen2Owner(item.Owner); err != nil {
+			res.Invalid = append(res.Invalid, item.Owner)
+		}
+
+		amount := new(big.Int)
+		if item.Amount != nil {
+			amount.Mul(item.Amount, multiplier)
+		}
+
+		res.TotalAmount.Add(res.TotalAmount, amount)
+		counts[item.Owner]++
+
+		if blacklist[item.Owner] {
+			res.BlacklistedEntries++
+			res.BlacklistedAmount.Add(res.BlacklistedAmount, amount)
+		}
+	}
+
+	res.Owners = len(counts)
+	for o, c := range counts {
+		if c > 1 {
+			res.Duplicates[o] = c
+		}
+	}
+
+	if len(res.Invalid) == 0 {
+		if callData := snapshotCallData(ss); callData != nil {
+			hash := crypto.Keccak256Hash(callData)
+			res.CallDataHash = &hash
+		}
+	}
+
+	return res
+}
+
+func parseSnapshotBalances(ss *snapshot) (*snapshotBalances, error) {
+	// NOTE: Gen 2 precision is 8, but Gen 3 is 18
+	multiplier := big.NewInt(1e10)
+
+	res := &snapshotBalances{
+		amounts:   make(map[common.Address]*big.Int, len(ss.Txouts)),
+		names:     make(map[common.Address]string, len(ss.Txouts)),
+		blacklist: make(map[common.Address]bool, len(ss.Blacklist)),
+	}
+
+	for _, item := range ss.Txouts {
+		owner, err := decodeGen2Owner(item.Owner)
+		if err != nil {
+			return nil, errors.New("Invalid owner " + item.Owner + ": " + err.Error())
+		}
+
+		if item.Amount == nil {
+			return nil, errors.New("Missing amount of " + item.Owner)
+		}
+
+		amount, ok := res.amounts[owner]
+		if !ok {
+			amount = new(big.Int)
+			res.amounts[owner] = amount
+		}
+
+		amount.Add(amount, new(big.Int).Mul(item.Amount, multiplier))
+		res.names[owner] = item.Owner
+	}
+
+	for _, o := range ss.Blacklist {
+		owner, err := decodeGen2Owner(o)
+		if err != nil {
+			return nil, errors.New("Invalid blacklisted owner " + o + ": " + err.Error())
+		}
+
+		res.blacklist[owner] = true
+	}
+
+	return res, nil
+}
+
+func callDataBalances(callData []byte) (*snapshotBalances, error) {
+	migration_abi, err := abi.JSON(strings.NewReader(energi_abi.Gen2MigrationABI))
+	if err != nil {
+		panic(err)
+	}
+
+	method := migration_abi.Methods["setSnapshot"]
+	if len(callData) < 4 || !bytes.Equal(callData[:4], method.Id()) {
+		return nil, errors.New("Not a setSnapshot() call")
+	}
+
+	var args struct {
+		Owners    [][20]byte
+		Amounts   []*big.Int
+		Blacklist [][20]byte
+	}
+
+	if err = method.Inputs.Unpack(&args, callData[4:]); err != nil {
+		return nil, err
+	}
+
+	if len(args.Owners) != len(args.Amounts) {
+		return nil, errors.New("Owner and amount count mismatch")
+	}
+
+	res := &snapshotBalances{
+		amounts:   make(map[common.Address]*big.Int, len(args.Owners)),
+		names:     make(map[common.Address]string),
+		blacklist: make(map[common.Address]bool, len(args.Blacklist)),
+	}
+
+	for i, o := range args.Owners {
+		owner := common.BytesToAddress(o[:])
+
+		amount, ok := res.amounts[owner]
+		if !ok {
+			amount = new(big.Int)
+			res.amounts[owner] = amount
+		}
+
+		amount.Add(amount, args.Amounts[i])
+	}
+
+	for _, o := range args.Blacklist {
+		res.blacklist[common.BytesToAddress(o[:])] = true
+	}
+
+	return res, nil
+}
+
+func diffSnapshotBalances(expected, actual *snapshotBalances) *SnapshotDiff {
+	res := &SnapshotDiff{
+		ExpectedTotal:    new(big.Int),
+		ActualTotal:      new(big.Int),
+		Mismatches:       []SnapshotMismatch{},
+		BlacklistAdded:   []common.Address{},
+		BlacklistRemoved: []common.Address{},
+	}
+
+	name := func(owner common.Address) string {
+		if n, ok := expected.names[owner]; ok {
+			return n
+		}
+		return actual.names[owner]
+	}
+
+	for owner, amount := range expected.amounts {
+		res.ExpectedTotal.Add(res.ExpectedTotal, amount)
+
+		other, ok := actual.amounts[owner]
+		if !ok || other.Cmp(amount) != 0 {
+			res.Mismatches = append(res.Mismatches, SnapshotMismatch{
+				Owner:     owner,
+				Gen2Owner: name(owner),
+				Expected:  amount,
+				Actual:    other,
+			})
+		}
+	}
+
+	for owner, amount := range actual.amounts {
+		res.ActualTotal.Add(res.ActualTotal, amount)
+
+		if _, ok := expected.amounts[owner]; !ok {
+			res.Mismatches = append(res.Mismatches, SnapshotMismatch{
+				Owner:     owner,
+				Gen2Owner: name(owner),
+				Actual:    amount,
+			})
+		}
+	}
+
+	for owner := range expected.blacklist {
+		if !actual.blacklist[owner] {
+			res.BlacklistRemoved = append(res.BlacklistRemoved, owner)
+		}
+	}
+
+	for owner := range actual.blacklist {
+		if !expected.blacklist[owner] {
+			res.BlacklistAdded = append(res.BlacklistAdded, owner)
+		}
+	}
+
+	// Make output reproducible
+	sort.Slice(res.Mismatches, func(i, j int) bool {
+		return bytes.Compare(res.Mismatches[i].Owner[:], res.Mismatches[j].Owner[:]) < 0
+	})
+	sort.Slice(res.BlacklistAdded, func(i, j int) bool {
+		return bytes.Compare(res.BlacklistAdded[i][:], res.BlacklistAdded[j][:]) < 0
+	})
+	sort.Slice(res.BlacklistRemoved, func(i, j int) bool {
+		return bytes.Compare(res.BlacklistRemoved[i][:], res.BlacklistRemoved[j][:]) < 0
+	})
+
+	return res
+}
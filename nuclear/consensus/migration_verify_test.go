@@ -0,0 +1,97 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotReport(t *testing.T) {
+	t.Parallel()
+
+	ss, err := parseSnapshot(strings.NewReader(testSnapshotData))
+	assert.Empty(t, err)
+
+	res := snapshotReport(ss)
+	assert.Equal(t, ss.Hash, res.Hash)
+	assert.Equal(t, 2, res.Entries)
+	assert.Equal(t, 2, res.Owners)
+	assert.Equal(t, "102290000100000000000", res.TotalAmount.String())
+	assert.Equal(t, 1, res.BlacklistedEntries)
+	assert.Equal(t, "10000100000000000", res.BlacklistedAmount.String())
+	assert.Empty(t, res.Duplicates)
+	assert.Empty(t, res.Invalid)
+	assert.NotNil(t, res.CallDataHash)
+
+	ss.Txouts = append(ss.Txouts, ss.Txouts[0], snapshotItem{
+		Owner:  "t6vtJKxdjaJdofaUrx7w4xUs5bMcjDq5R3",
+		Amount: big.NewInt(1),
+	})
+	res = snapshotReport(ss)
+	assert.Equal(t, 4, res.Entries)
+	assert.Equal(t, 3, res.Owners)
+	assert.Equal(t, map[string]int{"t6vtJKxdjaJdofaUrx7w4xUs5bMcjDq5R2": 2}, res.Duplicates)
+	assert.Equal(t, []string{"t6vtJKxdjaJdofaUrx7w4xUs5bMcjDq5R3"}, res.Invalid)
+	assert.Nil(t, res.CallDataHash)
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	t.Parallel()
+
+	ss, err := parseSnapshot(strings.NewReader(testSnapshotData))
+	assert.Empty(t, err)
+
+	expected, err := parseSnapshotBalances(ss)
+	assert.Empty(t, err)
+
+	actual, err := callDataBalances(snapshotCallData(ss))
+	assert.Empty(t, err)
+
+	diff := diffSnapshotBalances(expected, actual)
+	assert.True(t, diff.IsEmpty())
+	assert.Equal(t, diff.ExpectedTotal, diff.ActualTotal)
+
+	// Alter amount, remove blacklist entry and add a new owner
+	ss.Txouts[0].Amount = big.NewInt(10228000001)
+	ss.Blacklist = nil
+	extra := common.HexToAddress("0x1234")
+	actual, err = callDataBalances(snapshotCallData(ss))
+	assert.Empty(t, err)
+	actual.amounts[extra] = big.NewInt(1)
+
+	diff = diffSnapshotBalances(expected, actual)
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, 2, len(diff.Mismatches))
+	assert.Equal(t, extra, diff.Mismatches[0].Owner)
+	assert.Nil(t, diff.Mismatches[0].Expected)
+	assert.Equal(t, common.HexToAddress("0x000D90BA0EFF81760202C28B40563B9636C1CCD4"), diff.Mismatches[1].Owner)
+	assert.Equal(t, "t6vtJKxdjaJdofaUrx7w4xUs5bMcjDq5R2", diff.Mismatches[1].Gen2Owner)
+	assert.Equal(t, "102280000000000000000", diff.Mismatches[1].Expected.String())
+	assert.Equal(t, "102280000010000000000", diff.Mismatches[1].Actual.String())
+	assert.Equal(t, []common.Address{
+		common.HexToAddress("0xFFF4AF0E7421838CDB2F14134F74AA4B5B0A816E")}, diff.BlacklistRemoved)
+	assert.Empty(t, diff.BlacklistAdded)
+
+	_, err = callDataBalances([]byte{1, 2, 3, 4})
+	assert.Equal(t, "Not a setSnapshot() call", err.Error())
+}
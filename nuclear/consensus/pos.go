@@ -567,11 +567,16 @@ func (e *Nuclear) mine(
 				continue
 			}
 
+			policy := e.StakingPolicy(v.addr)
+			if !policy.allowed(v.weight, blockTime) {
+				continue
+			}
+
 			//log.Trace("PoS stake candidate", "addr", v.addr, "weight", v.weight)
 			header.Coinbase = v.addr
 			poshash, used_weight := e.calcPoSHash(header, target, v.weight)
 
-			nonceCap := e.GetMinerNonceCap()
+			nonceCap := policy.nonceCap(e.GetMinerNonceCap())
 			if nonceCap != 0 && nonceCap < used_weight {
 				continue
 			} else if poshash != nil {
@@ -24,22 +24,147 @@ import (
 	"nuclear/core/nuclear/core"
 	"nuclear/core/nuclear/core/state"
 	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/core/vm"
 	"nuclear/core/nuclear/log"
 
 	energi_params "nuclear/core/nuclear/energi/params"
 )
 
+const (
+	blockRewardsCacheSize = 256
+)
+
 var (
 	BigBalance = new(big.Int).Div(math.MaxBig256, big.NewInt(2))
 )
 
+// rewardTransfer is an internal value transfer made during reward() call.
+type rewardTransfer struct {
+	From   common.Address
+	To     common.Address
+	Amount *big.Int
+
+	snapshot int
+}
+
+// rewardRecorder collects internal transfers of reward() call. Transfers
+// of reverted call frames are discarded. All events are passed through to
+// the node transfer tracer, if any.
+type rewardRecorder struct {
+	next      vm.TransferTracer
+	transfers []rewardTransfer
+}
+
+var _ vm.TransferTracer = (*rewardRecorder)(nil)
+
+func (r *rewardRecorder) CaptureTransfer(
+	env *vm.EVM, snapshot int, from, to common.Address, value *big.Int,
+) {
+	r.transfers = append(r.transfers, rewardTransfer{
+		From:     from,
+		To:       to,
+		Amount:   new(big.Int).Set(value),
+		snapshot: snapshot,
+	})
+
+	if r.next != nil {
+		r.next.CaptureTransfer(env, snapshot, from, to, value)
+	}
+}
+
+func (r *rewardRecorder) CaptureRevert(env *vm.EVM, snapshot int) {
+	// Snapshot revisions grow monotonically
+	i := len(r.transfers)
+	for i > 0 && r.transfers[i-1].snapshot >= snapshot {
+		i--
+	}
+	r.transfers = r.transfers[:i]
+
+	if r.next != nil {
+		r.next.CaptureRevert(env, snapshot)
+	}
+}
+
+// BlockRewards returns the reward breakdown of a recently processed block.
+func (e *Nuclear) BlockRewards(root common.Hash) (types.BlockRewards, bool) {
+	if v, ok := e.blockRewards.Get(root); ok {
+		return v.(types.BlockRewards), true
+	}
+
+	return nil, false
+}
+
+// rewardCategories maps system contract proxies and their implementations
+// to reward categories.
+func rewardCategories(statedb vm.StateDB) map[common.Address]string {
+	res := make(map[common.Address]string, 10)
+
+	for proxy, category := range map[common.Address]string{
+		energi_params.Nuclear_BlockReward:        "",
+		energi_params.Nuclear_StakerReward:       types.RewardStaker,
+		energi_params.Nuclear_BackboneReward:     types.RewardBackbone,
+		energi_params.Nuclear_Treasury:           types.RewardTreasury,
+		energi_params.Nuclear_MasternodeRegistry: types.RewardMasternode,
+	} {
+		impl := common.BytesToAddress(
+			statedb.GetState(proxy, energi_params.Storage_ProxyImpl).Bytes())
+		res[proxy] = category
+		res[impl] = category
+	}
+
+	return res
+}
+
+// itemiseRewards converts internal transfers of reward() call into rewards.
+//
+// Funds leaving a reward contract for a non-system address are paid to
+// the recipient under the category of the contract. Funds entering
+// the Treasury from another system contract are accounted as Treasury reward.
+func itemiseRewards(
+	transfers []rewardTransfer,
+	categories map[common.Address]string,
+) types.BlockRewards {
+	res := types.BlockRewards{}
+
+	add := func(recipient common.Address, category string, amount *big.Int) {
+		for _, r := range res {
+			if r.Recipient == recipient && r.Category == category {
+				r.Amount.Add(r.Amount, amount)
+				return
+			}
+		}
+
+		res = append(res, &types.BlockReward{
+			Recipient: recipient,
+			Category:  category,
+			Amount:    new(big.Int).Set(amount),
+		})
+	}
+
+	for _, t := range transfers {
+		from, from_system := categories[t.From]
+		to, to_system := categories[t.To]
+
+		switch {
+		case !from_system:
+			continue
+		case !to_system && from != "":
+			add(t.To, from, t.Amount)
+		case to_system && to == types.RewardTreasury && from != types.RewardTreasury:
+			add(energi_params.Nuclear_Treasury, types.RewardTreasury, t.Amount)
+		}
+	}
+
+	return res
+}
+
 func (e *Nuclear) processBlockRewards(
 	chain ChainReader,
 	header *types.Header,
 	statedb *state.StateDB,
 	txs types.Transactions,
 	receipts types.Receipts,
-) (types.Transactions, types.Receipts, error) {
+) (types.Transactions, types.Receipts, types.BlockRewards, error) {
 	systemFaucet := e.systemFaucet
 
 	// Temporary balance setup & clean up
@@ -50,13 +175,13 @@ func (e *Nuclear) processBlockRewards(
 	getRewardData, err := e.rewardAbi.Pack("getReward", header.Number)
 	if err != nil {
 		log.Error("Fail to prepare getReward() call", "err", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	rewardData, err := e.rewardAbi.Pack("reward")
 	if err != nil {
 		log.Error("Fail to prepare reward() call", "err", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// GetReward()
@@ -78,7 +203,7 @@ func (e *Nuclear) processBlockRewards(
 	statedb.RevertToSnapshot(rev_id)
 	if err != nil {
 		log.Error("Failed in getReward() call", "err", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	//
@@ -86,7 +211,7 @@ func (e *Nuclear) processBlockRewards(
 	err = e.rewardAbi.Unpack(&total_reward, "getReward", output)
 	if err != nil {
 		log.Error("Failed to unpack getReward() call", "err", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// Reward
@@ -105,15 +230,25 @@ func (e *Nuclear) processBlockRewards(
 	msg, err = tx.AsMessage(&ConsensusSigner{})
 	if err != nil {
 		log.Error("Failed in BlockReward AsMessage()", "err", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	evm = e.createEVM(msg, chain, header, statedb)
+	// Record internal transfers to itemise the reward
+	categories := rewardCategories(statedb)
+	vmc := e.vmConfig(chain)
+	recorder := &rewardRecorder{next: vmc.TransferTracer}
+	vmc.TransferTracer = recorder
+	evm = e.createEVMWithConfig(msg, chain, header, statedb, vmc)
 	gp = core.GasPool(msg.Gas())
 	_, gas2, failed, err := core.ApplyMessage(evm, msg, &gp)
 	if err != nil {
 		log.Error("Failed in reward() call", "err", err)
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	rewards := types.BlockRewards{}
+	if !failed {
+		rewards = itemiseRewards(recorder.transfers, categories)
 	}
 
 	// NOTE: it should be Byzantium finalization here...
@@ -126,5 +261,5 @@ func (e *Nuclear) processBlockRewards(
 
 	log.Trace("Block reward", "reward", total_reward, "gas", gas1+gas2)
 
-	return append(txs, tx), append(receipts, receipt), nil
+	return append(txs, tx), append(receipts, receipt), rewards, nil
 }
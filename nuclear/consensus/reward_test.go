@@ -31,6 +31,8 @@ import (
 	"nuclear/core/nuclear/params"
 
 	"github.com/stretchr/testify/assert"
+
+	energi_params "nuclear/core/nuclear/energi/params"
 )
 
 func TestBlockRewards(t *testing.T) {
@@ -72,9 +74,10 @@ func TestBlockRewards(t *testing.T) {
 
 	for i := 0; i < 5; i++ {
 		// TODO: check balance changes
-		txs, receipts, err := engine.processBlockRewards(chain, header, statedb, nil, nil)
+		txs, receipts, rewards, err := engine.processBlockRewards(chain, header, statedb, nil, nil)
 		assert.Equal(t, 1, len(txs))
 		assert.Equal(t, 1, len(receipts))
+		assert.NotNil(t, rewards)
 
 		if err != nil {
 			panic(err)
@@ -82,3 +85,105 @@ func TestBlockRewards(t *testing.T) {
 	}
 
 }
+
+func TestItemiseRewards(t *testing.T) {
+	t.Parallel()
+
+	staker := common.HexToAddress("0x1111")
+	backbone := common.HexToAddress("0x2222")
+	mn_owner := common.HexToAddress("0x3333")
+	reward_impl := common.HexToAddress("0x4444")
+	treasury_impl := common.HexToAddress("0x5555")
+	mn_impl := common.HexToAddress("0x6666")
+
+	categories := map[common.Address]string{
+		energi_params.Nuclear_BlockReward:        "",
+		reward_impl:                              "",
+		energi_params.Nuclear_StakerReward:       types.RewardStaker,
+		energi_params.Nuclear_BackboneReward:     types.RewardBackbone,
+		energi_params.Nuclear_Treasury:           types.RewardTreasury,
+		treasury_impl:                            types.RewardTreasury,
+		energi_params.Nuclear_MasternodeRegistry: types.RewardMasternode,
+		mn_impl:                                  types.RewardMasternode,
+	}
+
+	xfer := func(from, to common.Address, amount int64) rewardTransfer {
+		return rewardTransfer{From: from, To: to, Amount: big.NewInt(amount)}
+	}
+
+	transfers := []rewardTransfer{
+		xfer(energi_params.Nuclear_BlockReward, reward_impl, 100),
+		xfer(reward_impl, energi_params.Nuclear_StakerReward, 10),
+		xfer(energi_params.Nuclear_StakerReward, staker, 10),
+		xfer(reward_impl, energi_params.Nuclear_BackboneReward, 20),
+		xfer(energi_params.Nuclear_BackboneReward, backbone, 20),
+		xfer(reward_impl, energi_params.Nuclear_Treasury, 30),
+		xfer(energi_params.Nuclear_Treasury, treasury_impl, 30),
+		xfer(reward_impl, energi_params.Nuclear_MasternodeRegistry, 40),
+		xfer(energi_params.Nuclear_MasternodeRegistry, mn_impl, 40),
+		xfer(mn_impl, mn_owner, 25),
+		xfer(mn_impl, mn_owner, 5),
+		// no other masternode to pay
+		xfer(mn_impl, energi_params.Nuclear_Treasury, 10),
+		// unrelated
+		xfer(staker, backbone, 1),
+	}
+
+	rewards := itemiseRewards(transfers, categories)
+	assert.Equal(t, types.BlockRewards{
+		{Recipient: staker, Category: types.RewardStaker, Amount: big.NewInt(10)},
+		{Recipient: backbone, Category: types.RewardBackbone, Amount: big.NewInt(20)},
+		{Recipient: energi_params.Nuclear_Treasury, Category: types.RewardTreasury, Amount: big.NewInt(40)},
+		{Recipient: mn_owner, Category: types.RewardMasternode, Amount: big.NewInt(30)},
+	}, rewards)
+	assert.Equal(t, big.NewInt(100), rewards.Total())
+
+	assert.Equal(t, types.BlockRewards{}, itemiseRewards(nil, categories))
+}
+
+func TestRewardRecorder(t *testing.T) {
+	t.Parallel()
+
+	call := func(to common.Address, value byte) []byte {
+		code := []byte{
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+			byte(vm.PUSH1), value, byte(vm.PUSH20),
+		}
+		code = append(code, to.Bytes()...)
+		return append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	}
+
+	outer := common.HexToAddress("0x10")
+	inner := common.HexToAddress("0x20")
+	a := common.HexToAddress("0x30")
+	b := common.HexToAddress("0x40")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.SetCode(outer, append(call(a, 1), call(inner, 0)...))
+	statedb.SetCode(inner, append(call(b, 2),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)))
+	statedb.SetBalance(outer, big.NewInt(10))
+	statedb.SetBalance(inner, big.NewInt(10))
+
+	next := &rewardRecorder{}
+	recorder := &rewardRecorder{next: next}
+	ctx := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: common.Big1,
+	}
+	evm := vm.NewEVM(ctx, statedb, params.TestChainConfig, vm.Config{TransferTracer: recorder})
+
+	_, _, err := evm.Call(vm.AccountRef(a), outer, nil, 1000000, common.Big0)
+	assert.Empty(t, err)
+
+	// The transfer of the reverted inner call is discarded
+	assert.Len(t, recorder.transfers, 1)
+	assert.Equal(t, outer, recorder.transfers[0].From)
+	assert.Equal(t, a, recorder.transfers[0].To)
+	assert.Equal(t, big.NewInt(1), recorder.transfers[0].Amount)
+	assert.Equal(t, recorder.transfers, next.transfers)
+	assert.Equal(t, big.NewInt(1), statedb.GetBalance(a))
+	assert.Equal(t, big.NewInt(10), statedb.GetBalance(inner))
+	assert.Equal(t, common.Big0, statedb.GetBalance(b))
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/params"
+)
+
+var errSporkOverrideMainnet = errors.New("spork overrides are not allowed on mainnet")
+
+// SporkOverrides replaces the on-chain spork values to test private networks.
+// Values which are not set are taken from the spork registry.
+//
+// NOTE: the overrides change consensus, so all the nodes of the network must
+// use the same ones.
+type SporkOverrides struct {
+	CallGas *uint64 `json:"callGas,omitempty"`
+	XferGas *uint64 `json:"xferGas,omitempty"`
+}
+
+// LoadSporkOverrides reads the overrides from a JSON file.
+func LoadSporkOverrides(path string) (*SporkOverrides, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := &SporkOverrides{}
+	if err := json.Unmarshal(data, overrides); err != nil {
+		return nil, fmt.Errorf("invalid spork overrides %s: %v", path, err)
+	}
+
+	return overrides, nil
+}
+
+// Apply returns the consensus gas limits with the overrides applied.
+func (o *SporkOverrides) Apply(callGas, xferGas uint64) (uint64, uint64) {
+	if o == nil {
+		return callGas, xferGas
+	}
+	if o.CallGas != nil {
+		callGas = *o.CallGas
+	}
+	if o.XferGas != nil {
+		xferGas = *o.XferGas
+	}
+	return callGas, xferGas
+}
+
+// complete checks if the on-chain values are not needed at all.
+func (o *SporkOverrides) complete() bool {
+	return o != nil && o.CallGas != nil && o.XferGas != nil
+}
+
+// SetSporkOverrides makes the engine use the overrides instead of the on-chain
+// sporks. It must be called before the engine is used. The overrides are
+// refused on the mainnet.
+func (e *Nuclear) SetSporkOverrides(chainID *big.Int, overrides *SporkOverrides) error {
+	if overrides != nil && chainID.Cmp(params.NuclearMainnetChainConfig.ChainID) == 0 {
+		return errSporkOverrideMainnet
+	}
+
+	if overrides != nil {
+		log.Warn("Using local spork overrides", "callGas", overrides.CallGas, "xferGas", overrides.XferGas)
+	}
+
+	e.sporkOverrides = overrides
+	return nil
+}
+
+// SporkOverrides returns the overrides in use, if any.
+func (e *Nuclear) SporkOverrides() *SporkOverrides {
+	return e.sporkOverrides
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSporkOverrides(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "sporks")
+	assert.Empty(t, err)
+	defer os.RemoveAll(dir)
+
+	// Partial overrides
+	path := filepath.Join(dir, "sporks.json")
+	assert.Empty(t, ioutil.WriteFile(path, []byte(`{"callGas": 12345}`), 0600))
+
+	overrides, err := LoadSporkOverrides(path)
+	assert.Empty(t, err)
+	assert.False(t, overrides.complete())
+
+	callGas, xferGas := overrides.Apply(1, 2)
+	assert.Equal(t, uint64(12345), callGas)
+	assert.Equal(t, uint64(2), xferGas)
+
+	// No overrides
+	callGas, xferGas = (*SporkOverrides)(nil).Apply(1, 2)
+	assert.Equal(t, uint64(1), callGas)
+	assert.Equal(t, uint64(2), xferGas)
+
+	// Invalid file
+	assert.Empty(t, ioutil.WriteFile(path, []byte(`{"callGas": "x"}`), 0600))
+	_, err = LoadSporkOverrides(path)
+	assert.NotEmpty(t, err)
+
+	// Mainnet is refused
+	engine := &Nuclear{}
+	assert.Equal(t, errSporkOverrideMainnet, engine.SetSporkOverrides(
+		params.NuclearMainnetChainConfig.ChainID, overrides))
+	assert.Nil(t, engine.SporkOverrides())
+
+	assert.Empty(t, engine.SetSporkOverrides(
+		params.NuclearTestnetChainConfig.ChainID, overrides))
+	assert.Equal(t, overrides, engine.SporkOverrides())
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"errors"
+
+	"nuclear/core/nuclear/common"
+)
+
+var errInvalidStakingWindow = errors.New("invalid staking window hour")
+
+// AccountStakingPolicy restricts staking of a single local account.
+type AccountStakingPolicy struct {
+	Account   common.Address
+	NonceCap  uint64 // maximum used weight, zero for the global cap
+	MinWeight uint64 // weight to exceed for staking
+	FromHour  uint8  // start of the daily UTC staking window
+	ToHour    uint8  // end of the window, equal to FromHour for the whole day
+}
+
+func (p AccountStakingPolicy) validate() error {
+	if p.FromHour >= 24 || p.ToHour >= 24 {
+		return errInvalidStakingWindow
+	}
+	return nil
+}
+
+// nonceCap returns the effective cap on used weight.
+func (p AccountStakingPolicy) nonceCap(globalCap uint64) uint64 {
+	if p.NonceCap != 0 {
+		return p.NonceCap
+	}
+	return globalCap
+}
+
+// allowed checks if the account may stake with the weight at the time.
+func (p AccountStakingPolicy) allowed(weight uint64, blockTime uint64) bool {
+	if weight <= p.MinWeight {
+		return false
+	}
+
+	if p.FromHour == p.ToHour {
+		return true
+	}
+
+	hour := uint8(blockTime / 3600 % 24)
+	if p.FromHour < p.ToHour {
+		return hour >= p.FromHour && hour < p.ToHour
+	}
+	// The window wraps around midnight
+	return hour >= p.FromHour || hour < p.ToHour
+}
+
+// SetStakingPolicies replaces all the per-account staking policies.
+func (e *Nuclear) SetStakingPolicies(policies []AccountStakingPolicy) error {
+	res := make(map[common.Address]AccountStakingPolicy, len(policies))
+	for _, p := range policies {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		res[p.Account] = p
+	}
+
+	e.policyMtx.Lock()
+	defer e.policyMtx.Unlock()
+	e.policies = res
+	return nil
+}
+
+// SetStakingPolicy sets the staking policy of a single account. The change
+// is not written to the miner config and lasts only until restart.
+func (e *Nuclear) SetStakingPolicy(policy AccountStakingPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	e.policyMtx.Lock()
+	defer e.policyMtx.Unlock()
+
+	if e.policies == nil {
+		e.policies = make(map[common.Address]AccountStakingPolicy)
+	}
+	e.policies[policy.Account] = policy
+	return nil
+}
+
+// RemoveStakingPolicy drops the staking policy of the account until restart.
+func (e *Nuclear) RemoveStakingPolicy(account common.Address) bool {
+	e.policyMtx.Lock()
+	defer e.policyMtx.Unlock()
+
+	_, ok := e.policies[account]
+	delete(e.policies, account)
+	return ok
+}
+
+// StakingPolicy returns the staking policy of the account. Accounts without
+// a policy get the default one.
+func (e *Nuclear) StakingPolicy(account common.Address) AccountStakingPolicy {
+	e.policyMtx.RLock()
+	defer e.policyMtx.RUnlock()
+
+	if p, ok := e.policies[account]; ok {
+		return p
+	}
+	return AccountStakingPolicy{Account: account}
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"testing"
+
+	"nuclear/core/nuclear/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStakingPolicy(t *testing.T) {
+	t.Parallel()
+
+	engine := &Nuclear{}
+	acct1 := common.HexToAddress("0x1")
+	acct2 := common.HexToAddress("0x2")
+
+	// Defaults
+	p := engine.StakingPolicy(acct1)
+	assert.Equal(t, acct1, p.Account)
+	assert.Equal(t, uint64(10), p.nonceCap(10))
+	assert.True(t, p.allowed(1, 0))
+
+	assert.Equal(t, errInvalidStakingWindow, engine.SetStakingPolicies([]AccountStakingPolicy{
+		{Account: acct1, FromHour: 24},
+	}))
+	assert.NoError(t, engine.SetStakingPolicies([]AccountStakingPolicy{
+		{Account: acct1, NonceCap: 100, MinWeight: 50},
+		{Account: acct2, FromHour: 22, ToHour: 2},
+	}))
+
+	// Weight threshold and own cap
+	p = engine.StakingPolicy(acct1)
+	assert.Equal(t, uint64(100), p.nonceCap(10))
+	assert.False(t, p.allowed(50, 0))
+	assert.True(t, p.allowed(51, 0))
+
+	// Window around midnight
+	p = engine.StakingPolicy(acct2)
+	assert.Equal(t, uint64(10), p.nonceCap(10))
+	assert.True(t, p.allowed(1, 23*3600))
+	assert.True(t, p.allowed(1, 24*3600+3600))
+	assert.False(t, p.allowed(1, 2*3600))
+	assert.False(t, p.allowed(1, 12*3600))
+
+	// Plain window
+	assert.NoError(t, engine.SetStakingPolicy(AccountStakingPolicy{
+		Account: acct2, FromHour: 8, ToHour: 16,
+	}))
+	p = engine.StakingPolicy(acct2)
+	assert.False(t, p.allowed(1, 7*3600+3599))
+	assert.True(t, p.allowed(1, 8*3600))
+	assert.False(t, p.allowed(1, 16*3600))
+
+	assert.True(t, engine.RemoveStakingPolicy(acct2))
+	assert.False(t, engine.RemoveStakingPolicy(acct2))
+	assert.True(t, engine.StakingPolicy(acct2).allowed(1, 12*3600))
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+	eth_consensus "nuclear/core/nuclear/consensus"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/core/vm"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/params"
+
+	"github.com/stretchr/testify/assert"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+func TestStakedBlockTransfers(t *testing.T) {
+	t.Parallel()
+	log.Root().SetHandler(log.StdoutHandler)
+
+	staker_key, _ := crypto.GenerateKey()
+	staker := crypto.PubkeyToAddress(staker_key.PublicKey)
+	user_key, _ := crypto.GenerateKey()
+	user := crypto.PubkeyToAddress(user_key.PublicKey)
+
+	// The forwarder sends the call value to the target with an internal call
+	forwarder := common.HexToAddress("0x1000")
+	target := common.HexToAddress("0x2000")
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.CALLVALUE), byte(vm.PUSH20),
+	}
+	code = append(code, target.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+
+	testdb := ethdb.NewMemDatabase()
+	engine := New(&params.NuclearConfig{MigrationSigner: staker}, testdb)
+	engine.testing = true
+	engine.diffFn = func(ChainReader, uint64, *types.Header, *timeTarget) *big.Int {
+		return common.Big1
+	}
+
+	var number uint64
+	engine.SetMinerCB(
+		func() []common.Address {
+			if number == 1 {
+				return []common.Address{energi_params.Nuclear_MigrationContract}
+			}
+			return []common.Address{staker}
+		},
+		func(addr common.Address, hash []byte) ([]byte, error) {
+			return crypto.Sign(hash, staker_key)
+		},
+		func() int { return 1 },
+		func() bool { return true },
+	)
+
+	chainConfig := *params.NuclearTestnetChainConfig
+	chainConfig.Nuclear = &params.NuclearConfig{MigrationSigner: staker}
+	signer := types.NewEIP155Signer(chainConfig.ChainID)
+
+	gspec := &core.Genesis{
+		Config:     &chainConfig,
+		GasLimit:   8000000,
+		Timestamp:  1000,
+		Difficulty: big.NewInt(1),
+		Coinbase:   energi_params.Nuclear_Treasury,
+		Alloc: core.GenesisAlloc{
+			staker:                                  {Balance: minStake},
+			user:                                    {Balance: minStake},
+			energi_params.Nuclear_MigrationContract: {Balance: minStake},
+			forwarder:                               {Balance: common.Big0, Code: code},
+		},
+		Xfers: core.DeployNuclearGovernance(&chainConfig),
+	}
+	genesis := gspec.MustCommit(testdb)
+
+	vmc := vm.Config{TransferTracer: core.NewTransferRecorder()}
+	chain, err := core.NewBlockChain(testdb, nil, &chainConfig, engine, vmc, nil)
+	assert.Empty(t, err)
+	defer chain.Stop()
+
+	var user_tx *types.Transaction
+	parent := genesis.Header()
+
+	for number = 1; number <= 2; number++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			GasLimit:   parent.GasLimit,
+			Number:     new(big.Int).SetUint64(number),
+			Time:       parent.Time,
+		}
+		assert.Empty(t, engine.Prepare(chain, header))
+
+		var tx *types.Transaction
+		if number == 1 {
+			tx = migrationTx(signer, header, &snapshot{
+				Txouts: []snapshotItem{{
+					Owner:  "t6vtJKxdjaJdofaUrx7w4xUs5bMcjDq5R2",
+					Amount: big.NewInt(10228000000),
+					Atype:  "pubkeyhash",
+				}},
+			}, engine)
+		} else {
+			tx, err = types.SignTx(
+				types.NewTransaction(0, forwarder, big.NewInt(1000), 100000, big.NewInt(1), nil),
+				signer, user_key)
+			assert.Empty(t, err)
+			user_tx = tx
+		}
+
+		// Apply transactions and finalize on a state copy like the miner does
+		statedb := chain.CalculateBlockState(header.ParentHash, number-1)
+		statedb.Prepare(tx.Hash(), common.Hash{}, 0)
+		receipt, _, err := core.ApplyTransaction(
+			&chainConfig, chain, &header.Coinbase,
+			new(core.GasPool).AddGas(header.GasLimit),
+			statedb, header, tx, &header.GasUsed, vmc)
+		assert.Empty(t, err)
+
+		block, _, err := engine.Finalize(
+			chain, header, statedb.Copy(), types.Transactions{tx}, nil, types.Receipts{receipt})
+		assert.Empty(t, err)
+
+		results := make(chan *eth_consensus.SealResult, 1)
+		assert.Empty(t, engine.Seal(chain, block, results, nil))
+		res := <-results
+		assert.NotNil(t, res.Block)
+
+		_, err = chain.WriteBlockWithState(res.Block, res.Receipts, res.NewState)
+		assert.Empty(t, err)
+		parent = res.Block.Header()
+	}
+
+	head := chain.CurrentBlock()
+	assert.Equal(t, uint64(2), head.NumberU64())
+	assert.Equal(t, staker, head.Coinbase())
+
+	var found []*types.InternalTransfer
+	for _, it := range chain.GetInternalTransfers(head.Hash(), head.NumberU64()) {
+		if it.TxHash == user_tx.Hash() {
+			found = append(found, it)
+		}
+	}
+	assert.Equal(t, []*types.InternalTransfer{{
+		TxHash: user_tx.Hash(),
+		From:   forwarder,
+		To:     target,
+		Value:  big.NewInt(1000),
+		Depth:  1,
+	}}, found)
+}
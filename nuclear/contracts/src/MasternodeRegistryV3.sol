@@ -0,0 +1,101 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of Nuclear Core.
+//
+// Nuclear Core is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Nuclear Core is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Nuclear Core. If not, see <http://www.gnu.org/licenses/>.
+
+// Nuclear Governance system is the fundamental part of Nuclear Core.
+
+// NOTE: It's not allowed to change the compiler due to byte-to-byte
+//       match requirement.
+pragma solidity 0.5.16;
+//pragma experimental SMTChecker;
+
+import { IGovernedContract } from "./GovernedContract.sol";
+import { IGovernedProxy } from "./IGovernedProxy.sol";
+import { MasternodeRegistryV2 } from "./MasternodeRegistryV2.sol";
+
+/**
+ * MN-2: MasternodeRegistry upgrade for masternodes without IPv4 address
+ *
+ * Zero ipv4address is accepted in announce(). The addresses of such
+ * masternodes are only known from the node records gossiped between
+ * masternodes.
+ */
+contract MasternodeRegistryV3 is MasternodeRegistryV2 {
+    constructor(
+        address _proxy,
+        IGovernedProxy _token_proxy,
+        IGovernedProxy _treasury_proxy,
+        uint[5] memory _config
+    )
+        public
+        MasternodeRegistryV2(_proxy, _token_proxy, _treasury_proxy, _config)
+    // solium-disable-next-line no-empty-blocks
+    {}
+
+    function _announce_check_ipv4(uint32 ipv4address) internal pure {
+        // NOTE: no IPv4 address at all is fine, but a non-public one is not.
+        if (ipv4address != 0) {
+            MasternodeRegistryV2._announce_check_ipv4(ipv4address);
+        }
+    }
+
+    // IGovernedContract
+    //---------------------------------
+    function _migrate(IGovernedContract _oldImpl) internal {
+        // Dispose
+        v1storage.kill();
+
+        MasternodeRegistryV2 oldinstance = MasternodeRegistryV2(address(_oldImpl));
+        v1storage = oldinstance.v1storage();
+
+        // Migration data
+        mn_announced = oldinstance.mn_announced();
+        current_masternode = oldinstance.current_masternode();
+        current_payouts = oldinstance.current_payouts();
+
+        // Other data
+        mn_ever_collateral = oldinstance.mn_ever_collateral();
+        mn_active_collateral = oldinstance.mn_active_collateral();
+        mn_announced_collateral = oldinstance.mn_announced_collateral();
+        mn_active = oldinstance.mn_active();
+        address[] memory old_list = oldinstance.enumerate();
+        last_block_number = block.number;
+
+        // Restore the mn status information.
+        // NOTE: V2 status has no extra field unlike V1 in the V2 migration.
+        for (uint i = old_list.length; i-- > 0;) {
+            address mn = old_list[i];
+
+            Status memory status;
+            (
+                status.sw_features,
+                ,
+                status.inactive_since,
+                ,
+                status.invalidations,
+                status.seq_payouts,
+                status.last_vote_epoch
+            ) = oldinstance.mn_status(mn);
+
+            status.next_heartbeat = block.timestamp + (i * TARGET_HEARTBEATS_COEF);
+            status.validator_index = validator_list.length;
+            validator_list.push(mn);
+
+            mn_status[mn] = status;
+        }
+
+        _processValidationEpoch();
+    }
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+// Nuclear Governance system is the fundamental part of Nuclear Core.
+
+'use strict';
+
+const MockProxy = artifacts.require('MockProxy');
+const MockProposal = artifacts.require('MockProposal');
+const MasternodeRegistryV2 = artifacts.require('MasternodeRegistryV2');
+const MasternodeRegistryV3 = artifacts.require('MasternodeRegistryV3');
+const IMasternodeRegistryV2 = artifacts.require('IMasternodeRegistryV2');
+const IMasternodeToken = artifacts.require('IMasternodeToken');
+
+const common = require('./common');
+
+contract("MasternodeRegistryV3", async accounts => {
+    const { fromAscii, toBN, toWei } = web3.utils;
+
+    const collateral = toWei('10000', 'ether');
+
+    const owner1 = accounts[1];
+    const owner2 = accounts[2];
+    const masternode1 = accounts[9];
+    const masternode2 = accounts[8];
+
+    const ip1 = toBN(0x12345678);
+    const no_ip = toBN(0);
+
+    const enode_common = '123456789012345678901234567890'
+    const enode1 = [fromAscii(enode_common + '11'), fromAscii(enode_common + '11')];
+    const enode2 = [fromAscii(enode_common + '11'), fromAscii(enode_common + '22')];
+
+    let mn_proxy;
+    let imn;
+    let impl3;
+    let mntoken_abi;
+
+    before(async () => {
+        const orig = await MasternodeRegistryV2.deployed();
+        const mntoken_proxy_addr = await orig.token_proxy();
+        const treasury_proxy_addr = await orig.treasury_proxy();
+        mntoken_abi = await IMasternodeToken.at(mntoken_proxy_addr);
+
+        mn_proxy = await MockProxy.new();
+        imn = await IMasternodeRegistryV2.at(mn_proxy.address);
+
+        const impl2 = await MasternodeRegistryV2.new(
+            mn_proxy.address,
+            mntoken_proxy_addr,
+            treasury_proxy_addr,
+            common.mnregistry_config_v2,
+            common.mnreg_deploy_opts
+        );
+        impl3 = await MasternodeRegistryV3.new(
+            mn_proxy.address,
+            mntoken_proxy_addr,
+            treasury_proxy_addr,
+            common.mnregistry_config_v2,
+            common.mnreg_deploy_opts
+        );
+        await mn_proxy.setImpl(impl2.address);
+
+        await mntoken_abi.depositCollateral({ from: owner1, value: collateral });
+        await mntoken_abi.depositCollateral({ from: owner2, value: collateral });
+    });
+
+    after(async () => {
+        await mntoken_abi.withdrawCollateral(collateral, { from: owner1 });
+        await mntoken_abi.withdrawCollateral(collateral, { from: owner2 });
+    });
+
+    it('should refuse announce() without IPv4 in V2', async () => {
+        try {
+            await imn.announce(masternode2, no_ip, enode2, { from: owner2 });
+            assert.fail('It should fail');
+        } catch (e) {
+            assert.match(e.message, /Wrong IP/);
+        }
+    });
+
+    it('should migrate from V2', async () => {
+        await imn.announce(masternode1, ip1, enode1, { from: owner1 });
+        expect(await impl3.enumerate()).members([]);
+
+        const { logs } = await mn_proxy.proposeUpgrade(impl3.address, 0);
+        assert.equal(logs.length, 1);
+
+        const proposal = await MockProposal.at(logs[0].args['1']);
+
+        await proposal.setAccepted();
+        await mn_proxy.upgrade(proposal.address);
+
+        expect(await imn.enumerate()).members([masternode1]);
+        expect(await impl3.enumerate()).members([masternode1]);
+        assert.isTrue(await imn.isActive(masternode1));
+    });
+
+    it('should announce() without IPv4', async () => {
+        await imn.announce(masternode2, no_ip, enode2, { from: owner2 });
+
+        const info = await imn.info(masternode2);
+        assert.equal(info.owner, owner2);
+        assert.equal(info.ipv4address.toString(), '0');
+        expect(await imn.enumerate()).members([masternode1, masternode2]);
+    });
+
+    it('should still refuse announce() non-routable IPs', async () => {
+        for (let ip of [ 0x7F000001, 0x0A000001, 0x00123456, 0xFFFFFFFF ]) {
+            try {
+                await imn.announce(masternode2, ip, enode2, { from: owner2 });
+                assert.fail('It should fail');
+            } catch (e) {
+                assert.match(e.message, /Wrong IP/);
+            }
+        }
+    });
+});
@@ -54,3 +54,17 @@ var (
 	// NOTE: this is NOT very safe, but it optimizes significantly
 	Storage_ProxyImpl = common.BigToHash(big.NewInt(0x01))
 )
+
+// Nuclear_GovernedProxies maps the governed system proxies to their initial
+// implementations set up in genesis.
+var Nuclear_GovernedProxies = map[common.Address]common.Address{
+	Nuclear_BlockReward:        Nuclear_BlockRewardV1,
+	Nuclear_Treasury:           Nuclear_TreasuryV1,
+	Nuclear_MasternodeRegistry: Nuclear_MasternodeRegistryV1,
+	Nuclear_StakerReward:       Nuclear_StakerRewardV1,
+	Nuclear_BackboneReward:     Nuclear_BackboneRewardV1,
+	Nuclear_SporkRegistry:      Nuclear_SporkRegistryV1,
+	Nuclear_CheckpointRegistry: Nuclear_CheckpointRegistryV1,
+	Nuclear_BlacklistRegistry:  Nuclear_BlacklistRegistryV1,
+	Nuclear_MasternodeToken:    Nuclear_MasternodeTokenV1,
+}
@@ -0,0 +1,277 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/log"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+const (
+	// cpProposalCacheSize is the number of checkpoint proposals with known
+	// signature base.
+	cpProposalCacheSize = 32
+
+	// cpOwnVoteTimeout is the time to wait for the local quorum before
+	// the own vote is submitted on-chain anyway.
+	cpOwnVoteTimeout = 5 * time.Minute
+)
+
+var errInvalidCheckpointVote = errors.New("invalid checkpoint vote")
+
+// checkpointVoteMsg is a masternode signature of a proposed checkpoint as
+// gossiped between masternodes.
+type checkpointVoteMsg struct {
+	Proposal  common.Address
+	Number    uint64
+	Hash      common.Hash
+	Signature []byte
+}
+
+type cpProposal struct {
+	core.Checkpoint
+	baseHash [32]byte
+}
+
+// cpVoteChain is the part of the blockchain aggregating the votes.
+type cpVoteChain interface {
+	State() (*state.StateDB, error)
+	AddCheckpointVote(core.Checkpoint, common.Address, core.CheckpointSignature) (int, bool, error)
+	ConfirmCheckpointVotes(core.Checkpoint) bool
+}
+
+// pendingCPVote is the own vote waiting for the local quorum.
+type pendingCPVote struct {
+	vote     *checkpointVote
+	deadline time.Time
+}
+
+// checkpointVotes aggregates gossiped checkpoint votes. The registry accepts
+// only individual signatures of the sender, so the own vote is submitted
+// on-chain only once the quorum is observed locally, or after a timeout
+// not to lose it when the quorum is never gossiped.
+type checkpointVotes struct {
+	mnsvc      *MasternodeService
+	chain      cpVoteChain
+	activeMNs  func() (uint64, error)
+	submitVote func(*checkpointVote)
+	proposals  *lru.Cache
+
+	mu      sync.Mutex
+	pending map[common.Address]*pendingCPVote
+}
+
+func newCheckpointVotes(mnsvc *MasternodeService) *checkpointVotes {
+	proposals, _ := lru.New(cpProposalCacheSize)
+
+	return &checkpointVotes{
+		mnsvc: mnsvc,
+		chain: mnsvc.eth.BlockChain(),
+		activeMNs: func() (uint64, error) {
+			count, err := mnsvc.registry.Count()
+			if err != nil {
+				return 0, err
+			}
+			return count.Active.Uint64(), nil
+		},
+		submitVote: func(vote *checkpointVote) {
+			select {
+			case mnsvc.cpVoteChan <- vote:
+			default:
+				log.Warn("Checkpoint vote queue is full", "cp", vote.address)
+			}
+		},
+		proposals: proposals,
+		pending:   make(map[common.Address]*pendingCPVote),
+	}
+}
+
+// proposal returns the checkpoint info of the on-chain proposal.
+func (cv *checkpointVotes) proposal(cpAddr common.Address) (*cpProposal, error) {
+	if v, ok := cv.proposals.Get(cpAddr); ok {
+		return v.(*cpProposal), nil
+	}
+
+	mnsvc := cv.mnsvc
+	cp, err := energi_abi.NewICheckpointV2Caller(cpAddr, mnsvc.eth.APIBackend)
+	if err != nil {
+		return nil, err
+	}
+
+	callOpts := &mnsvc.cpRegistry.CallOpts
+
+	info, err := cp.Info(callOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	baseHash, err := cp.SignatureBase(callOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &cpProposal{
+		Checkpoint: core.Checkpoint{
+			Since:  info.Since.Uint64(),
+			Number: info.Number.Uint64(),
+			Hash:   info.Hash,
+		},
+		baseHash: baseHash,
+	}
+	cv.proposals.Add(cpAddr, res)
+	return res, nil
+}
+
+// addOwn aggregates the own vote, which is then submitted on-chain on the
+// local quorum, and returns the message to gossip. The vote is not kept on
+// error, so the caller should submit it directly.
+func (cv *checkpointVotes) addOwn(vote *checkpointVote) (*checkpointVoteMsg, error) {
+	cp, err := cv.proposal(vote.address)
+	if err != nil {
+		return nil, err
+	}
+
+	cv.mu.Lock()
+	cv.pending[vote.address] = &pendingCPVote{
+		vote:     vote,
+		deadline: time.Now().Add(cpOwnVoteTimeout),
+	}
+	cv.mu.Unlock()
+
+	msg := &checkpointVoteMsg{
+		Proposal:  vote.address,
+		Number:    cp.Number,
+		Hash:      cp.Hash,
+		Signature: vote.signature,
+	}
+
+	if _, err := cv.add(msg); err != nil {
+		cv.mu.Lock()
+		if pending, ok := cv.pending[vote.address]; ok && pending.vote == vote {
+			delete(cv.pending, vote.address)
+		}
+		cv.mu.Unlock()
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// add verifies and aggregates the vote. It returns true for the votes not
+// seen before.
+func (cv *checkpointVotes) add(msg *checkpointVoteMsg) (bool, error) {
+	cp, err := cv.proposal(msg.Proposal)
+	if err != nil {
+		return false, err
+	}
+
+	if cp.Number != msg.Number || cp.Hash != msg.Hash || len(msg.Signature) != 65 {
+		return false, errInvalidCheckpointVote
+	}
+
+	// Drop Ecrecover workaround
+	sig := append([]byte{}, msg.Signature...)
+	sig[64] -= 27
+
+	pubkey, err := crypto.SigToPub(cp.baseHash[:], sig)
+	if err != nil {
+		return false, err
+	}
+
+	signer := crypto.PubkeyToAddress(*pubkey)
+
+	statedb, err := cv.chain.State()
+	if err != nil {
+		return false, err
+	}
+
+	mn_indicator := statedb.GetState(energi_params.Nuclear_MasternodeList, signer.Hash())
+	if (mn_indicator == common.Hash{}) {
+		log.Debug("Checkpoint vote of inactive masternode", "mn", signer)
+		return false, errInvalidCheckpointVote
+	}
+
+	count, added, err := cv.chain.AddCheckpointVote(cp.Checkpoint, signer, msg.Signature)
+	if err != nil {
+		return false, err
+	}
+
+	log.Trace("Checkpoint vote", "cp", msg.Proposal, "mn", signer, "votes", count)
+
+	// NOTE: the own vote may get known after the quorum is already reached.
+	if count >= cv.quorum() {
+		cv.chain.ConfirmCheckpointVotes(cp.Checkpoint)
+		cv.submit(msg.Proposal)
+	}
+
+	return added, nil
+}
+
+// quorum is the number of votes to consider the checkpoint approved.
+func (cv *checkpointVotes) quorum() int {
+	count, err := cv.activeMNs()
+	if err != nil {
+		log.Warn("Failed to get masternode count", "err", err)
+		return int(^uint(0) >> 1)
+	}
+
+	return int(count/2 + 1)
+}
+
+// submit sends the own pending vote for the on-chain record.
+func (cv *checkpointVotes) submit(cpAddr common.Address) {
+	cv.mu.Lock()
+	pending, ok := cv.pending[cpAddr]
+	delete(cv.pending, cpAddr)
+	cv.mu.Unlock()
+
+	if ok {
+		cv.submitVote(pending.vote)
+	}
+}
+
+// submitExpired sends the own votes which have not got the local quorum
+// in time.
+func (cv *checkpointVotes) submitExpired(now time.Time) {
+	var expired []*checkpointVote
+
+	cv.mu.Lock()
+	for cpAddr, pending := range cv.pending {
+		if now.After(pending.deadline) {
+			expired = append(expired, pending.vote)
+			delete(cv.pending, cpAddr)
+		}
+	}
+	cv.mu.Unlock()
+
+	for _, vote := range expired {
+		log.Debug("Checkpoint quorum is not gossiped in time", "cp", vote.address)
+		cv.submitVote(vote)
+	}
+}
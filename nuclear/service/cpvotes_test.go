@@ -0,0 +1,236 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/state"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/ethdb"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/stretchr/testify/assert"
+
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+type fakeCPVoteChain struct {
+	statedb   *state.StateDB
+	votes     map[common.Address]core.CheckpointSignature
+	confirmed int
+}
+
+func (c *fakeCPVoteChain) State() (*state.StateDB, error) {
+	return c.statedb, nil
+}
+
+func (c *fakeCPVoteChain) AddCheckpointVote(
+	cp core.Checkpoint,
+	signer common.Address,
+	sig core.CheckpointSignature,
+) (int, bool, error) {
+	if _, ok := c.votes[signer]; ok {
+		return len(c.votes), false, nil
+	}
+	c.votes[signer] = sig
+	return len(c.votes), true, nil
+}
+
+func (c *fakeCPVoteChain) ConfirmCheckpointVotes(cp core.Checkpoint) bool {
+	c.confirmed++
+	return true
+}
+
+func signCPVote(t *testing.T, key *ecdsa.PrivateKey, cp *cpProposal) []byte {
+	sig, err := crypto.Sign(cp.baseHash[:], key)
+	assert.Empty(t, err)
+	sig[64] += 27
+	return sig
+}
+
+func TestCheckpointVotes(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	chain := &fakeCPVoteChain{
+		statedb: statedb,
+		votes:   make(map[common.Address]core.CheckpointSignature),
+	}
+
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+
+		// Only the first three are active masternodes
+		if i < 3 {
+			statedb.SetState(
+				energi_params.Nuclear_MasternodeList,
+				crypto.PubkeyToAddress(key.PublicKey).Hash(),
+				common.BytesToHash([]byte{1}))
+		}
+	}
+
+	var submitted []*checkpointVote
+
+	proposals, _ := lru.New(cpProposalCacheSize)
+	cv := &checkpointVotes{
+		chain:      chain,
+		activeMNs:  func() (uint64, error) { return 3, nil },
+		submitVote: func(vote *checkpointVote) { submitted = append(submitted, vote) },
+		proposals:  proposals,
+		pending:    make(map[common.Address]*pendingCPVote),
+	}
+
+	cpAddr := common.HexToAddress("0x1234")
+	cp := &cpProposal{
+		Checkpoint: core.Checkpoint{Number: 10, Hash: common.HexToHash("0x10")},
+		baseHash:   common.HexToHash("0xba5e"),
+	}
+	proposals.Add(cpAddr, cp)
+
+	// The own vote is aggregated and returned for gossip
+	own := &checkpointVote{address: cpAddr, signature: signCPVote(t, keys[0], cp)}
+	msg, err := cv.addOwn(own)
+	assert.Empty(t, err)
+	assert.Equal(t, &checkpointVoteMsg{
+		Proposal:  cpAddr,
+		Number:    10,
+		Hash:      cp.Hash,
+		Signature: signCPVote(t, keys[0], cp),
+	}, msg)
+	assert.Len(t, chain.votes, 1)
+	assert.Equal(t, 0, chain.confirmed)
+	assert.Empty(t, submitted)
+
+	// Failures are reported to fall back to the direct path
+	cpAddr2 := common.HexToAddress("0x5678")
+	proposals.Add(cpAddr2, cp)
+	_, err = cv.addOwn(&checkpointVote{address: cpAddr2, signature: signCPVote(t, keys[3], cp)})
+	assert.Equal(t, errInvalidCheckpointVote, err)
+	assert.Len(t, cv.pending, 1)
+
+	// Mismatching checkpoint info
+	bad := *msg
+	bad.Number = 11
+	_, err = cv.add(&bad)
+	assert.Equal(t, errInvalidCheckpointVote, err)
+
+	bad = *msg
+	bad.Signature = bad.Signature[:64]
+	_, err = cv.add(&bad)
+	assert.Equal(t, errInvalidCheckpointVote, err)
+
+	// Inactive masternode
+	_, err = cv.add(&checkpointVoteMsg{
+		Proposal:  cpAddr,
+		Number:    10,
+		Hash:      cp.Hash,
+		Signature: signCPVote(t, keys[3], cp),
+	})
+	assert.Equal(t, errInvalidCheckpointVote, err)
+	assert.Len(t, chain.votes, 1)
+
+	// Duplicates are not relayed
+	added, err := cv.add(msg)
+	assert.Empty(t, err)
+	assert.False(t, added)
+
+	// Quorum of active masternodes
+	added, err = cv.add(&checkpointVoteMsg{
+		Proposal:  cpAddr,
+		Number:    10,
+		Hash:      cp.Hash,
+		Signature: signCPVote(t, keys[1], cp),
+	})
+	assert.Empty(t, err)
+	assert.True(t, added)
+	assert.Len(t, chain.votes, 2)
+	assert.Equal(t, 1, chain.confirmed)
+
+	// The own vote is submitted on-chain only once
+	assert.Equal(t, []*checkpointVote{own}, submitted)
+	assert.Empty(t, cv.pending)
+
+	// Unknown masternode count never reaches the quorum
+	cv.activeMNs = func() (uint64, error) { return 0, errors.New("test") }
+	_, err = cv.add(&checkpointVoteMsg{
+		Proposal:  cpAddr,
+		Number:    10,
+		Hash:      cp.Hash,
+		Signature: signCPVote(t, keys[2], cp),
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, 1, chain.confirmed)
+}
+
+func TestCheckpointVotesTimeout(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	chain := &fakeCPVoteChain{
+		statedb: statedb,
+		votes:   make(map[common.Address]core.CheckpointSignature),
+	}
+
+	key, _ := crypto.GenerateKey()
+	statedb.SetState(
+		energi_params.Nuclear_MasternodeList,
+		crypto.PubkeyToAddress(key.PublicKey).Hash(),
+		common.BytesToHash([]byte{1}))
+
+	var submitted []*checkpointVote
+
+	proposals, _ := lru.New(cpProposalCacheSize)
+	cv := &checkpointVotes{
+		chain:      chain,
+		activeMNs:  func() (uint64, error) { return 10, nil },
+		submitVote: func(vote *checkpointVote) { submitted = append(submitted, vote) },
+		proposals:  proposals,
+		pending:    make(map[common.Address]*pendingCPVote),
+	}
+
+	cpAddr := common.HexToAddress("0x1234")
+	cp := &cpProposal{
+		Checkpoint: core.Checkpoint{Number: 10, Hash: common.HexToHash("0x10")},
+		baseHash:   common.HexToHash("0xba5e"),
+	}
+	proposals.Add(cpAddr, cp)
+
+	own := &checkpointVote{address: cpAddr, signature: signCPVote(t, key, cp)}
+	_, err := cv.addOwn(own)
+	assert.Empty(t, err)
+
+	// The proposal info may leave the cache meanwhile
+	proposals.Purge()
+
+	cv.submitExpired(time.Now())
+	assert.Empty(t, submitted)
+
+	// The quorum is not gossiped in time
+	cv.submitExpired(time.Now().Add(cpOwnVoteTimeout + time.Second))
+	assert.Equal(t, []*checkpointVote{own}, submitted)
+
+	cv.submitExpired(time.Now().Add(2 * cpOwnVoteTimeout))
+	assert.Len(t, submitted, 1)
+}
@@ -19,6 +19,8 @@ package service
 import (
 	"errors"
 	"math/big"
+	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -32,6 +34,7 @@ import (
 	"nuclear/core/nuclear/log"
 	"nuclear/core/nuclear/node"
 	"nuclear/core/nuclear/p2p"
+	"nuclear/core/nuclear/p2p/enode"
 	"nuclear/core/nuclear/rpc"
 
 	energi_abi "nuclear/core/nuclear/energi/abi"
@@ -59,8 +62,9 @@ type checkpointVote struct {
 }
 
 type MasternodeService struct {
-	server *p2p.Server
-	eth    *eth.Ethereum
+	server     *p2p.Server
+	serverLock sync.RWMutex
+	eth        *eth.Ethereum
 
 	inSync int32
 
@@ -76,26 +80,51 @@ type MasternodeService struct {
 	features *big.Int
 
 	validator *peerValidator
+
+	addrs   []net.IP
+	gossip  *mnGossip
+	mesh    *mnMesh
+	cpVotes *checkpointVotes
+}
+
+// MasternodeConfig holds the optional Masternode service settings.
+type MasternodeConfig struct {
+	Owner    common.Address // Expected owner of the masternode, if set
+	Addrs    []net.IP       // Extra public addresses advertised in the masternode ENR
+	MeshSize int            // Number of reserved connections to other masternodes
+
+	// Gossip checkpoint votes and submit the own one only after the quorum
+	CheckpointGossip bool
 }
 
-func NewMasternodeService(ethServ *eth.Ethereum, owner common.Address) (node.Service, error) {
+func NewMasternodeService(ethServ *eth.Ethereum, cfg *MasternodeConfig) (node.Service, error) {
 	r := &MasternodeService{
 		eth:      ethServ,
 		inSync:   1,
 		features: energi_common.SWVersionToInt(),
-		owner:    owner,
+		owner:    cfg.Owner,
+		addrs:    cfg.Addrs,
 		// NOTE: we need to avoid triggering DoS on restart.
 		// There is no reliable way to check blockchain and all pools in the network.
 		nextHB: time.Now().Add(recheckInterval),
 
 		cpVoteChan: make(chan *checkpointVote, cpChanBufferSize),
 	}
+	gossip, err := newMNGossip(r)
+	if err != nil {
+		return nil, err
+	}
+	r.gossip = gossip
+	r.mesh = newMNMesh(r, cfg.MeshSize)
+	if cfg.CheckpointGossip {
+		r.cpVotes = newCheckpointVotes(r)
+	}
 	go r.listenDownloader()
 	return r, nil
 }
 
 func (m *MasternodeService) Protocols() []p2p.Protocol {
-	return nil
+	return []p2p.Protocol{m.gossip.protocol()}
 }
 
 func (m *MasternodeService) APIs() []rpc.API {
@@ -155,8 +184,11 @@ func (m *MasternodeService) Start(server *p2p.Server) error {
 		TransactOpts: m.registry.TransactOpts,
 	}
 
-	m.server = server
 	m.validator = newPeerValidator(common.Address{}, m)
+
+	m.serverLock.Lock()
+	m.server = server
+	m.serverLock.Unlock()
 	go m.loop()
 
 	log.Info("Started Nuclear Masternode", "addr", address)
@@ -169,6 +201,12 @@ func (m *MasternodeService) Stop() error {
 	return nil
 }
 
+func (m *MasternodeService) getServer() *p2p.Server {
+	m.serverLock.RLock()
+	defer m.serverLock.RUnlock()
+	return m.server
+}
+
 func (m *MasternodeService) listenDownloader() {
 	events := m.eth.EventMux().Subscribe(
 		downloader.StartEvent{},
@@ -308,10 +346,21 @@ func (m *MasternodeService) onCheckpoint(cpe CheckpointProposalEvent) {
 
 	signature[64] += 27
 
-	m.cpVoteChan <- &checkpointVote{
+	vote := &checkpointVote{
 		address:   cpAddr,
 		signature: signature,
 	}
+
+	if m.cpVotes != nil {
+		msg, err := m.cpVotes.addOwn(vote)
+		if err == nil {
+			m.gossip.broadcast(nil, cpVoteMsg, msg)
+			return
+		}
+		log.Error("Failed to add own checkpoint vote", "cp", cpAddr, "err", err)
+	}
+
+	m.cpVoteChan <- vote
 }
 
 // voteOnCheckpoints recieves the identified checkpoints vote information and
@@ -351,9 +400,12 @@ func (m *MasternodeService) onChainHead(block *types.Block) {
 		if do_cleanup {
 			m.eth.TxPool().RemoveBySender(m.address)
 		}
+		m.mesh.reset()
 		return
 	}
 
+	m.mesh.update(block)
+
 	// MN-4 - Heartbeats
 	now := time.Now()
 
@@ -389,6 +441,9 @@ func (m *MasternodeService) onChainHead(block *types.Block) {
 	}
 
 	// Vote on the identified checkpoints.
+	if m.cpVotes != nil {
+		m.cpVotes.submitExpired(now)
+	}
 	m.voteOnCheckpoints()
 
 	//
@@ -458,16 +513,17 @@ func (v *peerValidator) validate() {
 	}
 
 	cfg := mnsvc.eth.BlockChain().Config()
-	enode := energi_common.MastenodeEnode(mninfo.Ipv4address, mninfo.Enode, cfg)
+	nodes := energi_common.MasternodeNodes(
+		mnsvc.eth.ChainDb(), v.target, mninfo.Ipv4address, mninfo.Enode, cfg)
 
-	if enode == nil {
+	if len(nodes) == 0 {
 		log.Debug("Invalid ipv4address or public key was used")
 		return
 	}
 
 	// Check if the node is already connected as a peer and
 	// skip MN Validation if true.
-	if isFound := server.IsPeerActive(enode); isFound {
+	if isFound := server.IsPeerActive(nodes[0]); isFound {
 		log.Debug("Masternode validation skipped since peer is already connected",
 			"target", v.target.Hex())
 		return
@@ -482,40 +538,69 @@ func (v *peerValidator) validate() {
 	server.EnableMsgEvents = true
 	defer peerSub.Unsubscribe()
 
-	server.AddPeer(enode)
+	//---
+	// All candidates share the node ID, so every address of the record is
+	// tried in turn within the common validation period.
+	attempt := time.Minute / time.Duration(len(nodes))
+
+	for i, enode := range nodes {
+		server.AddPeer(enode)
+		found := v.awaitPeer(peerCh, enode, attempt)
 
-	defer func() {
 		// Disconnect this peer if more than half of the max peers are connected.
-		if server.PeerCount() > server.MaxPeers/2 {
+		// Unreachable addresses are always removed before the next one is tried.
+		// Mesh members hold reserved slots and are never disconnected here.
+		if !mnsvc.mesh.isMember(enode.ID()) &&
+			((!found && i < len(nodes)-1) || server.PeerCount() > server.MaxPeers/2) {
 			server.RemovePeer(enode)
 		}
-	}()
 
-	//---
-	deadline := time.Now().Add(time.Minute)
+		if found || v.isCancelled() {
+			return
+		}
+	}
+
+	log.Info("MN Invalidation", "mn", v.target)
+
+	// TODO: an excepted, but not seen before problem on scale got identified.
+	log.Warn("Invalidations are temporary disabled.")
+	return
+
+	_, err = mnsvc.registry.Invalidate(v.target)
+	if err != nil {
+		log.Warn("MN Invalidate error", "mn", v.target, "err", err)
+	}
+}
+
+// awaitPeer waits until the dialled node sends a message.
+func (v *peerValidator) awaitPeer(
+	peerCh chan *p2p.PeerEvent,
+	enode *enode.Node,
+	timeout time.Duration,
+) bool {
+	deadline := time.Now().Add(timeout)
 
 	for {
 		select {
 		case <-v.cancelCh:
-			return
+			return false
 		case pe := <-peerCh:
 			if pe.Peer != enode.ID() || pe.Type != p2p.PeerEventTypeMsgRecv {
 				break
 			}
 			// TODO: validate block availability as per MN-14
-			return
+			return true
 		case <-time.After(deadline.Sub(time.Now())):
-			log.Info("MN Invalidation", "mn", v.target)
-
-			// TODO: an excepted, but not seen before problem on scale got identified.
-			log.Warn("Invalidations are temporary disabled.")
-			return
-
-			_, err := mnsvc.registry.Invalidate(v.target)
-			if err != nil {
-				log.Warn("MN Invalidate error", "mn", v.target, "err", err)
-			}
-			return
+			return false
 		}
 	}
 }
+
+func (v *peerValidator) isCancelled() bool {
+	select {
+	case <-v.cancelCh:
+		return true
+	default:
+		return false
+	}
+}
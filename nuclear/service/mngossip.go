@@ -0,0 +1,438 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/p2p"
+	"nuclear/core/nuclear/p2p/enode"
+	"nuclear/core/nuclear/p2p/enr"
+	"nuclear/core/nuclear/rlp"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+const (
+	mnGossipName    = "mngossip"
+	mnGossipVersion = 1
+	mnGossipLength  = 2
+
+	mnGossipMaxMsgSize = 256 * 1024
+
+	// enrMsg carries a list of RLP encoded masternode records.
+	enrMsg = 0x00
+
+	// cpVoteMsg carries a single masternode checkpoint vote.
+	cpVoteMsg = 0x01
+
+	// maxENRsPerMsg limits the number of records in a single message.
+	maxENRsPerMsg = 512
+
+	// mnGossipQueueSize is the number of relayed messages waiting to be sent
+	// to a single peer. Messages are dropped when the queue is full.
+	mnGossipQueueSize = 64
+
+	// mnGossipRelayInterval is the minimal interval between relayed
+	// messages sent to a single peer.
+	mnGossipRelayInterval = 20 * time.Millisecond
+
+	// mnGossipMaxENRMsgs limits the number of record messages accepted from
+	// a single peer in mnGossipENRWindow. The rest are dropped.
+	mnGossipMaxENRMsgs = 60
+	mnGossipENRWindow  = time.Minute
+
+	// mnGossipMaxBadENRs is the number of invalid records and records of
+	// unknown masternodes tolerated from a single peer.
+	mnGossipMaxBadENRs = 32
+
+	// mnGossipMaxUnknown limits the cache of unknown masternodes.
+	mnGossipMaxUnknown = 4096
+)
+
+var (
+	errGossipNotStarted = errors.New("Masternode service is not started")
+	errBadMasternodeENR = errors.New("invalid record or unknown masternode")
+)
+
+// mnGossip relays signed node records between masternodes so that every
+// address a masternode is reachable at is known, not only the IPv4 one
+// kept in the registry. Checkpoint votes are relayed as well, if enabled.
+type mnGossip struct {
+	mnsvc *MasternodeService
+	addrs []enr.Entry
+
+	// mnEnode returns the registered enode key of the masternode, or
+	// ErrReverted for the unknown ones.
+	mnEnode func(common.Address) ([2][32]byte, error)
+	head    func() common.Hash
+
+	mu    sync.RWMutex
+	peers map[*p2p.Peer]*mnGossipPeer
+	enrs  map[common.Address]*enode.Node
+
+	// Unknown masternodes as of the head block
+	unknownMu   sync.Mutex
+	unknownHead common.Hash
+	unknown     map[common.Address]bool
+}
+
+type mnGossipMsg struct {
+	code    uint64
+	payload interface{}
+}
+
+// mnGossipPeer relays messages to a single peer one by one.
+type mnGossipPeer struct {
+	p     *p2p.Peer
+	rw    p2p.MsgReadWriter
+	queue chan mnGossipMsg
+
+	// Inbound records, used by the handling loop only
+	enrWindow time.Time
+	enrMsgs   int
+	badENRs   int
+}
+
+func newMNGossipPeer(p *p2p.Peer, rw p2p.MsgReadWriter) *mnGossipPeer {
+	return &mnGossipPeer{
+		p:     p,
+		rw:    rw,
+		queue: make(chan mnGossipMsg, mnGossipQueueSize),
+	}
+}
+
+// relay queues the message without blocking.
+func (gp *mnGossipPeer) relay(code uint64, payload interface{}) bool {
+	select {
+	case gp.queue <- mnGossipMsg{code, payload}:
+		return true
+	default:
+		return false
+	}
+}
+
+// allowENR limits the rate of inbound record messages.
+func (gp *mnGossipPeer) allowENR(now time.Time) bool {
+	if now.Sub(gp.enrWindow) >= mnGossipENRWindow {
+		gp.enrWindow = now
+		gp.enrMsgs = 0
+	}
+
+	gp.enrMsgs++
+	return gp.enrMsgs <= mnGossipMaxENRMsgs
+}
+
+func (gp *mnGossipPeer) loop(quit <-chan struct{}) {
+	ticker := time.NewTicker(mnGossipRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-gp.queue:
+			if err := p2p.Send(gp.rw, msg.code, msg.payload); err != nil {
+				log.Trace("Failed to relay masternode gossip", "peer", gp.p.ID(), "err", err)
+				return
+			}
+		case <-quit:
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func newMNGossip(mnsvc *MasternodeService) (*mnGossip, error) {
+	registry, err := energi_abi.NewIMasternodeRegistryV2Caller(
+		energi_params.Nuclear_MasternodeRegistry,
+		energi_common.RevertCaller{ContractCaller: mnsvc.eth.APIBackend})
+	if err != nil {
+		return nil, err
+	}
+
+	g := &mnGossip{
+		mnsvc: mnsvc,
+		mnEnode: func(mn common.Address) ([2][32]byte, error) {
+			mninfo, err := registry.Info(&bind.CallOpts{}, mn)
+			return mninfo.Enode, err
+		},
+		head: func() common.Hash {
+			return mnsvc.eth.BlockChain().CurrentHeader().Hash()
+		},
+		peers: make(map[*p2p.Peer]*mnGossipPeer),
+		enrs:  make(map[common.Address]*enode.Node),
+	}
+
+	if len(mnsvc.addrs) > 0 {
+		g.addrs = []enr.Entry{energi_common.MasternodeIPs(mnsvc.addrs)}
+	}
+
+	return g, nil
+}
+
+func (g *mnGossip) protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:       mnGossipName,
+		Version:    mnGossipVersion,
+		Length:     mnGossipLength,
+		Run:        g.run,
+		Attributes: g.addrs,
+	}
+}
+
+func (g *mnGossip) run(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	server := g.mnsvc.getServer()
+	if server == nil {
+		return errGossipNotStarted
+	}
+
+	gp := newMNGossipPeer(p, rw)
+	quit := make(chan struct{})
+	go gp.loop(quit)
+
+	g.mu.Lock()
+	g.peers[p] = gp
+	known := make([]*enode.Node, 0, len(g.enrs)+1)
+	known = append(known, server.Self())
+	for _, n := range g.enrs {
+		known = append(known, n)
+	}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.peers, p)
+		g.mu.Unlock()
+		close(quit)
+	}()
+
+	for len(known) > 0 {
+		batch := known
+		if len(batch) > maxENRsPerMsg {
+			batch = batch[:maxENRsPerMsg]
+		}
+		known = known[len(batch):]
+
+		if err := p2p.Send(rw, enrMsg, encodeENRs(batch)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		if err := g.handle(gp); err != nil {
+			log.Debug("Masternode gossip failed", "peer", p.ID(), "err", err)
+			return err
+		}
+	}
+}
+
+func (g *mnGossip) handle(gp *mnGossipPeer) error {
+	p := gp.p
+
+	msg, err := gp.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+
+	if msg.Size > mnGossipMaxMsgSize {
+		return errors.New("message is too large")
+	}
+
+	switch msg.Code {
+	case enrMsg:
+		var list []rlp.RawValue
+		if err := msg.Decode(&list); err != nil {
+			return err
+		}
+		if len(list) > maxENRsPerMsg {
+			return errors.New("too many records")
+		}
+		if !gp.allowENR(time.Now()) {
+			log.Trace("Masternode records are sent too often", "peer", p.ID())
+			break
+		}
+
+		updated := make([]*enode.Node, 0, len(list))
+		for _, data := range list {
+			n, err := g.onENR(data)
+			if err != nil {
+				gp.badENRs++
+				if gp.badENRs > mnGossipMaxBadENRs {
+					return err
+				}
+				continue
+			}
+			if n != nil {
+				updated = append(updated, n)
+			}
+		}
+
+		if len(updated) > 0 {
+			g.broadcast(p, enrMsg, encodeENRs(updated))
+		}
+	case cpVoteMsg:
+		cpVotes := g.mnsvc.cpVotes
+		if cpVotes == nil {
+			break
+		}
+
+		var vote checkpointVoteMsg
+		if err := msg.Decode(&vote); err != nil {
+			return err
+		}
+
+		// NOTE: the proposal may be not known yet due to sync lag
+		added, err := cpVotes.add(&vote)
+		if err != nil {
+			log.Debug("Checkpoint vote is rejected", "peer", p.ID(), "cp", vote.Proposal, "err", err)
+			break
+		}
+
+		if added {
+			g.broadcast(p, cpVoteMsg, &vote)
+		}
+	default:
+		return errors.New("unknown message")
+	}
+
+	return nil
+}
+
+// onENR validates a record against the registry and returns it, if it is
+// newer than the already known one. An error is returned for the invalid
+// records and the records of unknown masternodes.
+func (g *mnGossip) onENR(data []byte) (*enode.Node, error) {
+	n, err := energi_common.DecodeMasternodeENR(data)
+	if err != nil {
+		log.Debug("Invalid masternode ENR", "err", err)
+		return nil, errBadMasternodeENR
+	}
+
+	mn := crypto.PubkeyToAddress(*n.Pubkey())
+
+	g.mu.RLock()
+	old, ok := g.enrs[mn]
+	g.mu.RUnlock()
+
+	if ok && old.Seq() >= n.Seq() {
+		return nil, nil
+	}
+
+	if g.isUnknown(mn) {
+		return nil, errBadMasternodeENR
+	}
+
+	pubkey, err := g.mnEnode(mn)
+	if err == energi_common.ErrReverted || (err == nil && !energi_common.IsMasternodeENR(n, pubkey)) {
+		log.Trace("Record of unknown masternode", "mn", mn)
+		g.setUnknown(mn)
+		return nil, errBadMasternodeENR
+	} else if err != nil {
+		log.Debug("Failed to get masternode info", "mn", mn, "err", err)
+		return nil, nil
+	}
+
+	if len(energi_common.MasternodeAddrs(n)) == 0 {
+		log.Debug("Masternode ENR without public address", "mn", mn)
+		return nil, nil
+	}
+
+	g.mu.Lock()
+	if old, ok := g.enrs[mn]; ok && old.Seq() >= n.Seq() {
+		g.mu.Unlock()
+		return nil, nil
+	}
+	g.enrs[mn] = n
+	g.mu.Unlock()
+
+	energi_common.StoreMasternodeENR(g.mnsvc.eth.ChainDb(), mn, n)
+	log.Debug("Updated masternode ENR", "mn", mn, "seq", n.Seq())
+
+	return n, nil
+}
+
+// isUnknown checks if the masternode is known to be not registered as of
+// the current head block.
+func (g *mnGossip) isUnknown(mn common.Address) bool {
+	head := g.head()
+
+	g.unknownMu.Lock()
+	defer g.unknownMu.Unlock()
+
+	if head != g.unknownHead {
+		g.unknownHead = head
+		g.unknown = nil
+	}
+
+	return g.unknown[mn]
+}
+
+func (g *mnGossip) setUnknown(mn common.Address) {
+	g.unknownMu.Lock()
+	defer g.unknownMu.Unlock()
+
+	if g.unknown == nil {
+		g.unknown = make(map[common.Address]bool)
+	}
+	if len(g.unknown) < mnGossipMaxUnknown {
+		g.unknown[mn] = true
+	}
+}
+
+// broadcast relays the message to all peers except the origin.
+func (g *mnGossip) broadcast(from *p2p.Peer, code uint64, payload interface{}) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for p, gp := range g.peers {
+		if p == from {
+			continue
+		}
+
+		if !gp.relay(code, payload) {
+			log.Trace("Masternode gossip queue is full", "peer", p.ID())
+		}
+	}
+}
+
+func encodeENRs(nodes []*enode.Node) []rlp.RawValue {
+	res := make([]rlp.RawValue, 0, len(nodes))
+
+	for _, n := range nodes {
+		data, err := energi_common.EncodeMasternodeENR(n)
+		if err != nil {
+			log.Error("Failed to encode ENR", "err", err)
+			continue
+		}
+		res = append(res, data)
+	}
+
+	return res
+}
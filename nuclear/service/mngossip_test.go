@@ -0,0 +1,181 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/p2p"
+	"nuclear/core/nuclear/p2p/enode"
+	"nuclear/core/nuclear/p2p/enr"
+
+	"github.com/stretchr/testify/assert"
+
+	energi_common "nuclear/core/nuclear/energi/common"
+)
+
+func TestMNGossipRelay(t *testing.T) {
+	t.Parallel()
+
+	origin := p2p.NewPeer(enode.ID{1}, "origin", nil)
+	peer := p2p.NewPeer(enode.ID{2}, "peer", nil)
+	origin_rw, _ := p2p.MsgPipe()
+	peer_rw, remote_rw := p2p.MsgPipe()
+	defer peer_rw.Close()
+
+	g := &mnGossip{
+		peers: map[*p2p.Peer]*mnGossipPeer{
+			origin: newMNGossipPeer(origin, origin_rw),
+			peer:   newMNGossipPeer(peer, peer_rw),
+		},
+	}
+
+	// Queues are bounded and nothing is sent back to the origin
+	for i := 0; i < mnGossipQueueSize+10; i++ {
+		g.broadcast(origin, cpVoteMsg, uint64(i))
+	}
+	assert.Len(t, g.peers[origin].queue, 0)
+	assert.Len(t, g.peers[peer].queue, mnGossipQueueSize)
+	assert.False(t, g.peers[peer].relay(cpVoteMsg, uint64(0)))
+
+	quit := make(chan struct{})
+	defer close(quit)
+	go g.peers[peer].loop(quit)
+
+	for i := 0; i < 3; i++ {
+		msg, err := remote_rw.ReadMsg()
+		assert.Empty(t, err)
+
+		var v uint64
+		assert.Empty(t, msg.Decode(&v))
+		assert.Equal(t, uint64(i), v)
+	}
+}
+
+func testMNRecord(t *testing.T, key *ecdsa.PrivateKey) *enode.Node {
+	var r enr.Record
+	r.SetSeq(1)
+	r.Set(enr.IP(net.ParseIP("1.2.3.4")))
+	r.Set(enr.TCP(49797))
+	assert.NoError(t, enode.SignV4(&r, key))
+
+	n, err := enode.New(enode.ValidSchemes, &r)
+	assert.NoError(t, err)
+	return n
+}
+
+func TestMNGossipENRs(t *testing.T) {
+	t.Parallel()
+
+	registered, _ := crypto.GenerateKey()
+	transient, _ := crypto.GenerateKey()
+	registered_mn := crypto.PubkeyToAddress(registered.PublicKey)
+	transient_mn := crypto.PubkeyToAddress(transient.PublicKey)
+
+	other, _ := crypto.GenerateKey()
+	lookups := 0
+	head := common.HexToHash("0x1")
+
+	g := &mnGossip{
+		mnEnode: func(mn common.Address) ([2][32]byte, error) {
+			lookups++
+			switch mn {
+			case registered_mn:
+				return energi_common.MasternodePubkey(&other.PublicKey), nil
+			case transient_mn:
+				return [2][32]byte{}, errors.New("missing trie node")
+			}
+			return [2][32]byte{}, energi_common.ErrReverted
+		},
+		head:  func() common.Hash { return head },
+		peers: make(map[*p2p.Peer]*mnGossipPeer),
+		enrs:  make(map[common.Address]*enode.Node),
+	}
+
+	encode := func(n *enode.Node) []byte {
+		data, err := energi_common.EncodeMasternodeENR(n)
+		assert.Empty(t, err)
+		return data
+	}
+
+	// Invalid records
+	_, err := g.onENR([]byte{0x01, 0x02})
+	assert.Equal(t, errBadMasternodeENR, err)
+
+	// Unknown masternodes are looked up once per head block
+	unknown, _ := crypto.GenerateKey()
+	data := encode(testMNRecord(t, unknown))
+	for i := 0; i < 3; i++ {
+		n, err := g.onENR(data)
+		assert.Nil(t, n)
+		assert.Equal(t, errBadMasternodeENR, err)
+	}
+	assert.Equal(t, 1, lookups)
+
+	head = common.HexToHash("0x2")
+	_, err = g.onENR(data)
+	assert.Equal(t, errBadMasternodeENR, err)
+	assert.Equal(t, 2, lookups)
+
+	// Records not signed by the registered key
+	_, err = g.onENR(encode(testMNRecord(t, registered)))
+	assert.Equal(t, errBadMasternodeENR, err)
+
+	// Failed lookups are not blamed on the peer
+	n, err := g.onENR(encode(testMNRecord(t, transient)))
+	assert.Nil(t, n)
+	assert.Empty(t, err)
+
+	// Peers sending too many bad records are dropped
+	peer := p2p.NewPeer(enode.ID{1}, "peer", nil)
+	local_rw, remote_rw := p2p.MsgPipe()
+	defer local_rw.Close()
+	gp := newMNGossipPeer(peer, local_rw)
+
+	bad := make([]*enode.Node, mnGossipMaxBadENRs+1)
+	for i := range bad {
+		key, _ := crypto.GenerateKey()
+		bad[i] = testMNRecord(t, key)
+	}
+
+	go p2p.Send(remote_rw, enrMsg, encodeENRs(bad[:mnGossipMaxBadENRs]))
+	assert.Empty(t, g.handle(gp))
+
+	go p2p.Send(remote_rw, enrMsg, encodeENRs(bad[mnGossipMaxBadENRs:]))
+	assert.Equal(t, errBadMasternodeENR, g.handle(gp))
+}
+
+func TestMNGossipENRRate(t *testing.T) {
+	t.Parallel()
+
+	gp := newMNGossipPeer(nil, nil)
+	now := time.Now()
+
+	for i := 0; i < mnGossipMaxENRMsgs; i++ {
+		assert.True(t, gp.allowENR(now))
+	}
+	assert.False(t, gp.allowENR(now))
+	assert.False(t, gp.allowENR(now.Add(mnGossipENRWindow-time.Second)))
+
+	assert.True(t, gp.allowENR(now.Add(mnGossipENRWindow)))
+}
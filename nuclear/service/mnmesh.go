@@ -0,0 +1,247 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/log"
+	"nuclear/core/nuclear/metrics"
+	"nuclear/core/nuclear/p2p/enode"
+
+	energi_common "nuclear/core/nuclear/energi/common"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+const (
+	// DefaultMeshSize is the default number of reserved masternode connections.
+	DefaultMeshSize = 8
+
+	meshRefreshInterval = time.Duration(10) * time.Minute
+)
+
+var (
+	meshSizeGauge      = metrics.NewRegisteredGauge("masternode/mesh/size", nil)
+	meshConnectedGauge = metrics.NewRegisteredGauge("masternode/mesh/connected", nil)
+	meshAddedMeter     = metrics.NewRegisteredMeter("masternode/mesh/added", nil)
+	meshRemovedMeter   = metrics.NewRegisteredMeter("masternode/mesh/removed", nil)
+)
+
+// mnMesh keeps reserved connections to a subset of other active masternodes.
+// Members are added as static trusted peers, so they do not compete for
+// the regular peer slots.
+type mnMesh struct {
+	mnsvc *MasternodeService
+	size  int
+
+	mu          sync.RWMutex
+	members     map[common.Address]*enode.Node
+	nextRefresh time.Time
+}
+
+func newMNMesh(mnsvc *MasternodeService, size int) *mnMesh {
+	return &mnMesh{
+		mnsvc:   mnsvc,
+		size:    size,
+		members: make(map[common.Address]*enode.Node),
+	}
+}
+
+// isMember checks if the node is a part of the mesh.
+func (mm *mnMesh) isMember(id enode.ID) bool {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	for _, n := range mm.members {
+		if n.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// update refreshes the mesh on schedule and reports its health.
+func (mm *mnMesh) update(block *types.Block) {
+	if mm.size <= 0 {
+		return
+	}
+
+	// NOTE: disconnected members are redialled by the server as static peers.
+	if now := time.Now(); now.After(mm.nextRefresh) {
+		if err := mm.refresh(block); err != nil {
+			log.Warn("Masternode mesh refresh failed", "err", err)
+		} else {
+			mm.nextRefresh = now.Add(meshRefreshInterval)
+		}
+	}
+
+	meshSizeGauge.Update(int64(mm.memberCount()))
+	meshConnectedGauge.Update(int64(mm.connected()))
+}
+
+func (mm *mnMesh) refresh(block *types.Block) error {
+	mnsvc := mm.mnsvc
+
+	statedb, err := mnsvc.eth.BlockChain().StateAt(block.Root())
+	if err != nil {
+		return err
+	}
+
+	active, err := mnsvc.registry.EnumerateActive()
+	if err != nil {
+		return err
+	}
+
+	// Only masternodes consensus considers active are taken.
+	candidates := make([]common.Address, 0, len(active))
+	for _, mn := range active {
+		mn_indicator := statedb.GetState(energi_params.Nuclear_MasternodeList, mn.Hash())
+		if (mn_indicator != common.Hash{}) {
+			candidates = append(candidates, mn)
+		}
+	}
+
+	cfg := mnsvc.eth.BlockChain().Config()
+	db := mnsvc.eth.ChainDb()
+	selected := make(map[common.Address]*enode.Node, mm.size)
+
+	for _, mn := range selectMeshPeers(mnsvc.address, candidates) {
+		if len(selected) >= mm.size {
+			break
+		}
+
+		mninfo, err := mnsvc.registry.Info(mn)
+		if err != nil {
+			log.Debug("MNInfo error", "mn", mn, "err", err)
+			continue
+		}
+
+		nodes := energi_common.MasternodeNodes(db, mn, mninfo.Ipv4address, mninfo.Enode, cfg)
+		if len(nodes) == 0 {
+			continue
+		}
+
+		selected[mn] = nodes[0]
+	}
+
+	mm.apply(selected)
+	return nil
+}
+
+func (mm *mnMesh) apply(selected map[common.Address]*enode.Node) {
+	server := mm.mnsvc.getServer()
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	for mn, n := range mm.members {
+		if s, ok := selected[mn]; ok && s.ID() == n.ID() && s.IP().Equal(n.IP()) {
+			continue
+		}
+
+		server.RemoveTrustedPeer(n)
+		server.RemovePeer(n)
+		delete(mm.members, mn)
+		meshRemovedMeter.Mark(1)
+		log.Debug("Masternode mesh peer removed", "mn", mn)
+	}
+
+	for mn, n := range selected {
+		if _, ok := mm.members[mn]; ok {
+			continue
+		}
+
+		server.AddTrustedPeer(n)
+		server.AddPeer(n)
+		mm.members[mn] = n
+		meshAddedMeter.Mark(1)
+		log.Debug("Masternode mesh peer added", "mn", mn, "enode", n)
+	}
+}
+
+// reset drops all mesh connections.
+func (mm *mnMesh) reset() {
+	if mm.memberCount() == 0 {
+		return
+	}
+
+	mm.apply(nil)
+	mm.nextRefresh = time.Time{}
+
+	meshSizeGauge.Update(0)
+	meshConnectedGauge.Update(0)
+}
+
+func (mm *mnMesh) memberCount() int {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return len(mm.members)
+}
+
+func (mm *mnMesh) connected() (res int) {
+	peers := make(map[enode.ID]bool)
+	for _, p := range mm.mnsvc.getServer().Peers() {
+		peers[p.ID()] = true
+	}
+
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	for _, n := range mm.members {
+		if peers[n.ID()] {
+			res++
+		}
+	}
+	return
+}
+
+// selectMeshPeers orders candidates by their distance from the own address.
+// The distance depends on both sides, so every masternode picks its own
+// pseudo-random subset which keeps the mesh as a whole well connected.
+func selectMeshPeers(self common.Address, candidates []common.Address) []common.Address {
+	type entry struct {
+		mn   common.Address
+		dist common.Hash
+	}
+
+	entries := make([]entry, 0, len(candidates))
+	for _, mn := range candidates {
+		if mn == self {
+			continue
+		}
+		entries = append(entries, entry{
+			mn:   mn,
+			dist: crypto.Keccak256Hash(self.Bytes(), mn.Bytes()),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].dist[:], entries[j].dist[:]) < 0
+	})
+
+	res := make([]common.Address, len(entries))
+	for i, e := range entries {
+		res[i] = e.mn
+	}
+	return res
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"math/big"
+	"testing"
+
+	"nuclear/core/nuclear/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectMeshPeers(t *testing.T) {
+	t.Parallel()
+
+	candidates := make([]common.Address, 20)
+	for i := range candidates {
+		candidates[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+
+	self := candidates[3]
+	res := selectMeshPeers(self, candidates)
+	assert.Len(t, res, len(candidates)-1)
+	assert.NotContains(t, res, self)
+
+	// Deterministic regardless of the input order
+	reversed := make([]common.Address, len(candidates))
+	for i, mn := range candidates {
+		reversed[len(candidates)-1-i] = mn
+	}
+	assert.Equal(t, res, selectMeshPeers(self, reversed))
+
+	// Another masternode gets its own order
+	assert.NotEqual(t, res[:5], selectMeshPeers(candidates[4], candidates)[:5])
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package nuclearclient provides a client for the Nuclear specific RPC API.
+package nuclearclient
+
+import (
+	"context"
+	"math/big"
+
+	"nuclear/core/nuclear"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/common/hexutil"
+	"nuclear/core/nuclear/rpc"
+
+	energi_api "nuclear/core/nuclear/energi/api"
+	energi "nuclear/core/nuclear/energi/consensus"
+)
+
+// Client defines typed wrappers for the energi, masternode and staking
+// related miner RPC namespaces.
+type Client struct {
+	c *rpc.Client
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*Client, error) {
+	return DialContext(context.Background(), rawurl)
+}
+
+func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+func (nc *Client) Close() {
+	nc.c.Close()
+}
+
+// Masternodes
+
+// ListMasternodes returns the registered masternodes at the given block.
+// The latest known block is used if number is nil.
+func (nc *Client) ListMasternodes(ctx context.Context, number *big.Int) ([]energi_api.MNInfo, error) {
+	var result []energi_api.MNInfo
+	err := nc.c.CallContext(ctx, &result, "masternode_listMasternodes", toBlockNumArg(number))
+	return result, err
+}
+
+// MasternodeInfo returns the masternode by its address or the owner address.
+func (nc *Client) MasternodeInfo(ctx context.Context, ownerOrMN common.Address) (*energi_api.MNInfo, error) {
+	var result energi_api.MNInfo
+	err := nc.c.CallContext(ctx, &result, "masternode_masternodeInfo", ownerOrMN)
+	if err != nil {
+		return nil, err
+	}
+	if (result.Masternode == common.Address{}) {
+		return nil, ethereum.NotFound
+	}
+	return &result, nil
+}
+
+// MasternodeStats returns the masternode registry statistics at the given
+// block. The latest known block is used if number is nil.
+func (nc *Client) MasternodeStats(ctx context.Context, number *big.Int) (*energi_api.MasternodeStats, error) {
+	var result *energi_api.MasternodeStats
+	err := nc.c.CallContext(ctx, &result, "masternode_stats", toBlockNumArg(number))
+	if err == nil && result == nil {
+		return nil, ethereum.NotFound
+	}
+	return result, err
+}
+
+// MasternodeEvents returns the recent masternode lifecycle events, optionally
+// of a single owner.
+func (nc *Client) MasternodeEvents(ctx context.Context, owner *common.Address) ([]energi_api.MasternodeEvent, error) {
+	var result []energi_api.MasternodeEvent
+	err := nc.c.CallContext(ctx, &result, "masternode_masternodeEvents", owner)
+	return result, err
+}
+
+// SubscribeMasternodeLifecycle subscribes to notifications about the
+// masternode lifecycle events.
+func (nc *Client) SubscribeMasternodeLifecycle(ctx context.Context, ch chan<- *energi_api.MasternodeEvent) (ethereum.Subscription, error) {
+	return nc.c.Subscribe(ctx, "masternode", ch, "lifecycle")
+}
+
+// Governance
+
+// BlacklistInfo returns the blacklist proposals at the given block.
+// The latest known block is used if number is nil.
+func (nc *Client) BlacklistInfo(ctx context.Context, number *big.Int) ([]energi_api.BLInfo, error) {
+	var result []energi_api.BLInfo
+	err := nc.c.CallContext(ctx, &result, "energi_blacklistInfo", toBlockNumArg(number))
+	return result, err
+}
+
+// CompensationInfo returns the compensation fund state at the given block.
+// The latest known block is used if number is nil.
+func (nc *Client) CompensationInfo(ctx context.Context, number *big.Int) (*energi_api.BudgetInfo, error) {
+	return nc.budgetInfo(ctx, "energi_compensationInfo", number)
+}
+
+// CheckpointInfo returns the registry and the active checkpoints at the given
+// block. The latest known block is used if number is nil.
+func (nc *Client) CheckpointInfo(ctx context.Context, number *big.Int) (*energi_api.AllCheckpointInfo, error) {
+	var result *energi_api.AllCheckpointInfo
+	err := nc.c.CallContext(ctx, &result, "energi_checkpointInfo", toBlockNumArg(number))
+	if err == nil && result == nil {
+		return nil, ethereum.NotFound
+	}
+	return result, err
+}
+
+// UpgradeInfo returns the upgrade proposals of the system contracts at the
+// given block. The latest known block is used if number is nil.
+func (nc *Client) UpgradeInfo(ctx context.Context, number *big.Int) (*energi_api.UpgradeProposals, error) {
+	var result *energi_api.UpgradeProposals
+	err := nc.c.CallContext(ctx, &result, "energi_upgradeInfo", toBlockNumArg(number))
+	if err == nil && result == nil {
+		return nil, ethereum.NotFound
+	}
+	return result, err
+}
+
+// BudgetInfo returns the treasury state at the given block.
+// The latest known block is used if number is nil.
+func (nc *Client) BudgetInfo(ctx context.Context, number *big.Int) (*energi_api.BudgetInfo, error) {
+	return nc.budgetInfo(ctx, "energi_budgetInfo", number)
+}
+
+func (nc *Client) budgetInfo(ctx context.Context, method string, number *big.Int) (*energi_api.BudgetInfo, error) {
+	var result *energi_api.BudgetInfo
+	err := nc.c.CallContext(ctx, &result, method, toBlockNumArg(number))
+	if err == nil && result == nil {
+		return nil, ethereum.NotFound
+	}
+	return result, err
+}
+
+// Migration
+
+// ListGen2Coins returns the Gen 2 coins pending migration at the given block.
+// The latest known block is used if number is nil.
+func (nc *Client) ListGen2Coins(ctx context.Context, number *big.Int) ([]energi_api.Gen2Coin, error) {
+	var result []energi_api.Gen2Coin
+	err := nc.c.CallContext(ctx, &result, "energi_listGen2Coins", toBlockNumArg(number))
+	return result, err
+}
+
+// Staking
+
+// StakingStatus returns the staking status of the node.
+func (nc *Client) StakingStatus(ctx context.Context) (*energi.StakingStatusInfo, error) {
+	var result *energi.StakingStatusInfo
+	err := nc.c.CallContext(ctx, &result, "miner_stakingStatus")
+	if err == nil && result == nil {
+		return nil, ethereum.NotFound
+	}
+	return result, err
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
@@ -0,0 +1,351 @@
+// Copyright 2020 The Nuclear Core Authors
+// This file is part of the Nuclear Core library.
+//
+// The Nuclear Core library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Nuclear Core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Nuclear Core library. If not, see <http://www.gnu.org/licenses/>.
+
+package nuclearclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"nuclear/core/nuclear"
+	"nuclear/core/nuclear/accounts/abi/bind"
+	"nuclear/core/nuclear/accounts/keystore"
+	"nuclear/core/nuclear/common"
+	"nuclear/core/nuclear/core"
+	"nuclear/core/nuclear/core/types"
+	"nuclear/core/nuclear/crypto"
+	"nuclear/core/nuclear/eth"
+	"nuclear/core/nuclear/eth/downloader"
+	"nuclear/core/nuclear/ethclient"
+	"nuclear/core/nuclear/node"
+	"nuclear/core/nuclear/p2p"
+
+	energi_abi "nuclear/core/nuclear/energi/abi"
+	energi_api "nuclear/core/nuclear/energi/api"
+	energi_testutils "nuclear/core/nuclear/energi/common/testutils"
+	energi_params "nuclear/core/nuclear/energi/params"
+)
+
+const (
+	testPass = "secret-pass"
+
+	// Max interval for a transaction to get into a staked block
+	miningInterval = 4 * time.Minute
+)
+
+var (
+	testBalance    = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(1e18))
+	testCollateral = new(big.Int).Mul(big.NewInt(10000), big.NewInt(1e18))
+)
+
+type testNode struct {
+	stack      *node.Node
+	peer       *node.Node
+	migrations *energi_testutils.TestGen2Migration
+	client     *Client
+	eth        *ethclient.Client
+	owner      *ecdsa.PrivateKey
+	mn         common.Address
+}
+
+// newTestNode starts an in-process node staking its own blocks with the
+// Nuclear engine. Another node is connected as its peer.
+func newTestNode(t *testing.T) *testNode {
+	migration_key, _ := crypto.GenerateKey()
+	staker_key, _ := crypto.GenerateKey()
+	owner_key, _ := crypto.GenerateKey()
+	mn_key, _ := crypto.GenerateKey()
+	migration_signer := crypto.PubkeyToAddress(migration_key.PublicKey)
+
+	genesis := core.DefaultNuclearTestnetGenesisBlock()
+	chainConfig := *genesis.Config
+	nrgConfig := *chainConfig.Nuclear
+	nrgConfig.MigrationSigner = migration_signer
+	nrgConfig.CPPSigner = migration_signer
+	chainConfig.Nuclear = &nrgConfig
+
+	genesis.Config = &chainConfig
+	genesis.GasLimit = 40000000
+	genesis.Difficulty = big.NewInt(1)
+	genesis.Timestamp = 12900000
+	genesis.Coinbase = migration_signer
+	genesis.Alloc = core.DefaultPrealloc()
+	for _, key := range []*ecdsa.PrivateKey{migration_key, staker_key, owner_key} {
+		genesis.Alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: testBalance}
+	}
+	genesis.Xfers = core.DeployNuclearGovernance(&chainConfig)
+
+	migrations := energi_testutils.NewTestGen2Migration()
+	if err := migrations.PrepareTestGen2Migration(chainConfig.ChainID.Uint64()); err != nil {
+		t.Fatal(err)
+	}
+
+	stack := startTestStack(t, genesis, migrations.TempFileName())
+	peer := startTestStack(t, genesis, "")
+
+	// The PoS miner does not work without peers
+	stack.Server().AddPeer(peer.Server().Self())
+	for deadline := time.Now().Add(time.Minute); stack.Server().PeerCount() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("peer is not connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var ethServ *eth.Ethereum
+	if err := stack.Service(&ethServ); err != nil {
+		t.Fatal(err)
+	}
+
+	store := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	for _, key := range []*ecdsa.PrivateKey{migration_key, staker_key} {
+		account, err := store.ImportECDSA(key, testPass)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Unlock(account, testPass, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ethServ.AddDPoS(energi_params.Nuclear_MigrationContract, migration_signer)
+
+	if err := ethServ.StartMining(1); err != nil {
+		t.Fatal(err)
+	}
+	// There is nothing to sync from the peer at genesis
+	ethServ.EventMux().Post(downloader.DoneEvent{})
+
+	rpcClient, err := stack.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testNode{
+		stack:      stack,
+		peer:       peer,
+		migrations: migrations,
+		client:     NewClient(rpcClient),
+		eth:        ethclient.NewClient(rpcClient),
+		owner:      owner_key,
+		mn:         crypto.PubkeyToAddress(mn_key.PublicKey),
+	}
+}
+
+// startTestStack starts a local node of the genesis.
+func startTestStack(t *testing.T, genesis *core.Genesis, migration string) *node.Node {
+	// Ephemeral nodes would persist the pool protection data into the
+	// working directory.
+	datadir, err := ioutil.TempDir("", "nuclearclient-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node_key, _ := crypto.GenerateKey()
+	stack, err := node.New(&node.Config{
+		DataDir: datadir,
+		P2P: p2p.Config{
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			MaxPeers:    1,
+			PrivateKey:  node_key,
+		},
+		NoUSB:             true,
+		UseLightweightKDF: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ethConfig := eth.DefaultConfig
+	ethConfig.Genesis = genesis
+	ethConfig.MinerMigration = migration
+	ethConfig.MinerRecommit = time.Second
+	err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return eth.New(ctx, &ethConfig)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stack.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	return stack
+}
+
+func (n *testNode) close() {
+	n.client.Close()
+	for _, stack := range []*node.Node{n.stack, n.peer} {
+		stack.Stop()
+		os.RemoveAll(stack.DataDir())
+	}
+	n.migrations.CleanUp()
+}
+
+func (n *testNode) waitMined(t *testing.T, tx *types.Transaction) {
+	ctx, cancel := context.WithTimeout(context.Background(), miningInterval)
+	defer cancel()
+
+	receipt, err := bind.WaitMined(ctx, n.eth, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
+
+// waitEvents collects the first lifecycle event of every kind.
+func (n *testNode) waitEvents(t *testing.T, ch <-chan *energi_api.MasternodeEvent, kinds ...string) map[string]*energi_api.MasternodeEvent {
+	res := make(map[string]*energi_api.MasternodeEvent)
+	deadline := time.After(miningInterval)
+	for len(res) < len(kinds) {
+		select {
+		case ev := <-ch:
+			for _, kind := range kinds {
+				if ev.Kind == kind && res[kind] == nil {
+					res[kind] = ev
+				}
+			}
+		case <-deadline:
+			t.Fatalf("no %v events received", kinds)
+		}
+	}
+	return res
+}
+
+func TestNuclearClient(t *testing.T) {
+	n := newTestNode(t)
+	defer n.close()
+
+	client := n.client
+	ctx := context.Background()
+	owner := crypto.PubkeyToAddress(n.owner.PublicKey)
+
+	// Nothing is registered at genesis
+	mns, err := client.ListMasternodes(ctx, big.NewInt(0))
+	assert.Empty(t, err)
+	assert.Empty(t, mns)
+
+	_, err = client.MasternodeInfo(ctx, owner)
+	assert.Equal(t, ethereum.NotFound, err)
+
+	_, err = client.ListMasternodes(ctx, big.NewInt(1000000))
+	assert.Error(t, err)
+
+	// Lifecycle of a watched owner
+	ch := make(chan *energi_api.MasternodeEvent, 16)
+	sub, err := client.SubscribeMasternodeLifecycle(ctx, ch)
+	assert.Empty(t, err)
+	defer sub.Unsubscribe()
+	assert.Empty(t, client.c.Call(nil, "admin_watchMasternode", owner))
+
+	token, err := energi_abi.NewIMasternodeTokenTransactor(energi_params.Nuclear_MasternodeToken, n.eth)
+	assert.Empty(t, err)
+	opts := bind.NewKeyedTransactor(n.owner)
+	opts.Value = testCollateral
+	deposit_tx, err := token.DepositCollateral(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Staked blocks are slow, so both go into the same block
+	registry, err := energi_abi.NewIMasternodeRegistryV2Transactor(energi_params.Nuclear_MasternodeRegistry, n.eth)
+	assert.Empty(t, err)
+	opts = bind.NewKeyedTransactor(n.owner)
+	opts.GasLimit = 1000000 // there is no collateral to estimate against yet
+	announce_tx, err := registry.Announce(opts, n.mn, uint32(130<<24|1), [2][32]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.waitMined(t, deposit_tx)
+	n.waitMined(t, announce_tx)
+
+	evs := n.waitEvents(t, ch, energi_api.MNEventCollateral, energi_api.MNEventAnnounced)
+	assert.Equal(t, owner, evs[energi_api.MNEventCollateral].Owner)
+	assert.Equal(t, testCollateral, evs[energi_api.MNEventCollateral].Balance.ToInt())
+	assert.Equal(t, n.mn, evs[energi_api.MNEventAnnounced].Masternode)
+
+	events, err := client.MasternodeEvents(ctx, &owner)
+	assert.Empty(t, err)
+	assert.NotEmpty(t, events)
+
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	events, err = client.MasternodeEvents(ctx, &other)
+	assert.Empty(t, err)
+	assert.Empty(t, events)
+
+	// Masternode queries
+	mns, err = client.ListMasternodes(ctx, nil)
+	assert.Empty(t, err)
+	assert.Len(t, mns, 1)
+	assert.Equal(t, n.mn, mns[0].Masternode)
+	assert.Equal(t, owner, mns[0].Owner)
+	assert.Equal(t, testCollateral, mns[0].Collateral.ToInt())
+
+	info, err := client.MasternodeInfo(ctx, owner)
+	assert.Empty(t, err)
+	assert.Equal(t, n.mn, info.Masternode)
+
+	stats, err := client.MasternodeStats(ctx, nil)
+	assert.Empty(t, err)
+	assert.Equal(t, uint64(1), stats.Total)
+
+	stats, err = client.MasternodeStats(ctx, big.NewInt(0))
+	assert.Empty(t, err)
+	assert.Equal(t, uint64(0), stats.Total)
+
+	// Governance
+	bl, err := client.BlacklistInfo(ctx, nil)
+	assert.Empty(t, err)
+	assert.Empty(t, bl)
+
+	_, err = client.CompensationInfo(ctx, nil)
+	assert.Empty(t, err)
+
+	cps, err := client.CheckpointInfo(ctx, big.NewInt(0))
+	assert.Empty(t, err)
+	assert.Empty(t, cps.Registry)
+
+	upgrades, err := client.UpgradeInfo(ctx, nil)
+	assert.Empty(t, err)
+	assert.Empty(t, upgrades.Treasury)
+
+	budget, err := client.BudgetInfo(ctx, nil)
+	assert.Empty(t, err)
+	assert.Empty(t, budget.Proposals)
+
+	// Migration and staking
+	coins, err := client.ListGen2Coins(ctx, big.NewInt(0))
+	assert.Empty(t, err)
+	assert.Empty(t, coins)
+
+	coins, err = client.ListGen2Coins(ctx, nil)
+	assert.Empty(t, err)
+	assert.NotEmpty(t, coins)
+
+	status, err := client.StakingStatus(ctx)
+	assert.Empty(t, err)
+	assert.True(t, status.Miner)
+	assert.True(t, status.Staking)
+	assert.NotEmpty(t, status.Accounts)
+}
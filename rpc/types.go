@@ -17,12 +17,14 @@
 package rpc
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
 	"strings"
 	"sync"
 
+	"nuclear/core/nuclear/common"
 	"nuclear/core/nuclear/common/hexutil"
 	mapset "github.com/deckarep/golang-set"
 )
@@ -163,3 +165,72 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
+
+// BlockNumberOrHash selects a block either by number or by hash.
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+}
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumberOrHash. It
+// supports an object with either of the fields, a block hash, or anything
+// accepted by BlockNumber.
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	type erased BlockNumberOrHash
+	e := erased{}
+	if err := json.Unmarshal(data, &e); err == nil {
+		if e.BlockNumber != nil && e.BlockHash != nil {
+			return fmt.Errorf("cannot specify both BlockHash and BlockNumber, choose one or the other")
+		}
+		bnh.BlockNumber = e.BlockNumber
+		bnh.BlockHash = e.BlockHash
+		return nil
+	}
+
+	var input string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	if len(input) == 2+2*common.HashLength {
+		hash := common.Hash{}
+		if err := hash.UnmarshalText([]byte(input)); err != nil {
+			return err
+		}
+		bnh.BlockHash = &hash
+		return nil
+	}
+
+	var bn BlockNumber
+	if err := bn.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	bnh.BlockNumber = &bn
+	return nil
+}
+
+// Number returns the block number, if selected by number.
+func (bnh *BlockNumberOrHash) Number() (BlockNumber, bool) {
+	if bnh.BlockNumber != nil {
+		return *bnh.BlockNumber, true
+	}
+	return BlockNumber(0), false
+}
+
+// Hash returns the block hash, if selected by hash.
+func (bnh *BlockNumberOrHash) Hash() (common.Hash, bool) {
+	if bnh.BlockHash != nil {
+		return *bnh.BlockHash, true
+	}
+	return common.Hash{}, false
+}
+
+// BlockNumberOrHashWithNumber selects the block by number.
+func BlockNumberOrHashWithNumber(blockNr BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &blockNr}
+}
+
+// BlockNumberOrHashWithHash selects the block by hash.
+func BlockNumberOrHashWithHash(hash common.Hash) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockHash: &hash}
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"nuclear/core/nuclear/common"
 	"nuclear/core/nuclear/common/math"
 )
 
@@ -64,3 +65,50 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockNumberOrHashJSONUnmarshal(t *testing.T) {
+	hash := common.HexToHash("0x1122334455667788990011223344556677889900112233445566778899001122")
+
+	tests := []struct {
+		input    string
+		mustFail bool
+		expected BlockNumberOrHash
+	}{
+		0:  {`"0x"`, true, BlockNumberOrHash{}},
+		1:  {`"0x0"`, false, BlockNumberOrHashWithNumber(0)},
+		2:  {`"0x12"`, false, BlockNumberOrHashWithNumber(18)},
+		3:  {`"0x8000000000000000"`, true, BlockNumberOrHash{}},
+		4:  {`"latest"`, false, BlockNumberOrHashWithNumber(LatestBlockNumber)},
+		5:  {`"pending"`, false, BlockNumberOrHashWithNumber(PendingBlockNumber)},
+		6:  {`"` + hash.Hex() + `"`, false, BlockNumberOrHashWithHash(hash)},
+		7:  {`"0x11223344556677889900112233445566778899001122334455667788990011zz"`, true, BlockNumberOrHash{}},
+		8:  {`{"blockNumber":"0x1"}`, false, BlockNumberOrHashWithNumber(1)},
+		9:  {`{"blockHash":"` + hash.Hex() + `"}`, false, BlockNumberOrHashWithHash(hash)},
+		10: {`{"blockNumber":"0x1","blockHash":"` + hash.Hex() + `"}`, true, BlockNumberOrHash{}},
+		11: {`someString`, true, BlockNumberOrHash{}},
+	}
+
+	for i, test := range tests {
+		var bnh BlockNumberOrHash
+		err := json.Unmarshal([]byte(test.input), &bnh)
+		if test.mustFail && err == nil {
+			t.Errorf("Test %d should fail", i)
+			continue
+		}
+		if !test.mustFail && err != nil {
+			t.Errorf("Test %d should pass but got err: %v", i, err)
+			continue
+		}
+		if test.mustFail {
+			continue
+		}
+
+		num, isNum := bnh.Number()
+		expNum, expIsNum := test.expected.Number()
+		h, isHash := bnh.Hash()
+		expHash, expIsHash := test.expected.Hash()
+		if isNum != expIsNum || num != expNum || isHash != expIsHash || h != expHash {
+			t.Errorf("Test %d got unexpected value, want %v, got %v", i, test.expected, bnh)
+		}
+	}
+}